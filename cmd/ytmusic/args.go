@@ -108,6 +108,9 @@ func parseArgs() (config.Config, string, error) {
 			i++
 			cfg.ImportOnly = config.ExpandHome(args[i])
 
+		case "--interactive", "-i":
+			cfg.Interactive = true
+
 		case "--config", "-c":
 			i++
 
@@ -160,10 +163,11 @@ func printUsage() {
 	fmt.Println("ytmusic - Download YouTube playlists with automatic metadata tagging")
 	fmt.Println()
 	fmt.Println("Usage: ytmusic [options] <playlist_url>")
+	fmt.Println("       ytmusic undo [<run-id> [file|dir ...]]")
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -v, --verbose              Show detailed output")
-	fmt.Println("  -n, --dry-run              Preview what would be downloaded (no actual download)")
+	fmt.Println("  -n, --dry-run              Preview what would be downloaded, or with --import-only the tag changes")
 	fmt.Println("  -p, --parallel <n>         Number of parallel downloads (1-10, default: 4)")
 	fmt.Println("  -b, --browser <name>       Browser to extract cookies from (default: brave)")
 	fmt.Println("  -f, --format <format>      Audio format: mp3, m4a, opus, flac, etc. (default: mp3)")
@@ -171,9 +175,14 @@ func printUsage() {
 	fmt.Println("      --no-lyrics            Skip lyrics fetching")
 	fmt.Println("      --lyrics-only <dir>    Fetch lyrics only for existing files in directory")
 	fmt.Println("      --import-only <dir>    Resolve metadata and lyrics for existing files (no download)")
+	fmt.Println("  -i, --interactive          Review low-confidence matches instead of keeping YouTube tags")
 	fmt.Println("  -c, --config <path>        Path to config file")
 	fmt.Println("  -h, --help                 Show this help message")
 	fmt.Println()
+	fmt.Println("Undo:")
+	fmt.Println("  undo                       List import runs with a tag backup")
+	fmt.Println("  undo <run-id> [paths...]   Restore the original tags of a run, for all or the given files")
+	fmt.Println()
 	fmt.Println("Configuration:")
 	fmt.Println("  --init-config              Create a default config file")
 	fmt.Println()
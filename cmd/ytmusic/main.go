@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"ytmusic/internal/backup"
 	"ytmusic/internal/config"
 	"ytmusic/internal/logger"
 	"ytmusic/internal/pipeline"
@@ -15,6 +16,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "undo" {
+		if err := runUndo(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfg, configPath, err := parseArgs()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
@@ -28,12 +37,19 @@ func main() {
 	log := logger.New(cfg.Verbose)
 	defer log.Close()
 
-	if !cfg.Verbose {
-		logDir := config.GetDefaultLogPath()
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "[WARN] Failed to create log directory: %v\n", err)
-		} else {
-			logFile := filepath.Join(logDir, fmt.Sprintf("ytmusic_%s.log", time.Now().Format("2006-01-02_15-04-05")))
+	// The run log, match report and tag backup share the run ID so they can be
+	// matched up later.
+	runID := backup.FreeID(config.GetDefaultBackupPath(), time.Now().Format(config.RunIDFormat))
+	runName := "ytmusic_" + runID
+	var reportPath string
+
+	logDir := config.GetDefaultLogPath()
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to create log directory: %v\n", err)
+	} else {
+		reportPath = config.GetReportPath(runID)
+		if !cfg.Verbose {
+			logFile := filepath.Join(logDir, runName+".log")
 			if err := log.SetFileLog(logFile); err != nil {
 				fmt.Fprintf(os.Stderr, "[WARN] Failed to setup file logging: %v\n", err)
 			} else {
@@ -53,7 +69,14 @@ func main() {
 	}
 
 	if cfg.ImportOnly != "" {
-		if err := pipeline.RunImportOnly(sh.Context(), cfg, log, cfg.ImportOnly); err != nil {
+		hooks := pipeline.Hooks{ReportPath: reportPath}
+		if !cfg.DryRun {
+			hooks.Backup = backup.New(config.GetDefaultBackupPath(), runID)
+		}
+		if cfg.Interactive {
+			hooks.Reviewer = newTerminalReviewer(os.Stdin, os.Stdout)
+		}
+		if err := pipeline.RunImportOnly(sh.Context(), cfg, log, cfg.ImportOnly, hooks); err != nil {
 			log.Error("%v", err)
 			os.Exit(1)
 		}
@@ -66,13 +89,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := run(sh, cfg, log); err != nil {
+	if err := run(sh, cfg, log, reportPath); err != nil {
 		log.Error("%v", err)
 		os.Exit(1)
 	}
 }
 
-func run(sh *shutdown.Handler, cfg config.Config, log *logger.Logger) error {
+func run(sh *shutdown.Handler, cfg config.Config, log *logger.Logger, reportPath string) error {
 	log.Debug("Checking dependencies...")
 	if err := utils.CheckDependencies(); err != nil {
 		return fmt.Errorf("dependency check failed: %w", err)
@@ -104,6 +127,17 @@ func run(sh *shutdown.Handler, cfg config.Config, log *logger.Logger) error {
 				bar.Increment()
 			}
 		},
+		ReportPath: reportPath,
+	}
+	if cfg.Interactive {
+		reviewer := newTerminalReviewer(os.Stdin, os.Stdout)
+		reviewer.beforePrompt = func() {
+			if bar != nil {
+				bar.Finish()
+				log.SetProgressBar(false)
+			}
+		}
+		hooks.Reviewer = reviewer
 	}
 
 	err = pipeline.Run(sh.Context(), cfg, log, tmpDir, hooks)
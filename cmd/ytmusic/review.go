@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ytmusic/internal/metadata"
+)
+
+// maxReviewCandidates caps how many candidates are listed per prompt.
+const maxReviewCandidates = 10
+
+// terminalReviewer implements metadata.Reviewer by prompting on the terminal.
+type terminalReviewer struct {
+	in  *bufio.Reader
+	out io.Writer
+	// beforePrompt runs before each prompt, e.g. to finish a progress bar.
+	beforePrompt func()
+}
+
+func newTerminalReviewer(in io.Reader, out io.Writer) *terminalReviewer {
+	return &terminalReviewer{in: bufio.NewReader(in), out: out}
+}
+
+// Review lists the candidates and reads the user's choice:
+// a number picks a candidate, "s" skips, "m" starts a manual search, and a
+// trailing "a" ("2a", "sa") applies the choice to the rest of the album.
+// End of input skips the file.
+func (t *terminalReviewer) Review(ctx context.Context, req metadata.ReviewRequest) (metadata.ReviewDecision, error) {
+	if err := ctx.Err(); err != nil {
+		return metadata.ReviewDecision{}, err
+	}
+	if t.beforePrompt != nil {
+		t.beforePrompt()
+	}
+
+	t.printRequest(req)
+	shown := len(req.Candidates)
+	if shown > maxReviewCandidates {
+		shown = maxReviewCandidates
+	}
+
+	for {
+		prompt := "[s]kip, [m]anual search"
+		if shown > 0 {
+			prompt = fmt.Sprintf("[1-%d] pick, %s", shown, prompt)
+		}
+		fmt.Fprintf(t.out, "%s; add 'a' to apply to the whole album: ", prompt)
+
+		line, err := t.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(t.out)
+			return metadata.ReviewDecision{Action: metadata.ReviewSkip}, nil
+		}
+
+		answer := strings.ToLower(strings.TrimSpace(line))
+		applyToAlbum := false
+		if len(answer) > 1 && strings.HasSuffix(answer, "a") {
+			applyToAlbum = true
+			answer = strings.TrimSuffix(answer, "a")
+		}
+
+		switch {
+		case answer == "s" || answer == "":
+			return metadata.ReviewDecision{Action: metadata.ReviewSkip, ApplyToAlbum: applyToAlbum}, nil
+
+		case answer == "m":
+			return metadata.ReviewDecision{Action: metadata.ReviewSearch, Query: t.readQuery(req.Query)}, nil
+
+		default:
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 || n > shown {
+				fmt.Fprintln(t.out, "Invalid choice.")
+				continue
+			}
+			return metadata.ReviewDecision{Action: metadata.ReviewPick, Choice: n - 1, ApplyToAlbum: applyToAlbum}, nil
+		}
+	}
+}
+
+func (t *terminalReviewer) printRequest(req metadata.ReviewRequest) {
+	fmt.Fprintln(t.out)
+	fmt.Fprintf(t.out, "Low-confidence match: %s\n", filepath.Base(req.Path))
+	fmt.Fprintf(t.out, "  Searched: %q by %q", req.Query.Title, req.Query.Artist)
+	if req.Query.Album != "" {
+		fmt.Fprintf(t.out, " on %q", req.Query.Album)
+	}
+	if req.Duration > 0 {
+		fmt.Fprintf(t.out, " [%s]", formatDuration(req.Duration))
+	}
+	fmt.Fprintln(t.out)
+
+	if len(req.Candidates) == 0 {
+		fmt.Fprintln(t.out, "  No candidates found.")
+		return
+	}
+	for i, c := range req.Candidates {
+		if i == maxReviewCandidates {
+			break
+		}
+		fmt.Fprintf(t.out, "  %2d. %.2f  %-11s %q by %q", i+1, c.Info.Confidence, c.Provider, c.Info.Title, c.Info.Artist)
+		if c.Info.Album != "" {
+			fmt.Fprintf(t.out, " on %q", c.Info.Album)
+		}
+		if c.Info.Year > 0 {
+			fmt.Fprintf(t.out, " (%d)", c.Info.Year)
+		}
+		if c.Info.Duration > 0 {
+			fmt.Fprintf(t.out, " [%s]", formatDuration(c.Info.Duration))
+		}
+		fmt.Fprintln(t.out)
+	}
+}
+
+// readQuery asks for a manual title and artist; empty answers keep the
+// current values.
+func (t *terminalReviewer) readQuery(current metadata.SearchQuery) metadata.SearchQuery {
+	title, artist := current.Title, current.Artist
+	fmt.Fprintf(t.out, "Title [%s]: ", title)
+	if line, _ := t.in.ReadString('\n'); strings.TrimSpace(line) != "" {
+		title = strings.TrimSpace(line)
+	}
+	fmt.Fprintf(t.out, "Artist [%s]: ", artist)
+	if line, _ := t.in.ReadString('\n'); strings.TrimSpace(line) != "" {
+		artist = strings.TrimSpace(line)
+	}
+	q := metadata.NormalizeQuery(title, artist)
+	q.Album = current.Album
+	return q
+}
+
+func formatDuration(d time.Duration) string {
+	secs := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"ytmusic/internal/backup"
+	"ytmusic/internal/config"
+)
+
+// runUndo implements `ytmusic undo [<run-id> [file|dir ...]]`. Without a run
+// ID it lists the runs that can be undone; with paths it restores only the
+// backed-up files at or below them.
+func runUndo(args []string, out io.Writer) error {
+	root := config.GetDefaultBackupPath()
+
+	if len(args) == 0 {
+		ids, err := backup.List(root)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			fmt.Fprintln(out, "No runs to undo.")
+			return nil
+		}
+		fmt.Fprintln(out, "Runs with a tag backup:")
+		for _, id := range ids {
+			b, err := backup.Open(root, id)
+			if err != nil {
+				fmt.Fprintf(out, "  %s  (%v)\n", id, err)
+				continue
+			}
+			fmt.Fprintf(out, "  %s  %d files\n", id, len(b.Entries()))
+		}
+		return nil
+	}
+
+	b, err := backup.Open(root, args[0])
+	if err != nil {
+		return err
+	}
+
+	entries, err := selectEntries(b.Entries(), args[1:])
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, e := range entries {
+		if err := b.Restore(e); err != nil {
+			fmt.Fprintf(out, "[WARN] %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Restored %s\n", e.Path)
+	}
+
+	fmt.Fprintf(out, "Restored %d of %d files from run %s\n", len(entries)-failed, len(entries), b.ID)
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be restored", failed)
+	}
+	return nil
+}
+
+// selectEntries returns the entries at or below paths, or all entries when
+// paths is empty. A path matching no backed-up file is an error.
+func selectEntries(entries []backup.Entry, paths []string) ([]backup.Entry, error) {
+	if len(paths) == 0 {
+		return entries, nil
+	}
+
+	var selected []backup.Entry
+	taken := make(map[string]bool)
+	for _, p := range paths {
+		abs, err := filepath.Abs(config.ExpandHome(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", p, err)
+		}
+		var matched bool
+		for _, e := range entries {
+			if e.Path != abs && !strings.HasPrefix(e.Path, abs+string(filepath.Separator)) {
+				continue
+			}
+			matched = true
+			if !taken[e.Path] {
+				taken[e.Path] = true
+				selected = append(selected, e)
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%s is not in this backup", p)
+		}
+	}
+	return selected, nil
+}
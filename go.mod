@@ -7,6 +7,7 @@ toolchain go1.23.5
 require (
 	github.com/gorilla/websocket v1.5.3
 	go.senan.xyz/taglib v0.11.1
+	golang.org/x/text v0.23.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
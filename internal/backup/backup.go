@@ -0,0 +1,306 @@
+// Package backup snapshots the tags of files an import run is about to
+// rewrite, so the run can be undone later.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+
+	"go.senan.xyz/taglib"
+)
+
+const manifestName = "manifest.json"
+
+// Entry is the state of one file before the run touched it.
+type Entry struct {
+	Path    string              `json:"path"`
+	Tags    map[string][]string `json:"tags"`
+	Artwork string              `json:"artwork,omitempty"` // SHA-256 of the embedded picture, empty if none
+}
+
+// Run is the backup of a single run, stored as <root>/<id>/manifest.json with
+// artwork kept once per hash under <root>/<id>/artwork. Safe for concurrent use.
+type Run struct {
+	ID  string
+	dir string
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+	order   []string
+}
+
+// New creates an empty backup for run id under root. Nothing is written to
+// disk until the first Snapshot.
+func New(root, id string) *Run {
+	return &Run{
+		ID:      id,
+		dir:     filepath.Join(root, id),
+		entries: make(map[string]*Entry),
+	}
+}
+
+// FreeID returns id, or id with the first "-N" suffix that is free, when root
+// already holds a backup named id. Runs started within the same second would
+// otherwise share a backup.
+func FreeID(root, id string) string {
+	free := id
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(root, free)); errors.Is(err, os.ErrNotExist) {
+			return free
+		}
+		free = fmt.Sprintf("%s-%d", id, n)
+	}
+}
+
+// Open loads the backup of run id from root.
+func Open(root, id string) (*Run, error) {
+	b := New(root, id)
+	data, err := os.ReadFile(filepath.Join(b.dir, manifestName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no backup found for run %q", id)
+		}
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest for run %q: %w", id, err)
+	}
+	for _, e := range entries {
+		b.entries[e.Path] = e
+		b.order = append(b.order, e.Path)
+	}
+	return b, nil
+}
+
+// List returns the IDs of the runs backed up under root, oldest first.
+func List(root string) ([]string, error) {
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	var ids []string
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, d.Name(), manifestName)); err == nil {
+			ids = append(ids, d.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Snapshot records the current tags and artwork of path. Later snapshots of
+// the same path are ignored, so the backup always holds the state from before
+// the run.
+func (b *Run) Snapshot(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	b.mu.Lock()
+	_, seen := b.entries[path]
+	b.mu.Unlock()
+	if seen {
+		return nil
+	}
+
+	tags, err := taglib.ReadTags(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tags of %s: %w", path, err)
+	}
+	img, err := taglib.ReadImage(path)
+	if err != nil {
+		return fmt.Errorf("failed to read artwork of %s: %w", path, err)
+	}
+
+	e := &Entry{Path: path, Tags: tags}
+	if len(img) > 0 {
+		e.Artwork = hashImage(img)
+		if err := b.storeArtwork(e.Artwork, img); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, seen := b.entries[path]; !seen {
+		b.entries[path] = e
+		b.order = append(b.order, path)
+	}
+	return nil
+}
+
+// Prune drops entries whose file still has the snapshotted tags and artwork,
+// leaving only the files the run actually changed.
+func (b *Run) Prune() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var kept []string
+	for _, p := range b.order {
+		if unchanged(b.entries[p]) {
+			delete(b.entries, p)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	b.order = kept
+}
+
+// Save writes the manifest and removes artwork no entry refers to any more.
+// A backup without entries is removed from disk instead, so runs that
+// changed nothing don't show up in List.
+func (b *Run) Save() error {
+	entries := b.Entries()
+	if len(entries) == 0 {
+		if err := os.RemoveAll(b.dir); err != nil {
+			return fmt.Errorf("failed to remove empty backup: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(b.dir, manifestName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return b.removeUnusedArtwork(entries)
+}
+
+// removeUnusedArtwork deletes the stored pictures of files Prune dropped.
+func (b *Run) removeUnusedArtwork(entries []Entry) error {
+	used := make(map[string]bool)
+	for _, e := range entries {
+		used[e.Artwork] = true
+	}
+	dir := b.artworkDir()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to list backed-up artwork: %w", err)
+	}
+	for _, f := range files {
+		if used[f.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			return fmt.Errorf("failed to remove unused artwork: %w", err)
+		}
+	}
+	return nil
+}
+
+// Entries returns the backed-up files in the order they were first seen.
+func (b *Run) Entries() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Entry, 0, len(b.order))
+	for _, p := range b.order {
+		out = append(out, *b.entries[p])
+	}
+	return out
+}
+
+// Restore puts the tags and artwork recorded in e back on its file. Tags the
+// run added are removed.
+func (b *Run) Restore(e Entry) error {
+	if err := taglib.WriteTags(e.Path, e.Tags, taglib.Clear); err != nil {
+		return fmt.Errorf("failed to restore tags of %s: %w", e.Path, err)
+	}
+
+	current, err := taglib.ReadImage(e.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read artwork of %s: %w", e.Path, err)
+	}
+	var currentHash string
+	if len(current) > 0 {
+		currentHash = hashImage(current)
+	}
+	if currentHash == e.Artwork {
+		return nil
+	}
+
+	var img []byte
+	if e.Artwork != "" {
+		img, err = os.ReadFile(b.artworkPath(e.Artwork))
+		if err != nil {
+			return fmt.Errorf("failed to read backed-up artwork for %s: %w", e.Path, err)
+		}
+	}
+	if err := taglib.WriteImage(e.Path, img); err != nil {
+		return fmt.Errorf("failed to restore artwork of %s: %w", e.Path, err)
+	}
+	return nil
+}
+
+func (b *Run) storeArtwork(hash string, img []byte) error {
+	path := b.artworkPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(path, img, 0644); err != nil {
+		return fmt.Errorf("failed to back up artwork: %w", err)
+	}
+	return nil
+}
+
+func (b *Run) artworkDir() string {
+	return filepath.Join(b.dir, "artwork")
+}
+
+func (b *Run) artworkPath(hash string) string {
+	return filepath.Join(b.artworkDir(), hash)
+}
+
+// unchanged reports whether the file behind e still matches the snapshot.
+// Files that can no longer be read count as changed so they stay restorable.
+func unchanged(e *Entry) bool {
+	tags, err := taglib.ReadTags(e.Path)
+	if err != nil || !sameTags(tags, e.Tags) {
+		return false
+	}
+	img, err := taglib.ReadImage(e.Path)
+	if err != nil {
+		return false
+	}
+	var hash string
+	if len(img) > 0 {
+		hash = hashImage(img)
+	}
+	return hash == e.Artwork
+}
+
+func sameTags(a, b map[string][]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func hashImage(img []byte) string {
+	sum := sha256.Sum256(img)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,189 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"go.senan.xyz/taglib"
+)
+
+func newTestMP3(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	cmd := exec.Command("ffmpeg", "-f", "lavfi", "-i", "anullsrc=r=44100:cl=mono", "-t", "0.1", "-q:a", "9", path)
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("ffmpeg failed: %v", err)
+	}
+	return path
+}
+
+// testPNG is a 1x1 transparent PNG.
+var testPNG = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+	0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+	0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+	0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+
+	ids, err := List(filepath.Join(root, "missing"))
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("List(missing) = %v, %v; want empty", ids, err)
+	}
+
+	for _, id := range []string{"2026-02-01_10-00-00", "2026-01-01_10-00-00"} {
+		dir := filepath.Join(root, id)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, manifestName), []byte("[]"), 0644)
+	}
+	os.MkdirAll(filepath.Join(root, "incomplete"), 0755)
+
+	ids, err = List(root)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"2026-01-01_10-00-00", "2026-02-01_10-00-00"}
+	if len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] {
+		t.Errorf("List = %v, want %v", ids, want)
+	}
+}
+
+func TestOpen_MissingRun(t *testing.T) {
+	if _, err := Open(t.TempDir(), "nope"); err == nil {
+		t.Error("expected an error for an unknown run")
+	}
+}
+
+func TestSave_EmptyBackupLeavesNothing(t *testing.T) {
+	root := t.TempDir()
+	b := New(root, "run")
+	os.MkdirAll(filepath.Join(root, "run", "artwork"), 0755)
+
+	if err := b.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "run")); !os.IsNotExist(err) {
+		t.Errorf("empty backup directory still exists: %v", err)
+	}
+}
+
+func TestSave_RemovesArtworkOfPrunedFiles(t *testing.T) {
+	b := New(t.TempDir(), "run")
+	b.storeArtwork("kept", testPNG)
+	b.storeArtwork("pruned", testPNG)
+	b.entries["/music/a.mp3"] = &Entry{Path: "/music/a.mp3", Artwork: "kept"}
+	b.order = []string{"/music/a.mp3"}
+
+	if err := b.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(b.artworkPath("kept")); err != nil {
+		t.Errorf("artwork of a kept entry removed: %v", err)
+	}
+	if _, err := os.Stat(b.artworkPath("pruned")); !os.IsNotExist(err) {
+		t.Errorf("artwork of a pruned entry still stored: %v", err)
+	}
+}
+
+func TestFreeID(t *testing.T) {
+	root := t.TempDir()
+	if got := FreeID(root, "2024-01-02_03-04-05"); got != "2024-01-02_03-04-05" {
+		t.Errorf("FreeID on an empty root = %q", got)
+	}
+	os.MkdirAll(filepath.Join(root, "2024-01-02_03-04-05"), 0755)
+	os.MkdirAll(filepath.Join(root, "2024-01-02_03-04-05-2"), 0755)
+	if got := FreeID(root, "2024-01-02_03-04-05"); got != "2024-01-02_03-04-05-3" {
+		t.Errorf("FreeID = %q, want the -3 suffix", got)
+	}
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	path := newTestMP3(t)
+	if err := taglib.WriteTags(path, map[string][]string{
+		taglib.Title:  {"Song (Official Video)"},
+		taglib.Artist: {"BandVEVO"},
+	}, 0); err != nil {
+		t.Fatalf("write initial tags: %v", err)
+	}
+	if err := taglib.WriteImage(path, testPNG); err != nil {
+		t.Fatalf("write initial artwork: %v", err)
+	}
+
+	root := t.TempDir()
+	b := New(root, "run")
+	if err := b.Snapshot(path); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	// The run rewrites tags, adds a new one and drops the artwork.
+	taglib.WriteTags(path, map[string][]string{
+		taglib.Title:  {"Song"},
+		taglib.Artist: {"Band"},
+		taglib.Genre:  {"Rock"},
+	}, 0)
+	taglib.WriteImage(path, nil)
+
+	// A second snapshot must not replace the original state.
+	if err := b.Snapshot(path); err != nil {
+		t.Fatalf("second Snapshot: %v", err)
+	}
+	b.Prune()
+	if err := b.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Open(root, "run")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	entries := loaded.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if err := loaded.Restore(entries[0]); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	tags, _ := taglib.ReadTags(path)
+	if got := tags[taglib.Title]; len(got) != 1 || got[0] != "Song (Official Video)" {
+		t.Errorf("Title = %v, want original", got)
+	}
+	if _, ok := tags[taglib.Genre]; ok {
+		t.Errorf("Genre added by the run was not removed: %v", tags[taglib.Genre])
+	}
+	img, _ := taglib.ReadImage(path)
+	if hashImage(img) != hashImage(testPNG) {
+		t.Error("artwork not restored")
+	}
+}
+
+func TestPrune_DropsUnchangedFiles(t *testing.T) {
+	changed := newTestMP3(t)
+	untouched := newTestMP3(t)
+	for _, p := range []string{changed, untouched} {
+		taglib.WriteTags(p, map[string][]string{taglib.Title: {"Song"}}, 0)
+	}
+
+	b := New(t.TempDir(), "run")
+	for _, p := range []string{changed, untouched} {
+		if err := b.Snapshot(p); err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+	}
+	taglib.WriteTags(changed, map[string][]string{taglib.Title: {"Other Song"}}, 0)
+
+	b.Prune()
+	entries := b.Entries()
+	if len(entries) != 1 || entries[0].Path != changed {
+		t.Errorf("entries after Prune = %+v, want only %s", entries, changed)
+	}
+}
@@ -6,26 +6,61 @@ import (
 	"path/filepath"
 	"strings"
 
+	"ytmusic/internal/metadata"
+	"ytmusic/internal/provider/musicbrainz"
+
 	"gopkg.in/yaml.v3"
 )
 
 // Config contains the program configuration
 type Config struct {
-	PlaylistURL         string   `yaml:"playlist_url"`
-	Verbose             bool     `yaml:"verbose"`
-	DryRun              bool     `yaml:"dry_run"`
-	ParallelJobs        int      `yaml:"parallel_jobs"`
-	CookiesBrowser      string   `yaml:"cookies_browser"`
-	AudioFormat         string   `yaml:"audio_format"`
-	MetadataProviders   []string `yaml:"metadata_providers"`
-	SpotifyClientID     string   `yaml:"spotify_client_id"`
-	SpotifyClientSecret string   `yaml:"spotify_client_secret"`
-	AcoustIDAPIKey      string   `yaml:"acoustid_api_key"`
-	ConfidenceThreshold float64  `yaml:"confidence_threshold"`
-	SkipLyrics          bool     `yaml:"skip_lyrics"`
-	LyricsOnly          string   `yaml:"-"`
-	ImportOnly          string   `yaml:"-"`
-	OutputDir           string   `yaml:"output_dir"`
+	PlaylistURL         string             `yaml:"playlist_url"`
+	Verbose             bool               `yaml:"verbose"`
+	DryRun              bool               `yaml:"dry_run"`
+	ParallelJobs        int                `yaml:"parallel_jobs"`
+	CookiesBrowser      string             `yaml:"cookies_browser"`
+	AudioFormat         string             `yaml:"audio_format"`
+	MetadataProviders   []string           `yaml:"metadata_providers"`
+	SpotifyClientID     string             `yaml:"spotify_client_id"`
+	SpotifyClientSecret string             `yaml:"spotify_client_secret"`
+	LastFMAPIKey        string             `yaml:"lastfm_api_key"`
+	DiscogsToken        string             `yaml:"discogs_token"`
+	AcoustIDAPIKey      string             `yaml:"acoustid_api_key"`
+	AcoustIDMinScore    float64            `yaml:"acoustid_min_score"`
+	ConfidenceThreshold float64            `yaml:"confidence_threshold"`
+	AlbumAgreement      float64            `yaml:"album_agreement"`
+	Transliterate       bool               `yaml:"transliterate"`
+	FeaturedArtists     string             `yaml:"featured_artists"`
+	TagPolicy           map[string]string  `yaml:"tag_policy"`
+	ReleasePreferences  ReleasePreferences `yaml:"release_preferences"`
+	FetchCredits        bool               `yaml:"fetch_credits"`
+	PathTemplate        string             `yaml:"path_template"`
+	Genres              Genres             `yaml:"genres"`
+	SkipLyrics          bool               `yaml:"skip_lyrics"`
+	LyricsOnly          string             `yaml:"-"`
+	ImportOnly          string             `yaml:"-"`
+	Interactive         bool               `yaml:"-"`
+	OutputDir           string             `yaml:"output_dir"`
+}
+
+// ReleasePreferences chooses among the MusicBrainz releases a recording
+// appears on. Empty fields keep the built-in preference.
+type ReleasePreferences struct {
+	Types     []string `yaml:"types"`     // primary types in order: album, ep, single, ...
+	Exclude   []string `yaml:"exclude"`   // secondary types to avoid: compilation, live, ...
+	Countries []string `yaml:"countries"` // release countries in order: US, GB, XW, ...
+	Formats   []string `yaml:"formats"`   // medium formats in order: digital media, cd, ...
+	Date      string   `yaml:"date"`      // "earliest" or "latest"
+}
+
+// Genres controls how provider genres are normalized before writing.
+type Genres struct {
+	Normalize   bool              `yaml:"normalize"`    // map genres onto the built-in genre tree
+	Depth       int               `yaml:"depth"`        // tree levels written per genre, 0 for all
+	Aliases     map[string]string `yaml:"aliases"`      // provider genre → genre to write
+	Whitelist   []string          `yaml:"whitelist"`    // genres that may be written; empty allows the whole tree
+	KeepUnknown bool              `yaml:"keep_unknown"` // keep genres outside the tree instead of dropping them
+	Separator   string            `yaml:"separator"`    // join genres into one value; empty writes one value per genre
 }
 
 // DefaultConfig returns the default configuration
@@ -36,7 +71,11 @@ func DefaultConfig() Config {
 		ParallelJobs:        4,
 		CookiesBrowser:      "brave",
 		AudioFormat:         "mp3",
+		AcoustIDMinScore:    0.5,
 		ConfidenceThreshold: 0.7,
+		AlbumAgreement:      0.6,
+		Transliterate:       true,
+		FeaturedArtists:     "artist",
 		OutputDir:           filepath.Join(homeDir(), "Music"),
 	}
 }
@@ -131,6 +170,20 @@ func GetDefaultLogPath() string {
 	return filepath.Join(homeDir(), ".local", "share", "ytmusic", "logs")
 }
 
+// RunIDFormat is the time layout of run IDs. A run's log, match report and
+// tag backup share its ID so they can be matched up later.
+const RunIDFormat = "2006-01-02_15-04-05"
+
+// GetReportPath returns where the match report of run runID is written.
+func GetReportPath(runID string) string {
+	return filepath.Join(GetDefaultLogPath(), "ytmusic_"+runID+".report.json")
+}
+
+// GetDefaultBackupPath returns the directory holding per-run tag backups
+func GetDefaultBackupPath() string {
+	return filepath.Join(homeDir(), ".local", "share", "ytmusic", "backups")
+}
+
 func homeDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -169,10 +222,43 @@ func (c *Config) ValidateBase() error {
 		return fmt.Errorf("confidence_threshold must be between 0.0 and 1.0, got %.2f", c.ConfidenceThreshold)
 	}
 
-	validProviders := map[string]bool{"spotify": true, "musicbrainz": true, "deezer": true, "itunes": true}
+	if c.AcoustIDMinScore < 0 || c.AcoustIDMinScore > 1 {
+		return fmt.Errorf("acoustid_min_score must be between 0.0 and 1.0, got %.2f", c.AcoustIDMinScore)
+	}
+
+	if c.AlbumAgreement < 0 || c.AlbumAgreement > 1 {
+		return fmt.Errorf("album_agreement must be between 0.0 and 1.0, got %.2f", c.AlbumAgreement)
+	}
+
+	if c.FeaturedArtists != "" && c.FeaturedArtists != "artist" && c.FeaturedArtists != "title" {
+		return fmt.Errorf("featured_artists must be \"artist\" or \"title\", got %q", c.FeaturedArtists)
+	}
+
+	for field, policy := range c.TagPolicy {
+		if !metadata.IsPolicyField(field) {
+			return fmt.Errorf("tag_policy.%s is not a tag the importer writes", field)
+		}
+		if policy != "overwrite" && policy != "fill" && policy != "keep" {
+			return fmt.Errorf("tag_policy.%s must be \"overwrite\", \"fill\" or \"keep\", got %q", field, policy)
+		}
+	}
+
+	if err := c.ReleasePreferences.validate(); err != nil {
+		return err
+	}
+
+	if c.Genres.Depth < 0 {
+		return fmt.Errorf("genres.depth cannot be negative, got %d", c.Genres.Depth)
+	}
+
+	if err := metadata.ValidatePathTemplate(c.PathTemplate); err != nil {
+		return fmt.Errorf("path_template: %w", err)
+	}
+
+	validProviders := map[string]bool{"spotify": true, "musicbrainz": true, "deezer": true, "itunes": true, "lastfm": true, "discogs": true}
 	for _, p := range c.MetadataProviders {
 		if !validProviders[p] {
-			return fmt.Errorf("unknown metadata provider %q, valid providers: spotify, musicbrainz, deezer, itunes", p)
+			return fmt.Errorf("unknown metadata provider %q, valid providers: spotify, musicbrainz, deezer, itunes, lastfm, discogs", p)
 		}
 	}
 
@@ -185,6 +271,14 @@ func (c *Config) ValidateBase() error {
 		}
 	}
 
+	if !c.DryRun && c.hasProvider("lastfm") && c.LastFMAPIKey == "" {
+		return fmt.Errorf("lastfm_api_key is required when lastfm is in metadata_providers")
+	}
+
+	if !c.DryRun && c.hasProvider("discogs") && c.DiscogsToken == "" {
+		return fmt.Errorf("discogs_token is required when discogs is in metadata_providers")
+	}
+
 	return nil
 }
 
@@ -216,3 +310,22 @@ func (c *Config) hasProvider(name string) bool {
 	}
 	return false
 }
+
+var releaseTypes = []string{"album", "single", "ep", "broadcast", "other"}
+
+func (p ReleasePreferences) validate() error {
+	for _, t := range p.Types {
+		if !metadata.ContainsFold(releaseTypes, t) {
+			return fmt.Errorf("release_preferences.types: unknown release type %q, valid types: %s", t, strings.Join(releaseTypes, ", "))
+		}
+	}
+	for _, t := range p.Exclude {
+		if !metadata.ContainsFold(musicbrainz.SecondaryTypes, t) {
+			return fmt.Errorf("release_preferences.exclude: unknown secondary type %q, valid types: %s", t, strings.Join(musicbrainz.SecondaryTypes, ", "))
+		}
+	}
+	if p.Date != "" && p.Date != "earliest" && p.Date != "latest" {
+		return fmt.Errorf("release_preferences.date must be \"earliest\" or \"latest\", got %q", p.Date)
+	}
+	return nil
+}
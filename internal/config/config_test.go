@@ -47,6 +47,16 @@ func TestValidate(t *testing.T) {
 			modify:  func(c *Config) { c.ConfidenceThreshold = 1.1 },
 			wantErr: true,
 		},
+		{
+			name:    "acoustid min score negative",
+			modify:  func(c *Config) { c.AcoustIDMinScore = -0.5 },
+			wantErr: true,
+		},
+		{
+			name:    "album agreement above 1",
+			modify:  func(c *Config) { c.AlbumAgreement = 1.5 },
+			wantErr: true,
+		},
 		{
 			name:    "parallel jobs 0",
 			modify:  func(c *Config) { c.ParallelJobs = 0 },
@@ -61,6 +71,67 @@ func TestValidate(t *testing.T) {
 			name:   "parallel jobs 10",
 			modify: func(c *Config) { c.ParallelJobs = 10 },
 		},
+		{
+			name:   "featured artists in title",
+			modify: func(c *Config) { c.FeaturedArtists = "title" },
+		},
+		{
+			name:    "featured artists invalid",
+			modify:  func(c *Config) { c.FeaturedArtists = "album" },
+			wantErr: true,
+		},
+		{
+			name: "tag policy",
+			modify: func(c *Config) {
+				c.TagPolicy = map[string]string{"title": "keep", "genre": "fill", "artwork": "overwrite"}
+			},
+		},
+		{
+			name:    "tag policy invalid",
+			modify:  func(c *Config) { c.TagPolicy = map[string]string{"title": "never"} },
+			wantErr: true,
+		},
+		{
+			name:    "tag policy unknown tag",
+			modify:  func(c *Config) { c.TagPolicy = map[string]string{"titel": "keep"} },
+			wantErr: true,
+		},
+		{
+			name: "release preferences",
+			modify: func(c *Config) {
+				c.ReleasePreferences = ReleasePreferences{
+					Types:     []string{"Album", "EP"},
+					Exclude:   []string{"compilation", "live"},
+					Countries: []string{"US"},
+					Formats:   []string{"Digital Media"},
+					Date:      "latest",
+				}
+			},
+		},
+		{
+			name:    "release preferences unknown type",
+			modify:  func(c *Config) { c.ReleasePreferences.Types = []string{"mixtape"} },
+			wantErr: true,
+		},
+		{
+			name:    "release preferences invalid date",
+			modify:  func(c *Config) { c.ReleasePreferences.Date = "newest" },
+			wantErr: true,
+		},
+		{
+			name:   "path template",
+			modify: func(c *Config) { c.PathTemplate = "{albumartistsort}/{year} - {album}" },
+		},
+		{
+			name:    "path template unknown placeholder",
+			modify:  func(c *Config) { c.PathTemplate = "{composer}/{album}" },
+			wantErr: true,
+		},
+		{
+			name:    "negative genre depth",
+			modify:  func(c *Config) { c.Genres.Depth = -1 },
+			wantErr: true,
+		},
 		{
 			name:    "invalid format",
 			modify:  func(c *Config) { c.AudioFormat = "wma" },
@@ -128,6 +199,30 @@ func TestValidate(t *testing.T) {
 		},
 		{
 			name:    "unknown provider",
+			modify:  func(c *Config) { c.MetadataProviders = []string{"tidal"} },
+			wantErr: true,
+		},
+		{
+			name: "lastfm provider",
+			modify: func(c *Config) {
+				c.MetadataProviders = []string{"lastfm"}
+				c.LastFMAPIKey = "key"
+			},
+		},
+		{
+			name: "discogs provider",
+			modify: func(c *Config) {
+				c.MetadataProviders = []string{"discogs"}
+				c.DiscogsToken = "token"
+			},
+		},
+		{
+			name:    "missing discogs token with discogs provider",
+			modify:  func(c *Config) { c.MetadataProviders = []string{"discogs"} },
+			wantErr: true,
+		},
+		{
+			name:    "missing lastfm key with lastfm provider",
 			modify:  func(c *Config) { c.MetadataProviders = []string{"lastfm"} },
 			wantErr: true,
 		},
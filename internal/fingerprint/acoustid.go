@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -31,61 +33,110 @@ func NewAcoustIDClient(apiKey, baseURL string) *AcoustIDClient {
 	}
 }
 
+// Match is an AcoustID result: a cluster of fingerprints similar to the one
+// looked up, with the MusicBrainz recordings linked to it.
+type Match struct {
+	ID         string
+	Score      float64 // fingerprint similarity, 0.0-1.0
+	Recordings []Recording
+}
+
+// Recording is a MusicBrainz recording linked to an AcoustID result.
+type Recording struct {
+	ID       string
+	Title    string
+	Artist   string        // joined artist credit
+	Duration time.Duration // 0 if unknown
+}
+
 type acoustidResponse struct {
 	Status  string           `json:"status"`
+	Error   *acoustidError   `json:"error,omitempty"`
 	Results []acoustidResult `json:"results"`
 }
 
+type acoustidError struct {
+	Message string `json:"message"`
+}
+
 type acoustidResult struct {
-	ID         string             `json:"id"`
-	Score      float64            `json:"score"`
+	ID         string              `json:"id"`
+	Score      float64             `json:"score"`
 	Recordings []acoustidRecording `json:"recordings"`
 }
 
 type acoustidRecording struct {
-	ID string `json:"id"`
+	ID       string           `json:"id"`
+	Title    string           `json:"title"`
+	Duration float64          `json:"duration"` // seconds
+	Artists  []acoustidArtist `json:"artists"`
 }
 
-// Lookup submits a fingerprint to AcoustID and returns the first MusicBrainz recording ID found.
-// Returns (mbid, true, nil) on success, ("", false, nil) when no match is found.
-func (c *AcoustIDClient) Lookup(ctx context.Context, fp Result) (string, bool, error) {
+type acoustidArtist struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+}
+
+// Lookup submits a fingerprint to AcoustID and returns every result with its
+// recordings, highest score first. Results without recordings are dropped.
+func (c *AcoustIDClient) Lookup(ctx context.Context, fp Result) ([]Match, error) {
 	params := url.Values{}
 	params.Set("client", c.apiKey)
 	params.Set("duration", strconv.Itoa(fp.Duration))
 	params.Set("fingerprint", fp.Fingerprint)
-	params.Set("meta", "recordingids")
+	params.Set("meta", "recordings")
 
 	reqURL := c.baseURL + "?" + params.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to build acoustid request: %w", err)
+		return nil, fmt.Errorf("failed to build acoustid request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", false, fmt.Errorf("acoustid request failed: %w", err)
+		return nil, fmt.Errorf("acoustid request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", false, fmt.Errorf("acoustid returned %d", resp.StatusCode)
+		return nil, fmt.Errorf("acoustid returned %d", resp.StatusCode)
 	}
 
 	var result acoustidResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", false, fmt.Errorf("failed to decode acoustid response: %w", err)
+		return nil, fmt.Errorf("failed to decode acoustid response: %w", err)
+	}
+	if result.Status == "error" && result.Error != nil {
+		return nil, fmt.Errorf("acoustid error: %s", result.Error.Message)
 	}
 
+	var matches []Match
 	for _, r := range result.Results {
-		if r.Score < 0.5 {
-			continue
-		}
+		m := Match{ID: r.ID, Score: r.Score}
 		for _, rec := range r.Recordings {
-			if rec.ID != "" {
-				return rec.ID, true, nil
+			if rec.ID == "" {
+				continue
 			}
+			m.Recordings = append(m.Recordings, Recording{
+				ID:       rec.ID,
+				Title:    rec.Title,
+				Artist:   joinArtists(rec.Artists),
+				Duration: time.Duration(rec.Duration * float64(time.Second)),
+			})
+		}
+		if len(m.Recordings) > 0 {
+			matches = append(matches, m)
 		}
 	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
 
-	return "", false, nil
+func joinArtists(artists []acoustidArtist) string {
+	var b strings.Builder
+	for _, a := range artists {
+		b.WriteString(a.Name)
+		b.WriteString(a.JoinPhrase)
+	}
+	return b.String()
 }
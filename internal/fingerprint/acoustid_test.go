@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"ytmusic/internal/fingerprint"
 )
@@ -18,7 +19,22 @@ func TestAcoustIDClient_Lookup_Found(t *testing.T) {
 				"id":    "acoustid-1",
 				"score": 0.95,
 				"recordings": []map[string]any{
-					{"id": "mbid-abc-123"},
+					{"id": "mbid-abc-123", "title": "Song", "duration": 241, "artists": []map[string]any{
+						{"name": "Band", "joinphrase": " & "}, {"name": "Friend"},
+					}},
+					{"id": "mbid-def-456"},
+				},
+			},
+			{
+				"id":         "acoustid-2",
+				"score":      0.4,
+				"recordings": []map[string]any{{"id": "mbid-low"}},
+			},
+			{
+				"id":    "acoustid-3",
+				"score": 0.98,
+				"recordings": []map[string]any{
+					{"id": "mbid-top"},
 				},
 			},
 		},
@@ -28,21 +44,31 @@ func TestAcoustIDClient_Lookup_Found(t *testing.T) {
 			http.Error(w, "bad form", http.StatusBadRequest)
 			return
 		}
+		if r.Form.Get("meta") != "recordings" {
+			t.Errorf("meta = %q, want recordings", r.Form.Get("meta"))
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(payload)
 	}))
 	defer srv.Close()
 
 	client := fingerprint.NewAcoustIDClient("test-key", srv.URL)
-	mbid, found, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
+	matches, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !found {
-		t.Fatal("expected found=true")
+	if len(matches) != 3 {
+		t.Fatalf("got %d matches, want all 3", len(matches))
+	}
+	if matches[0].ID != "acoustid-3" || matches[1].ID != "acoustid-1" || matches[2].ID != "acoustid-2" {
+		t.Errorf("matches not ordered by score: %+v", matches)
+	}
+	want := fingerprint.Recording{ID: "mbid-abc-123", Title: "Song", Artist: "Band & Friend", Duration: 241 * time.Second}
+	if len(matches[1].Recordings) != 2 || matches[1].Recordings[0] != want {
+		t.Errorf("recordings = %+v, want %+v first", matches[1].Recordings, want)
 	}
-	if mbid != "mbid-abc-123" {
-		t.Fatalf("expected mbid %q, got %q", "mbid-abc-123", mbid)
+	if matches[1].Score != 0.95 {
+		t.Errorf("score = %v, want 0.95", matches[1].Score)
 	}
 }
 
@@ -54,12 +80,12 @@ func TestAcoustIDClient_Lookup_NoResults(t *testing.T) {
 	defer srv.Close()
 
 	client := fingerprint.NewAcoustIDClient("test-key", srv.URL)
-	_, found, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
+	matches, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if found {
-		t.Fatal("expected found=false")
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %+v", matches)
 	}
 }
 
@@ -81,11 +107,11 @@ func TestAcoustIDClient_Lookup_NoRecordings(t *testing.T) {
 	defer srv.Close()
 
 	client := fingerprint.NewAcoustIDClient("test-key", srv.URL)
-	_, found, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
+	matches, err := client.Lookup(context.Background(), fingerprint.Result{Duration: 240, Fingerprint: "AQADtMm..."})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if found {
-		t.Fatal("expected found=false for result with no recordings")
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches for result with no recordings, got %+v", matches)
 	}
 }
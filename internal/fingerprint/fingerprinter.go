@@ -3,10 +3,14 @@ package fingerprint
 import (
 	"context"
 	"sync"
+	"time"
 
 	"ytmusic/internal/metadata"
 )
 
+// DefaultMinScore is the lowest AcoustID score accepted as a match.
+const DefaultMinScore = 0.5
+
 // fpcalcGenerator abstracts the fpcalc CLI (mockable in tests).
 type fpcalcGenerator interface {
 	Generate(ctx context.Context, path string) (Result, error)
@@ -14,7 +18,7 @@ type fpcalcGenerator interface {
 
 // acoustidLookup abstracts the AcoustID client (mockable in tests).
 type acoustidLookup interface {
-	Lookup(ctx context.Context, fp Result) (string, bool, error)
+	Lookup(ctx context.Context, fp Result) ([]Match, error)
 }
 
 // defaultFpcalc wraps the package-level Generate function.
@@ -29,6 +33,7 @@ type Fingerprinter struct {
 	fpcalc     fpcalcGenerator
 	acoustid   acoustidLookup
 	mbidLookup func(ctx context.Context, mbid, preferAlbum string) (metadata.TrackInfo, error)
+	minScore   float64
 }
 
 // New creates a production Fingerprinter with real dependencies.
@@ -38,16 +43,25 @@ func New(acoustidClient *AcoustIDClient, mbidLookup func(ctx context.Context, mb
 		fpcalc:     &defaultFpcalc{},
 		acoustid:   acoustidClient,
 		mbidLookup: mbidLookup,
+		minScore:   DefaultMinScore,
 	}
 }
 
 // NewFingerprinter creates a Fingerprinter with injected dependencies (used in tests).
 func NewFingerprinter(fp fpcalcGenerator, ac acoustidLookup, mbidLookup func(ctx context.Context, mbid, preferAlbum string) (metadata.TrackInfo, error)) *Fingerprinter {
-	return &Fingerprinter{fpcalc: fp, acoustid: ac, mbidLookup: mbidLookup}
+	return &Fingerprinter{fpcalc: fp, acoustid: ac, mbidLookup: mbidLookup, minScore: DefaultMinScore}
+}
+
+// WithMinScore sets the lowest AcoustID score (0.0-1.0) accepted as a match.
+func (f *Fingerprinter) WithMinScore(score float64) *Fingerprinter {
+	f.minScore = score
+	return f
 }
 
 // BatchLookupByFiles fingerprints all paths in parallel (max 4 concurrent) and
-// returns FileMatch entries only for files whose AcoustID lookup returned a recording MBID.
+// returns FileMatch entries only for files whose AcoustID lookup returned a recording
+// scoring at least the minimum. Without tags to compare, the recording whose length
+// best agrees with the file is chosen.
 // The mbidLookup step is intentionally skipped here; callers use the MBID directly.
 func (f *Fingerprinter) BatchLookupByFiles(ctx context.Context, paths []string) []metadata.FileMatch {
 	type slot struct {
@@ -70,11 +84,15 @@ func (f *Fingerprinter) BatchLookupByFiles(ctx context.Context, paths []string)
 			if err != nil {
 				return
 			}
-			mbid, found, err := f.acoustid.Lookup(ctx, fp)
-			if err != nil || !found {
+			matches, err := f.acoustid.Lookup(ctx, fp)
+			if err != nil {
+				return
+			}
+			best, ok := f.pickRecording(matches, metadata.SearchQuery{}, fp.Duration)
+			if !ok {
 				return
 			}
-			slots[i] = slot{match: metadata.FileMatch{Path: path, MBID: mbid}, ok: true}
+			slots[i] = slot{match: metadata.FileMatch{Path: path, MBID: best.recording.ID}, ok: true}
 		}(i, path)
 	}
 	wg.Wait()
@@ -89,24 +107,87 @@ func (f *Fingerprinter) BatchLookupByFiles(ctx context.Context, paths []string)
 }
 
 // LookupByFile identifies the audio file at path via its acoustic fingerprint.
-// preferAlbum is passed to MusicBrainz to break ties when a recording appears in multiple releases.
+// Of the recordings AcoustID links to the fingerprint, the one that best agrees
+// with the query's title and artist and the file's length is chosen; query.Album
+// is passed to MusicBrainz to break ties when the recording appears in multiple
+// releases. The match's confidence is its AcoustID score.
 // Returns (zero, false, nil) when no match is found; errors are non-fatal (logged by caller).
-func (f *Fingerprinter) LookupByFile(ctx context.Context, path, preferAlbum string) (metadata.TrackInfo, bool, error) {
+func (f *Fingerprinter) LookupByFile(ctx context.Context, path string, query metadata.SearchQuery) (metadata.TrackInfo, bool, error) {
 	fp, err := f.fpcalc.Generate(ctx, path)
 	if err != nil {
 		return metadata.TrackInfo{}, false, nil
 	}
 
-	mbid, found, err := f.acoustid.Lookup(ctx, fp)
-	if err != nil || !found {
+	matches, err := f.acoustid.Lookup(ctx, fp)
+	if err != nil {
+		return metadata.TrackInfo{}, false, nil
+	}
+	best, ok := f.pickRecording(matches, query, fp.Duration)
+	if !ok {
 		return metadata.TrackInfo{}, false, nil
 	}
 
-	info, err := f.mbidLookup(ctx, mbid, preferAlbum)
+	info, err := f.mbidLookup(ctx, best.recording.ID, query.Album)
 	if err != nil {
 		return metadata.TrackInfo{}, false, nil
 	}
 
-	info.Confidence = 1.0
+	info.Confidence = best.score
 	return info, true, nil
 }
+
+// candidate is a recording linked to an AcoustID result, with the result's score.
+type candidate struct {
+	recording Recording
+	score     float64
+}
+
+// pickRecording returns the recording of the results scoring at least the
+// minimum that ranks highest by AcoustID score weighted by its agreement with
+// the query and the file's length (in seconds). Ties go to the recording
+// AcoustID listed first.
+func (f *Fingerprinter) pickRecording(matches []Match, query metadata.SearchQuery, seconds int) (candidate, bool) {
+	var best candidate
+	var bestRank float64
+	found := false
+	for _, m := range matches {
+		if m.Score < f.minScore {
+			continue
+		}
+		for _, rec := range m.Recordings {
+			rank := m.Score * (0.5 + 0.5*agreement(rec, query, seconds))
+			if !found || rank > bestRank {
+				best, bestRank, found = candidate{recording: rec, score: m.Score}, rank, true
+			}
+		}
+	}
+	return best, found
+}
+
+// agreement scores (0.0-1.0) how well a recording's title, artist and length
+// agree with the query and the file's length; parts either side lacks are
+// left out, and 0.5 is returned when nothing can be compared.
+func agreement(rec Recording, query metadata.SearchQuery, seconds int) float64 {
+	var sum, weight float64
+	if query.Title != "" && rec.Title != "" {
+		sum += 0.5 * metadata.Similarity(query.Title, rec.Title)
+		weight += 0.5
+	}
+	if query.Artist != "" && rec.Artist != "" {
+		sum += 0.3 * metadata.Similarity(query.Artist, rec.Artist)
+		weight += 0.3
+	}
+	if seconds > 0 && rec.Duration > 0 {
+		diff := rec.Duration - time.Duration(seconds)*time.Second
+		if diff < 0 {
+			diff = -diff
+		}
+		// fpcalc truncates to whole seconds; beyond 15s off it's another edit.
+		sum += 0.2 * max(0, 1-diff.Seconds()/15)
+		weight += 0.2
+	}
+	if weight == 0 {
+		return 0.5
+	}
+	return sum / weight
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"ytmusic/internal/fingerprint"
 	"ytmusic/internal/metadata"
@@ -16,13 +17,17 @@ func makeMBIDLookup(info metadata.TrackInfo, err error) func(context.Context, st
 }
 
 type stubAcoustID struct {
-	mbid  string
-	found bool
-	err   error
+	matches []fingerprint.Match
+	err     error
 }
 
-func (s *stubAcoustID) Lookup(_ context.Context, _ fingerprint.Result) (string, bool, error) {
-	return s.mbid, s.found, s.err
+func (s *stubAcoustID) Lookup(_ context.Context, _ fingerprint.Result) ([]fingerprint.Match, error) {
+	return s.matches, s.err
+}
+
+// oneMatch is an AcoustID result with a single bare recording.
+func oneMatch(mbid string, score float64) []fingerprint.Match {
+	return []fingerprint.Match{{ID: "acoustid-1", Score: score, Recordings: []fingerprint.Recording{{ID: mbid}}}}
 }
 
 type stubFpcalc struct {
@@ -35,14 +40,14 @@ func (s *stubFpcalc) Generate(_ context.Context, _ string) (fingerprint.Result,
 }
 
 func TestFingerprinter_LookupByFile_Success(t *testing.T) {
-	want := metadata.TrackInfo{Title: "Song", Artist: "Band", Album: "Record", Confidence: 1.0}
+	want := metadata.TrackInfo{Title: "Song", Artist: "Band", Album: "Record"}
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{mbid: "mbid-1", found: true},
+		&stubAcoustID{matches: oneMatch("mbid-1", 0.9)},
 		makeMBIDLookup(want, nil),
 	)
 
-	got, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", "")
+	got, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", metadata.SearchQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -52,8 +57,8 @@ func TestFingerprinter_LookupByFile_Success(t *testing.T) {
 	if got.Title != want.Title || got.Artist != want.Artist {
 		t.Errorf("got %+v, want %+v", got, want)
 	}
-	if got.Confidence != 1.0 {
-		t.Errorf("expected confidence 1.0, got %f", got.Confidence)
+	if got.Confidence != 0.9 {
+		t.Errorf("expected the AcoustID score 0.9 as confidence, got %f", got.Confidence)
 	}
 }
 
@@ -64,7 +69,7 @@ func TestFingerprinter_LookupByFile_FpcalcFails(t *testing.T) {
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
-	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", "")
+	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", metadata.SearchQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -76,11 +81,11 @@ func TestFingerprinter_LookupByFile_FpcalcFails(t *testing.T) {
 func TestFingerprinter_LookupByFile_AcoustIDNotFound(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{found: false},
+		&stubAcoustID{},
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
-	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", "")
+	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", metadata.SearchQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -92,11 +97,11 @@ func TestFingerprinter_LookupByFile_AcoustIDNotFound(t *testing.T) {
 func TestFingerprinter_LookupByFile_MBLookupFails(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{mbid: "mbid-1", found: true},
+		&stubAcoustID{matches: oneMatch("mbid-1", 0.9)},
 		makeMBIDLookup(metadata.TrackInfo{}, errors.New("mb down")),
 	)
 
-	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", "")
+	_, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", metadata.SearchQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -105,10 +110,75 @@ func TestFingerprinter_LookupByFile_MBLookupFails(t *testing.T) {
 	}
 }
 
+func TestFingerprinter_LookupByFile_PicksAgreeingRecording(t *testing.T) {
+	matches := []fingerprint.Match{
+		{ID: "acoustid-1", Score: 0.92, Recordings: []fingerprint.Recording{
+			{ID: "mbid-karaoke", Title: "Song (Karaoke Version)", Artist: "Covers Inc", Duration: 200 * time.Second},
+			{ID: "mbid-single", Title: "Song", Artist: "Band", Duration: 260 * time.Second},
+			{ID: "mbid-album", Title: "Song", Artist: "Band", Duration: 201 * time.Second},
+		}},
+		{ID: "acoustid-2", Score: 0.3, Recordings: []fingerprint.Recording{
+			{ID: "mbid-weak", Title: "Song", Artist: "Band", Duration: 200 * time.Second},
+		}},
+	}
+	var gotMBID, gotAlbum string
+	fp := fingerprint.NewFingerprinter(
+		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
+		&stubAcoustID{matches: matches},
+		func(_ context.Context, mbid, preferAlbum string) (metadata.TrackInfo, error) {
+			gotMBID, gotAlbum = mbid, preferAlbum
+			return metadata.TrackInfo{Title: "Song"}, nil
+		},
+	)
+
+	query := metadata.SearchQuery{Title: "Song", Artist: "Band", Album: "Record"}
+	got, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", query)
+	if err != nil || !found {
+		t.Fatalf("found=%v err=%v", found, err)
+	}
+	if gotMBID != "mbid-album" || gotAlbum != "Record" {
+		t.Errorf("looked up %q preferring %q, want mbid-album preferring Record", gotMBID, gotAlbum)
+	}
+	if got.Confidence != 0.92 {
+		t.Errorf("Confidence = %v, want 0.92", got.Confidence)
+	}
+}
+
+func TestFingerprinter_LookupByFile_MinScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		minScore float64
+		found    bool
+	}{
+		{"default rejects 0.45", fingerprint.DefaultMinScore, false},
+		{"lowered minimum accepts 0.45", 0.4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := fingerprint.NewFingerprinter(
+				&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
+				&stubAcoustID{matches: oneMatch("mbid-1", 0.45)},
+				makeMBIDLookup(metadata.TrackInfo{Title: "Song"}, nil),
+			).WithMinScore(tt.minScore)
+
+			got, found, err := fp.LookupByFile(context.Background(), "/fake/path.mp3", metadata.SearchQuery{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+			if found && got.Confidence != 0.45 {
+				t.Errorf("Confidence = %v, want 0.45", got.Confidence)
+			}
+		})
+	}
+}
+
 func TestBatchLookupByFiles_CollectsMBIDs(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{mbid: "mbid-1", found: true},
+		&stubAcoustID{matches: oneMatch("mbid-1", 0.9)},
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
@@ -128,7 +198,7 @@ func TestBatchLookupByFiles_CollectsMBIDs(t *testing.T) {
 func TestBatchLookupByFiles_FpcalcFails_Skipped(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{err: errors.New("fpcalc not found")},
-		&stubAcoustID{mbid: "mbid-1", found: true},
+		&stubAcoustID{matches: oneMatch("mbid-1", 0.9)},
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
@@ -141,7 +211,7 @@ func TestBatchLookupByFiles_FpcalcFails_Skipped(t *testing.T) {
 func TestBatchLookupByFiles_AcoustIDNotFound_Skipped(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{found: false},
+		&stubAcoustID{},
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
@@ -154,7 +224,7 @@ func TestBatchLookupByFiles_AcoustIDNotFound_Skipped(t *testing.T) {
 func TestBatchLookupByFiles_EmptyPaths(t *testing.T) {
 	fp := fingerprint.NewFingerprinter(
 		&stubFpcalc{result: fingerprint.Result{Duration: 200, Fingerprint: "AQx"}},
-		&stubAcoustID{mbid: "mbid-1", found: true},
+		&stubAcoustID{matches: oneMatch("mbid-1", 0.9)},
 		makeMBIDLookup(metadata.TrackInfo{}, nil),
 	)
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"ytmusic/internal/backup"
 	"ytmusic/internal/config"
 	"ytmusic/internal/logger"
 	"ytmusic/internal/metadata"
@@ -20,6 +21,10 @@ type Importer struct {
 	albumResolver      metadata.AlbumResolver      // nil if not configured
 	batchFingerprinter metadata.BatchFingerprinter // nil if not configured
 	releaseResolver    metadata.ReleaseResolver    // nil if not configured
+	creditsResolver    metadata.CreditsResolver    // nil unless credits were requested
+	reviewer           metadata.Reviewer           // nil unless running interactively
+	reportPath         string                      // empty to skip the match report
+	backup             *backup.Run                 // nil to skip the tag backup
 }
 
 // New creates a new Importer instance with the given metadata providers.
@@ -50,6 +55,31 @@ func (i *Importer) WithReleaseResolver(rr metadata.ReleaseResolver) *Importer {
 	return i
 }
 
+// WithCreditsResolver attaches a resolver for composer and performer credits.
+func (i *Importer) WithCreditsResolver(cr metadata.CreditsResolver) *Importer {
+	i.creditsResolver = cr
+	return i
+}
+
+// WithReviewer attaches a reviewer for below-threshold matches.
+func (i *Importer) WithReviewer(rv metadata.Reviewer) *Importer {
+	i.reviewer = rv
+	return i
+}
+
+// WithReportPath writes a per-file JSON match report to path after each import.
+func (i *Importer) WithReportPath(path string) *Importer {
+	i.reportPath = path
+	return i
+}
+
+// WithBackup snapshots each file's tags and artwork into b before they are
+// rewritten, keeping the files the import changed.
+func (i *Importer) WithBackup(b *backup.Run) *Importer {
+	i.backup = b
+	return i
+}
+
 // Import resolves metadata for all audio files in the given directory,
 // then writes improved tags.
 func (i *Importer) Import(ctx context.Context, dir string) error {
@@ -74,6 +104,25 @@ func (i *Importer) Import(ctx context.Context, dir string) error {
 	i.Logger.Debug("Found %d audio files", len(files))
 
 	resolver := metadata.NewResolver(i.providers, i.Logger, i.Config.ConfidenceThreshold)
+	resolver = resolver.WithFeatConvention(metadata.FeatConvention(i.Config.FeaturedArtists))
+	resolver = resolver.WithTransliteration(i.Config.Transliterate)
+	resolver = resolver.WithAlbumAgreement(i.Config.AlbumAgreement)
+	if g := i.Config.Genres; g.Normalize {
+		resolver = resolver.WithGenreNormalizer(metadata.NewGenreNormalizer(metadata.GenreOptions{
+			Aliases:     g.Aliases,
+			Depth:       g.Depth,
+			Whitelist:   g.Whitelist,
+			KeepUnknown: g.KeepUnknown,
+			Separator:   g.Separator,
+		}))
+	}
+	if len(i.Config.TagPolicy) > 0 {
+		policy := make(metadata.TagPolicy, len(i.Config.TagPolicy))
+		for field, p := range i.Config.TagPolicy {
+			policy[field] = metadata.WritePolicy(p)
+		}
+		resolver = resolver.WithTagPolicy(policy)
+	}
 	if i.fingerprinter != nil {
 		resolver = resolver.WithFingerprinter(i.fingerprinter)
 	}
@@ -86,10 +135,78 @@ func (i *Importer) Import(ctx context.Context, dir string) error {
 	if i.releaseResolver != nil {
 		resolver = resolver.WithReleaseResolver(i.releaseResolver)
 	}
-	if err := resolver.Resolve(ctx, files); err != nil {
+	if i.creditsResolver != nil {
+		resolver = resolver.WithCreditsResolver(i.creditsResolver)
+	}
+	if i.reviewer != nil {
+		resolver = resolver.WithReviewer(i.reviewer)
+	}
+	var preview *metadata.Preview
+	if i.Config.DryRun {
+		preview = metadata.NewPreview()
+		resolver = resolver.WithPreview(preview)
+	}
+	var report *metadata.Report
+	if i.reportPath != "" {
+		report = metadata.NewReport()
+		resolver = resolver.WithReport(report)
+	}
+
+	if i.backup != nil && preview == nil {
+		files = i.snapshot(files)
+		if len(files) == 0 {
+			return fmt.Errorf("no audio files could be backed up in %s", dir)
+		}
+	}
+
+	err = resolver.Resolve(ctx, files)
+
+	if preview != nil {
+		preview.WriteDiff(os.Stdout)
+	} else if i.backup != nil {
+		i.saveBackup()
+	}
+
+	if report != nil {
+		if werr := report.WriteJSON(i.reportPath); werr != nil {
+			i.Logger.Warn("%v", werr)
+		} else {
+			i.Logger.Info("Match report written to %s", i.reportPath)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("metadata resolution failed: %w", err)
 	}
 
+	if preview != nil {
+		i.Logger.Info("Dry run: no files were modified")
+	}
 	i.Logger.Info("Import completed")
 	return nil
 }
+
+// snapshot backs up files and returns those that were backed up; a file that
+// can't be restored later is left untouched.
+func (i *Importer) snapshot(files []string) []string {
+	var out []string
+	for _, path := range files {
+		if err := i.backup.Snapshot(path); err != nil {
+			i.Logger.Warn("Skipping %s: %v", path, err)
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+// saveBackup keeps the snapshots of the files the run changed and writes them.
+func (i *Importer) saveBackup() {
+	i.backup.Prune()
+	if err := i.backup.Save(); err != nil {
+		i.Logger.Warn("%v", err)
+		return
+	}
+	if n := len(i.backup.Entries()); n > 0 {
+		i.Logger.Info("Original tags of %d files backed up, restore with: ytmusic undo %s", n, i.backup.ID)
+	}
+}
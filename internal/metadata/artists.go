@@ -0,0 +1,213 @@
+package metadata
+
+import (
+	"regexp"
+	"strings"
+
+	"go.senan.xyz/taglib"
+)
+
+// FeatConvention selects where featured artists are written.
+type FeatConvention string
+
+const (
+	// FeatInArtist keeps featured artists in the ARTIST field ("A feat. B")
+	// and strips "(feat. B)" from the title.
+	FeatInArtist FeatConvention = "artist"
+	// FeatInTitle writes only the main artists to ARTIST and appends
+	// "(feat. B)" to the title.
+	FeatInTitle FeatConvention = "title"
+)
+
+const featJoinPhrase = " feat. "
+
+// VariousArtists is the album artist of compilations.
+const VariousArtists = "Various Artists"
+
+// IsVariousArtists reports whether an album artist marks a compilation.
+func IsVariousArtists(name string) bool {
+	return strings.EqualFold(strings.TrimSpace(name), VariousArtists)
+}
+
+// Pattern matching a join phrase that introduces featured artists
+var featJoinPattern = regexp.MustCompile(`(?i)^\s*(?:feat\.?|ft\.?|featuring|with)\s*$`)
+
+// Pattern splitting a list of featured artist names: "A, B & C", "A and B"
+var nameListSeparator = regexp.MustCompile(`(?i)\s*(?:,|&|\band\b)\s*`)
+
+// Pattern splitting a display artist string on commas and feat. markers
+var artistSplitPattern = regexp.MustCompile(`(?i)\s*(?:,|\s(?:feat\.?|ft\.?|featuring)\s)`)
+
+// ExtractFeatured removes "(feat. X & Y)" from title and returns the cleaned
+// title together with the featured artist names in order.
+func ExtractFeatured(title string) (string, []string) {
+	var names []string
+	title = featuringPattern.ReplaceAllStringFunc(title, func(m string) string {
+		list := featuringPattern.FindStringSubmatch(m)[1]
+		for _, n := range nameListSeparator.Split(list, -1) {
+			if n = strings.TrimSpace(n); n != "" {
+				names = append(names, n)
+			}
+		}
+		return ""
+	})
+	return strings.TrimSpace(title), names
+}
+
+// JoinArtists builds the display string for an artist credit list using each
+// credit's join phrase. A missing join phrase between two artists becomes ", ".
+func JoinArtists(credits []ArtistCredit) string {
+	var b strings.Builder
+	for i, c := range credits {
+		b.WriteString(c.Name)
+		if i == len(credits)-1 {
+			break
+		}
+		if c.JoinPhrase != "" {
+			b.WriteString(c.JoinPhrase)
+		} else {
+			b.WriteString(", ")
+		}
+	}
+	return b.String()
+}
+
+// ArtistNames returns the names of all credited artists in order.
+func ArtistNames(credits []ArtistCredit) []string {
+	names := make([]string, 0, len(credits))
+	for _, c := range credits {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// splitFeatured splits credits at the first "feat." join phrase into main and
+// featured artists.
+func splitFeatured(credits []ArtistCredit) (main, featured []ArtistCredit) {
+	for i, c := range credits {
+		if i < len(credits)-1 && featJoinPattern.MatchString(c.JoinPhrase) {
+			return credits[:i+1], credits[i+1:]
+		}
+	}
+	return credits, nil
+}
+
+// applyFeatConvention normalizes where featured artists appear in info.
+// Featured artists come from "feat." join phrases in the credits, from a
+// "(feat. X)" suffix in the title (Spotify style), or from extra, the featured
+// artists parsed from the source title when the provider only reports the main
+// artist. ARTISTS always lists every credited artist; only the display ARTIST
+// and TITLE depend on the convention.
+func applyFeatConvention(info TrackInfo, conv FeatConvention, extra []string) TrackInfo {
+	if conv == "" {
+		conv = FeatInArtist
+	}
+
+	title, fromTitle := ExtractFeatured(info.Title)
+
+	credits := info.Artists
+	if len(credits) == 0 && info.Artist != "" {
+		credits = []ArtistCredit{{Name: info.Artist}}
+	}
+	if len(credits) == 0 {
+		return info
+	}
+
+	main, featured := splitFeatured(credits)
+	if len(featured) == 0 {
+		names := fromTitle
+		if len(names) == 0 {
+			names = extra
+		}
+		main, featured = moveFeatured(main, names)
+	}
+	if len(featured) == 0 {
+		return info
+	}
+
+	main = withJoin(main, ", ")
+	main[len(main)-1].JoinPhrase = featJoinPhrase
+	featured = withJoin(featured, ", ")
+	featured[len(featured)-1].JoinPhrase = ""
+
+	all := append(append([]ArtistCredit{}, main...), featured...)
+	info.Artists = all
+
+	switch conv {
+	case FeatInTitle:
+		mainOnly := append([]ArtistCredit{}, main...)
+		mainOnly[len(mainOnly)-1].JoinPhrase = ""
+		info.Artist = JoinArtists(mainOnly)
+		info.Title = title + " (feat. " + joinNames(ArtistNames(featured)) + ")"
+	default:
+		info.Artist = JoinArtists(all)
+		info.Title = title
+	}
+	return info
+}
+
+// moveFeatured moves credits named in names from main to the featured list,
+// appending credits for names the provider did not report.
+func moveFeatured(main []ArtistCredit, names []string) ([]ArtistCredit, []ArtistCredit) {
+	if len(names) == 0 {
+		return main, nil
+	}
+	var kept, featured []ArtistCredit
+	for _, c := range main {
+		if ContainsFold(names, c.Name) {
+			featured = append(featured, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	for _, n := range names {
+		found := false
+		for _, c := range featured {
+			if strings.EqualFold(c.Name, n) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			featured = append(featured, ArtistCredit{Name: n})
+		}
+	}
+	if len(kept) == 0 {
+		// Every credit was listed as featured; keep the first as main.
+		return featured[:1], featured[1:]
+	}
+	return kept, featured
+}
+
+// withJoin returns a copy of credits with empty join phrases between artists
+// replaced by sep.
+func withJoin(credits []ArtistCredit, sep string) []ArtistCredit {
+	out := append([]ArtistCredit{}, credits...)
+	for i := range out[:len(out)-1] {
+		if out[i].JoinPhrase == "" || featJoinPattern.MatchString(out[i].JoinPhrase) {
+			out[i].JoinPhrase = sep
+		}
+	}
+	return out
+}
+
+// joinNames formats names as "A", "A & B" or "A, B & C".
+func joinNames(names []string) string {
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " & " + names[len(names)-1]
+}
+
+// primaryArtist returns the main artist of a file: the first ARTISTS value if
+// present, otherwise the ARTIST tag up to the first comma or "feat.".
+func primaryArtist(tags map[string][]string) string {
+	if a := firstTag(tags, taglib.Artists); a != "" {
+		return a
+	}
+	artist := firstTag(tags, taglib.Artist)
+	if loc := artistSplitPattern.FindStringIndex(artist); loc != nil && loc[0] > 0 {
+		artist = artist[:loc[0]]
+	}
+	return strings.TrimSpace(artist)
+}
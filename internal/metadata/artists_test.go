@@ -0,0 +1,161 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+
+	"go.senan.xyz/taglib"
+)
+
+func TestExtractFeatured(t *testing.T) {
+	tests := []struct {
+		title     string
+		wantTitle string
+		wantNames []string
+	}{
+		{"Get Lucky (feat. Pharrell Williams & Nile Rodgers)", "Get Lucky", []string{"Pharrell Williams", "Nile Rodgers"}},
+		{"HUMBLE. [ft. Jay Rock]", "HUMBLE.", []string{"Jay Rock"}},
+		{"Song (featuring A, B and C)", "Song", []string{"A", "B", "C"}},
+		{"Plain Title", "Plain Title", nil},
+	}
+	for _, tt := range tests {
+		title, names := ExtractFeatured(tt.title)
+		if title != tt.wantTitle {
+			t.Errorf("ExtractFeatured(%q) title = %q, want %q", tt.title, title, tt.wantTitle)
+		}
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("ExtractFeatured(%q) names = %v, want %v", tt.title, names, tt.wantNames)
+		}
+	}
+}
+
+func TestJoinArtists(t *testing.T) {
+	credits := []ArtistCredit{
+		{Name: "Queen", JoinPhrase: " & "},
+		{Name: "David Bowie"},
+	}
+	if got := JoinArtists(credits); got != "Queen & David Bowie" {
+		t.Errorf("JoinArtists = %q, want %q", got, "Queen & David Bowie")
+	}
+
+	noJoin := []ArtistCredit{{Name: "A"}, {Name: "B"}}
+	if got := JoinArtists(noJoin); got != "A, B" {
+		t.Errorf("JoinArtists = %q, want %q", got, "A, B")
+	}
+}
+
+func TestApplyFeatConvention(t *testing.T) {
+	// Spotify style: every artist in the array, featured ones named in the title.
+	spotify := TrackInfo{
+		Title:  "Get Lucky (feat. Pharrell Williams & Nile Rodgers)",
+		Artist: "Daft Punk, Pharrell Williams, Nile Rodgers",
+		Artists: []ArtistCredit{
+			{Name: "Daft Punk", JoinPhrase: ", "},
+			{Name: "Pharrell Williams", JoinPhrase: ", "},
+			{Name: "Nile Rodgers"},
+		},
+	}
+	// MusicBrainz style: featured marked by the join phrase, clean title.
+	musicbrainz := TrackInfo{
+		Title:  "Get Lucky",
+		Artist: "Daft Punk feat. Pharrell Williams & Nile Rodgers",
+		Artists: []ArtistCredit{
+			{Name: "Daft Punk", JoinPhrase: " feat. "},
+			{Name: "Pharrell Williams", JoinPhrase: " & "},
+			{Name: "Nile Rodgers"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		info       TrackInfo
+		conv       FeatConvention
+		extra      []string
+		wantTitle  string
+		wantArtist string
+		wantNames  []string
+	}{
+		{
+			name:       "spotify in artist",
+			info:       spotify,
+			conv:       FeatInArtist,
+			wantTitle:  "Get Lucky",
+			wantArtist: "Daft Punk feat. Pharrell Williams, Nile Rodgers",
+			wantNames:  []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"},
+		},
+		{
+			name:       "spotify in title",
+			info:       spotify,
+			conv:       FeatInTitle,
+			wantTitle:  "Get Lucky (feat. Pharrell Williams & Nile Rodgers)",
+			wantArtist: "Daft Punk",
+			wantNames:  []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"},
+		},
+		{
+			name:       "musicbrainz in artist keeps join phrases",
+			info:       musicbrainz,
+			conv:       FeatInArtist,
+			wantTitle:  "Get Lucky",
+			wantArtist: "Daft Punk feat. Pharrell Williams & Nile Rodgers",
+			wantNames:  []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"},
+		},
+		{
+			name:       "musicbrainz in title",
+			info:       musicbrainz,
+			conv:       FeatInTitle,
+			wantTitle:  "Get Lucky (feat. Pharrell Williams & Nile Rodgers)",
+			wantArtist: "Daft Punk",
+			wantNames:  []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"},
+		},
+		{
+			name:       "single-artist provider uses featured from source title",
+			info:       TrackInfo{Title: "HUMBLE.", Artist: "Kendrick Lamar"},
+			conv:       FeatInArtist,
+			extra:      []string{"Jay Rock"},
+			wantTitle:  "HUMBLE.",
+			wantArtist: "Kendrick Lamar feat. Jay Rock",
+			wantNames:  []string{"Kendrick Lamar", "Jay Rock"},
+		},
+		{
+			name:       "collaboration without featured artists untouched",
+			info:       TrackInfo{Title: "Under Pressure", Artist: "Queen & David Bowie", Artists: []ArtistCredit{{Name: "Queen", JoinPhrase: " & "}, {Name: "David Bowie"}}},
+			conv:       FeatInTitle,
+			wantTitle:  "Under Pressure",
+			wantArtist: "Queen & David Bowie",
+			wantNames:  []string{"Queen", "David Bowie"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyFeatConvention(tt.info, tt.conv, tt.extra)
+			if got.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			if got.Artist != tt.wantArtist {
+				t.Errorf("Artist = %q, want %q", got.Artist, tt.wantArtist)
+			}
+			if names := ArtistNames(got.Artists); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Artists = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestPrimaryArtist(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string][]string
+		want string
+	}{
+		{"artists tag wins", map[string][]string{taglib.Artists: {"Daft Punk", "Pharrell Williams"}, taglib.Artist: {"Something Else"}}, "Daft Punk"},
+		{"comma split", map[string][]string{taglib.Artist: {"Queen, David Bowie"}}, "Queen"},
+		{"feat split", map[string][]string{taglib.Artist: {"Kendrick Lamar feat. Jay Rock"}}, "Kendrick Lamar"},
+		{"single", map[string][]string{taglib.Artist: {"Marracash"}}, "Marracash"},
+	}
+	for _, tt := range tests {
+		if got := primaryArtist(tt.tags); got != tt.want {
+			t.Errorf("%s: primaryArtist = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
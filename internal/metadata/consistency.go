@@ -0,0 +1,408 @@
+package metadata
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.senan.xyz/taglib"
+)
+
+// tracklistWeight is the vote of a file positioned from a release tracklist
+// when reconciling album fields; other tagged files vote 1.
+const tracklistWeight = 3
+
+// mixedAlbumShare is the share of files whose album title disagrees with the
+// group's that marks the group as a mix of releases.
+const mixedAlbumShare = 0.25
+
+// albumFields are reconciled across an album group. MEDIA is left out as it
+// may differ between discs, and GENRE as tracks of one album may rightly
+// differ; the group's genre only fills files that have none.
+var albumFields = []string{
+	taglib.Album, taglib.AlbumArtist, taglib.AlbumSort, taglib.AlbumArtistSort, taglib.Date,
+	taglib.Label, taglib.CatalogNumber, taglib.Barcode, taglib.ReleaseCountry,
+	taglib.ReleaseType, taglib.OriginalDate,
+}
+
+// albumFile is a group member as seen by the consistency pass.
+type albumFile struct {
+	path    string
+	tags    map[string][]string
+	artwork []byte
+	weight  int
+}
+
+// reconcileGroup makes the album-level fields of the files in an album group
+// agree after per-file resolution: album, album artist, date, release
+// details and artwork are set to the value with the most weight,
+// tracklist-backed files counting more, and files without a genre get the
+// group's. Track and disc totals and the compilation flag are derived for the
+// whole group. Groups that look like several releases are only flagged.
+func (r *Resolver) reconcileGroup(album string, files []string) {
+	var members []albumFile
+	for _, path := range files {
+		if !r.tagged[path] && r.tracklists[path] == nil {
+			continue // kept its original tags, leave it alone
+		}
+		tags, err := r.readTags(path)
+		if err != nil {
+			continue
+		}
+		w := 1
+		if r.tracklists[path] != nil {
+			w = tracklistWeight
+		}
+		members = append(members, albumFile{path: path, tags: tags, artwork: r.readImage(path), weight: w})
+	}
+	if len(members) < 2 {
+		return
+	}
+
+	tl := r.dominantTracklist(members)
+
+	if reason := r.mixedReleaseReason(members, tl); reason != "" {
+		r.logger.Warn("Album %q looks like a mix of releases (%s), not reconciling", album, reason)
+		for _, m := range members {
+			r.fileReport(m.path).MixedRelease = reason
+		}
+		return
+	}
+
+	want := make(map[string][]string)
+	for _, field := range albumFields {
+		if v := voteField(members, field); len(v) > 0 {
+			want[field] = v
+		}
+	}
+	if tl != nil {
+		// The release's own spelling wins over whichever provider answered.
+		if tl.Title != "" {
+			want[taglib.Album] = []string{tl.Title}
+			want[taglib.AlbumSort] = []string{r.fold.sortName(tl.Title)}
+		}
+		if tl.Artist != "" {
+			albumArtistSort := tl.ArtistSort
+			if albumArtistSort == "" {
+				albumArtistSort = r.fold.sortName(tl.Artist)
+			}
+			want[taglib.AlbumArtist] = []string{tl.Artist}
+			want[taglib.AlbumArtistSort] = []string{albumArtistSort}
+		}
+		for k, v := range releaseTags(tl.Release) {
+			want[k] = v
+		}
+	}
+	if isCompilation(members, tl, want[taglib.AlbumArtist]) {
+		want[taglib.Compilation] = []string{"1"}
+	}
+	genre := voteField(members, taglib.Genre)
+	artwork := voteArtwork(members)
+
+	// Totals come from the release tracklist, or else from what the group's
+	// providers reported, raised to the highest position actually present.
+	totals := func(disc int) map[string][]string {
+		if tl != nil {
+			return totalTags(*tl, disc)
+		}
+		return groupTotals(members, disc)
+	}
+
+	for _, m := range members {
+		tags := make(map[string][]string)
+		for field, v := range want {
+			if !equalValues(m.tags[field], v) {
+				tags[field] = v
+			}
+		}
+		if len(genre) > 0 && firstTag(m.tags, taglib.Genre) == "" {
+			tags[taglib.Genre] = genre
+		}
+		for k, v := range totals(tagInt(m.tags, taglib.DiscNumber)) {
+			if !equalValues(m.tags[k], v) {
+				tags[k] = v
+			}
+		}
+		tags = r.policy.filter(tags, m.tags)
+
+		rep := r.fileReport(m.path)
+		if len(tags) > 0 {
+			if err := r.writeTags(m.path, tags); err != nil {
+				r.logger.Warn("  consistency: failed to write %s: %v", m.path, err)
+				continue
+			}
+			if rep.Reconciled == nil {
+				rep.Reconciled = make(map[string]string)
+			}
+			for k, v := range tags {
+				rep.Reconciled[k] = strings.Join(v, "; ")
+			}
+		}
+
+		if artwork != nil && imageHash(m.artwork) != imageHash(artwork) && r.mayReplaceArtwork(m.artwork) {
+			if err := r.writeArtwork(m.path, artwork); err != nil {
+				r.logger.Warn("  consistency: failed to write artwork to %s: %v", m.path, err)
+			} else {
+				if rep.Reconciled == nil {
+					rep.Reconciled = make(map[string]string)
+				}
+				rep.Reconciled[ArtworkField] = imageHash(artwork)
+			}
+		}
+	}
+}
+
+// dominantTracklist returns the tracklist that positioned most members, or nil.
+func (r *Resolver) dominantTracklist(members []albumFile) *Tracklist {
+	counts := make(map[*Tracklist]int)
+	var best *Tracklist
+	for _, m := range members {
+		tl := r.tracklists[m.path]
+		if tl == nil {
+			continue
+		}
+		counts[tl]++
+		if best == nil || counts[tl] > counts[best] {
+			best = tl
+		}
+	}
+	return best
+}
+
+// mixedReleaseReason explains why members look like more than one release,
+// or returns "" when they look like one.
+func (r *Resolver) mixedReleaseReason(members []albumFile, tl *Tracklist) string {
+	releases := make(map[string]bool)
+	for _, m := range members {
+		if mtl := r.tracklists[m.path]; mtl != nil {
+			releases[mtl.ID+"\x00"+mtl.Title] = true
+		}
+	}
+	if len(releases) > 1 {
+		return fmt.Sprintf("positioned from %d different releases", len(releases))
+	}
+
+	seen := make(map[[2]int]string)
+	for _, m := range members {
+		track := tagInt(m.tags, taglib.TrackNumber)
+		if track == 0 {
+			continue
+		}
+		pos := [2]int{tagInt(m.tags, taglib.DiscNumber), track}
+		if pos[0] == 0 {
+			pos[0] = 1
+		}
+		if other, ok := seen[pos]; ok && other != m.path {
+			return fmt.Sprintf("two files at disc %d track %d", pos[0], pos[1])
+		}
+		seen[pos] = m.path
+	}
+
+	var album string
+	if v := voteField(members, taglib.Album); len(v) > 0 {
+		album = v[0]
+	}
+	if tl != nil && tl.Title != "" {
+		album = tl.Title
+	}
+	if album == "" {
+		return ""
+	}
+	var dissent int
+	for _, m := range members {
+		if a := firstTag(m.tags, taglib.Album); a != "" && r.fold.compare(a, album) < albumDecisionMatch {
+			dissent++
+		}
+	}
+	if float64(dissent) > float64(len(members))*mixedAlbumShare {
+		return fmt.Sprintf("%d of %d files matched a different album", dissent, len(members))
+	}
+	return ""
+}
+
+// voteField returns the value of field with the most weight among members.
+// Dates vote by year; the most specific date of the winning year is returned.
+func voteField(members []albumFile, field string) []string {
+	weights := make(map[string]int)
+	values := make(map[string][]string)
+	var order []string
+	for _, m := range members {
+		v := m.tags[field]
+		if len(v) == 0 || strings.TrimSpace(v[0]) == "" {
+			continue
+		}
+		key := strings.Join(v, "\x00")
+		if field == taglib.Date && len(v[0]) >= 4 {
+			key = v[0][:4]
+		}
+		if _, ok := weights[key]; !ok {
+			order = append(order, key)
+		}
+		weights[key] += m.weight
+		if len(v[0]) > len(firstTag(values, key)) {
+			values[key] = v
+		}
+	}
+
+	var best string
+	for _, k := range order {
+		if best == "" || weights[k] > weights[best] {
+			best = k
+		}
+	}
+	return values[best]
+}
+
+// voteInt is voteField for a numeric field, 0 if no member has it.
+func voteInt(members []albumFile, field string) int {
+	v := voteField(members, field)
+	if len(v) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(v[0]))
+	return n
+}
+
+// voteArtwork returns the embedded picture with the most weight, or nil if no
+// member has one.
+func voteArtwork(members []albumFile) []byte {
+	weights := make(map[string]int)
+	images := make(map[string][]byte)
+	var order []string
+	for _, m := range members {
+		if len(m.artwork) == 0 {
+			continue
+		}
+		h := imageHash(m.artwork)
+		if _, ok := weights[h]; !ok {
+			order = append(order, h)
+			images[h] = m.artwork
+		}
+		weights[h] += m.weight
+	}
+	var best string
+	for _, h := range order {
+		if best == "" || weights[h] > weights[best] {
+			best = h
+		}
+	}
+	return images[best]
+}
+
+// isCompilation reports whether the group is a various-artists release: the
+// tracklist or reconciled album artist says so, or no single artist leads at
+// least half of three or more tracks.
+func isCompilation(members []albumFile, tl *Tracklist, albumArtist []string) bool {
+	if tl != nil && tl.Compilation {
+		return true
+	}
+	if len(albumArtist) > 0 && IsVariousArtists(albumArtist[0]) {
+		return true
+	}
+	if len(members) < 3 {
+		return false
+	}
+	counts := make(map[string]int)
+	var top int
+	for _, m := range members {
+		a := strings.ToLower(primaryArtist(m.tags))
+		if a == "" {
+			return false
+		}
+		counts[a]++
+		if counts[a] > top {
+			top = counts[a]
+		}
+	}
+	return top*2 < len(members)
+}
+
+// groupTotals derives the track total of disc (1 if unknown) and the disc
+// total from the members' own TOTALTRACKS/TOTALDISCS tags and positions.
+func groupTotals(members []albumFile, disc int) map[string][]string {
+	if disc == 0 {
+		disc = 1
+	}
+	var onDisc []albumFile
+	var maxTrack, maxDisc int
+	for _, m := range members {
+		d := tagInt(m.tags, taglib.DiscNumber)
+		if d == 0 {
+			d = 1
+		}
+		if d > maxDisc {
+			maxDisc = d
+		}
+		if d == disc {
+			onDisc = append(onDisc, m)
+			if n := tagInt(m.tags, taglib.TrackNumber); n > maxTrack {
+				maxTrack = n
+			}
+		}
+	}
+
+	tags := make(map[string][]string)
+	if tracks := voteInt(onDisc, TotalTracksTag); tracks > 0 {
+		tags[TotalTracksTag] = []string{strconv.Itoa(max(tracks, maxTrack))}
+	}
+	if discs := voteInt(members, TotalDiscsTag); discs > 0 || maxDisc > 1 {
+		tags[TotalDiscsTag] = []string{strconv.Itoa(max(discs, maxDisc))}
+	}
+	return tags
+}
+
+// mayReplaceArtwork applies the artwork policy to a file whose current
+// picture is current.
+func (r *Resolver) mayReplaceArtwork(current []byte) bool {
+	switch r.policy.For(ArtworkField) {
+	case PolicyKeep:
+		return false
+	case PolicyFill:
+		return len(current) == 0
+	}
+	return true
+}
+
+// totalTags returns the track total of disc (1 if unknown) and the disc total
+// of tl.
+func totalTags(tl Tracklist, disc int) map[string][]string {
+	if disc == 0 {
+		disc = 1
+	}
+	var tracks, discs int
+	for _, t := range tl.Tracks {
+		d := t.DiscNumber
+		if d == 0 {
+			d = 1
+		}
+		if d == disc {
+			tracks++
+		}
+		if d > discs {
+			discs = d
+		}
+	}
+	tags := make(map[string][]string)
+	if tracks > 0 {
+		tags[TotalTracksTag] = []string{strconv.Itoa(tracks)}
+	}
+	if discs > 0 {
+		tags[TotalDiscsTag] = []string{strconv.Itoa(discs)}
+	}
+	return tags
+}
+
+// tagInt parses a numeric tag such as TRACKNUMBER, accepting the "5/12" form.
+func tagInt(tags map[string][]string, key string) int {
+	v, _, _ := strings.Cut(firstTag(tags, key), "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(v))
+	return n
+}
+
+func imageHash(img []byte) string {
+	if len(img) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(img))
+}
@@ -0,0 +1,203 @@
+package metadata
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// folding controls how text is folded before it is compared or turned into a
+// sort name. The resolver carries its own, set by WithTransliteration.
+type folding struct {
+	transliterate bool // romanize kana, hangul and Cyrillic
+}
+
+// defaultFolding is the folding of FoldText, Similarity and SortName.
+var defaultFolding = folding{transliterate: true}
+
+// FoldText prepares a string for fuzzy comparison: applies NFKC (so full-width
+// "ＡＢＣ" becomes "ABC"), lowercases, transliterates non-Latin scripts to
+// Latin, and folds diacritics ("Beyoncé" → "beyonce").
+// Punctuation is preserved; callers strip it as needed.
+func FoldText(s string) string {
+	return defaultFolding.fold(s)
+}
+
+// ContainsFold reports whether list holds s, ignoring case.
+func ContainsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fold is FoldText with transliteration as f selects.
+func (f folding) fold(s string) string {
+	s = strings.ToLower(norm.NFKC.String(s))
+	if f.transliterate {
+		s = transliterate(s)
+	}
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		// Only strip Latin-style combining accents; kana voicing marks
+		// (U+3099/U+309A) must survive when transliteration is off.
+		if r >= 0x0300 && r <= 0x036F {
+			continue
+		}
+		if folded, ok := letterFolds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// letterFolds covers Latin letters that have no decomposition under NFD.
+var letterFolds = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ð': "d",
+	'ł': "l",
+	'þ': "th",
+	'ı': "i",
+}
+
+// transliterate romanizes Cyrillic (GOST-like), kana (Hepburn) and hangul
+// (Revised Romanization, without assimilation rules). Other scripts, including
+// kanji, are left unchanged.
+func transliterate(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			if lat, ok := cyrillicToLatin[r]; ok {
+				b.WriteString(lat)
+			} else {
+				b.WriteRune(r)
+			}
+
+		case unicode.Is(unicode.Hangul, r) && r >= 0xAC00 && r <= 0xD7A3:
+			b.WriteString(romanizeHangul(r))
+
+		case isKana(r):
+			out, consumed := romanizeKana(runes[i:])
+			b.WriteString(out)
+			i += consumed - 1
+
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Ukrainian, Belarusian, Serbian additions
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u", 'ђ': "dj",
+	'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+}
+
+// Revised Romanization tables for the initial, medial and final jamo of a
+// precomposed hangul syllable.
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+func romanizeHangul(r rune) string {
+	idx := int(r - 0xAC00)
+	initial := idx / (21 * 28)
+	medial := (idx % (21 * 28)) / 28
+	final := idx % 28
+	return hangulInitials[initial] + hangulMedials[medial] + hangulFinals[final]
+}
+
+func isKana(r rune) bool {
+	return (r >= 0x3041 && r <= 0x3096) || (r >= 0x30A1 && r <= 0x30FA) || r == 'ー'
+}
+
+// toHiragana maps katakana to the equivalent hiragana so a single table serves both.
+func toHiragana(r rune) rune {
+	if r >= 0x30A1 && r <= 0x30F6 {
+		return r - 0x60
+	}
+	return r
+}
+
+// romanizeKana romanizes the kana sequence at the start of runes and returns
+// the Latin text and the number of runes consumed. Handles yōon digraphs
+// (きゃ → kya), sokuon (っ doubles the next consonant) and the long-vowel mark.
+func romanizeKana(runes []rune) (string, int) {
+	r := toHiragana(runes[0])
+
+	switch r {
+	case 'っ':
+		if len(runes) > 1 && isKana(runes[1]) {
+			next, n := romanizeKana(runes[1:])
+			if next != "" && !strings.ContainsRune("aeioun", rune(next[0])) {
+				return next[:1] + next, n + 1
+			}
+			return next, n + 1
+		}
+		return "", 1
+	case 'ー':
+		return "", 1
+	}
+
+	base, ok := kanaToLatin[r]
+	if !ok {
+		return string(runes[0]), 1
+	}
+
+	if len(runes) > 1 {
+		if small, ok := kanaYoon[toHiragana(runes[1])]; ok && strings.HasSuffix(base, "i") && len(base) > 1 {
+			stem := base[:len(base)-1]
+			switch stem {
+			case "sh", "ch", "j":
+				return stem + small[1:], 2
+			}
+			return stem + small, 2
+		}
+	}
+	return base, 1
+}
+
+var kanaYoon = map[rune]string{'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo"}
+
+var kanaToLatin = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+}
@@ -0,0 +1,48 @@
+package metadata
+
+import "testing"
+
+func TestFoldText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Beyoncé", "beyonce"},
+		{"Sigur Rós", "sigur ros"},
+		{"Motörhead", "motorhead"},
+		{"Die Ärzte", "die arzte"},
+		{"Straße", "strasse"},
+		{"Mø", "mo"},
+		{"ＡＢＣ１２３", "abc123"},
+		{"Кино", "kino"},
+		{"Звёзды", "zvezdy"},
+		{"よるにかける", "yorunikakeru"},
+		{"ヨルシカ", "yorushika"},
+		{"きゃりーぱみゅぱみゅ", "kyaripamyupamyu"},
+		{"ずっと", "zutto"},
+		{"방탄소년단", "bangtansonyeondan"},
+		{"夜に駆ける", "夜ni駆keru"},
+	}
+
+	for _, tt := range tests {
+		if got := FoldText(tt.in); got != tt.want {
+			t.Errorf("FoldText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFold_TransliterationDisabled(t *testing.T) {
+	f := folding{transliterate: false}
+
+	if got := f.fold("Кино"); got != "кино" {
+		t.Errorf("fold(%q) = %q, want Cyrillic preserved", "Кино", got)
+	}
+	if got := f.fold("がっこう"); got != "がっこう" {
+		t.Errorf("fold(%q) = %q, want kana voicing marks preserved", "がっこう", got)
+	}
+	if got := f.fold("Beyoncé"); got != "beyonce" {
+		t.Errorf("fold(%q) = %q, diacritics should still be folded", "Beyoncé", got)
+	}
+	if got := f.sortName("Кино"); got != "Кино" {
+		t.Errorf("sortName(%q) = %q, want Cyrillic preserved", "Кино", got)
+	}
+}
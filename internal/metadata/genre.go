@@ -0,0 +1,365 @@
+package metadata
+
+import (
+	"strings"
+)
+
+// genreParents is the built-in canonical genre tree: each genre maps to its
+// parent, top-level genres to "".
+var genreParents = map[string]string{
+	"Blues":                  "",
+	"Classical":              "",
+	"Country":                "",
+	"Electronic":             "",
+	"Folk":                   "",
+	"Hip Hop":                "",
+	"Jazz":                   "",
+	"Latin":                  "",
+	"Metal":                  "",
+	"Pop":                    "",
+	"R&B":                    "",
+	"Reggae":                 "",
+	"Rock":                   "",
+	"Soundtrack":             "",
+	"World":                  "",
+	"Children's Music":       "",
+	"Spoken Word":            "",
+	"Easy Listening":         "",
+	"Religious":              "",
+	"Experimental":           "",
+	"Delta Blues":            "Blues",
+	"Chicago Blues":          "Blues",
+	"Blues Rock":             "Blues",
+	"Baroque":                "Classical",
+	"Romantic":               "Classical",
+	"Opera":                  "Classical",
+	"Contemporary Classical": "Classical",
+	"Choral":                 "Classical",
+	"Bluegrass":              "Country",
+	"Americana":              "Country",
+	"Country Pop":            "Country",
+	"Outlaw Country":         "Country",
+	"Ambient":                "Electronic",
+	"Breakbeat":              "Electronic",
+	"Downtempo":              "Electronic",
+	"Drum and Bass":          "Electronic",
+	"Dubstep":                "Electronic",
+	"Electro":                "Electronic",
+	"House":                  "Electronic",
+	"Deep House":             "House",
+	"Tech House":             "House",
+	"Progressive House":      "House",
+	"Techno":                 "Electronic",
+	"Trance":                 "Electronic",
+	"IDM":                    "Electronic",
+	"Synthwave":              "Electronic",
+	"Trip Hop":               "Electronic",
+	"UK Garage":              "Electronic",
+	"Hardcore":               "Electronic",
+	"Indie Folk":             "Folk",
+	"Folk Rock":              "Folk",
+	"Singer-Songwriter":      "Folk",
+	"Alternative Hip Hop":    "Hip Hop",
+	"Boom Bap":               "Hip Hop",
+	"Conscious Hip Hop":      "Hip Hop",
+	"Drill":                  "Hip Hop",
+	"Gangsta Rap":            "Hip Hop",
+	"Trap":                   "Hip Hop",
+	"Grime":                  "Hip Hop",
+	"Bebop":                  "Jazz",
+	"Cool Jazz":              "Jazz",
+	"Free Jazz":              "Jazz",
+	"Fusion":                 "Jazz",
+	"Smooth Jazz":            "Jazz",
+	"Swing":                  "Jazz",
+	"Vocal Jazz":             "Jazz",
+	"Bossa Nova":             "Latin",
+	"Cumbia":                 "Latin",
+	"Reggaeton":              "Latin",
+	"Salsa":                  "Latin",
+	"Tango":                  "Latin",
+	"Latin Pop":              "Latin",
+	"Black Metal":            "Metal",
+	"Death Metal":            "Metal",
+	"Doom Metal":             "Metal",
+	"Heavy Metal":            "Metal",
+	"Metalcore":              "Metal",
+	"Nu Metal":               "Metal",
+	"Power Metal":            "Metal",
+	"Progressive Metal":      "Metal",
+	"Thrash Metal":           "Metal",
+	"Art Pop":                "Pop",
+	"Dance Pop":              "Pop",
+	"Dream Pop":              "Pop",
+	"Electropop":             "Pop",
+	"Indie Pop":              "Pop",
+	"J-Pop":                  "Pop",
+	"K-Pop":                  "Pop",
+	"Synth-Pop":              "Pop",
+	"Teen Pop":               "Pop",
+	"Alternative R&B":        "R&B",
+	"Contemporary R&B":       "R&B",
+	"Funk":                   "R&B",
+	"Neo Soul":               "R&B",
+	"Soul":                   "R&B",
+	"Disco":                  "R&B",
+	"Gospel":                 "Religious",
+	"Christian":              "Religious",
+	"Dancehall":              "Reggae",
+	"Dub":                    "Reggae",
+	"Ska":                    "Reggae",
+	"Roots Reggae":           "Reggae",
+	"Alternative Rock":       "Rock",
+	"Indie Rock":             "Alternative Rock",
+	"Grunge":                 "Alternative Rock",
+	"Shoegaze":               "Alternative Rock",
+	"Britpop":                "Alternative Rock",
+	"Emo":                    "Alternative Rock",
+	"Art Rock":               "Rock",
+	"Classic Rock":           "Rock",
+	"Garage Rock":            "Rock",
+	"Hard Rock":              "Rock",
+	"Post-Punk":              "Rock",
+	"Post-Rock":              "Rock",
+	"Progressive Rock":       "Rock",
+	"Psychedelic Rock":       "Rock",
+	"Punk":                   "Rock",
+	"Pop Punk":               "Punk",
+	"Hardcore Punk":          "Punk",
+	"Post-Hardcore":          "Punk",
+	"Melodic Hardcore":       "Punk",
+	"Rock and Roll":          "Rock",
+	"Soft Rock":              "Rock",
+	"Stoner Rock":            "Rock",
+	"Afrobeat":               "World",
+	"Afrobeats":              "World",
+	"Flamenco":               "World",
+	"Celtic":                 "World",
+	"Film Score":             "Soundtrack",
+	"Video Game Music":       "Soundtrack",
+	"Musical":                "Soundtrack",
+	"Anime":                  "Soundtrack",
+	"Lounge":                 "Easy Listening",
+	"New Age":                "Easy Listening",
+	"Noise":                  "Experimental",
+	"Comedy":                 "Spoken Word",
+}
+
+// genreAliases maps provider spellings and micro-genres to canonical genres.
+// Keys are in genreKey form.
+var genreAliases = map[string]string{
+	"hip hop rap":        "Hip Hop",
+	"rap":                "Hip Hop",
+	"hiphop":             "Hip Hop",
+	"rap hip hop":        "Hip Hop",
+	"rnb":                "R&B",
+	"r b":                "R&B",
+	"r&b soul":           "R&B",
+	"rhythm and blues":   "R&B",
+	"soul funk":          "Soul",
+	"dance":              "Electronic",
+	"electronica":        "Electronic",
+	"edm":                "Electronic",
+	"electro house":      "House",
+	"dnb":                "Drum and Bass",
+	"drum n bass":        "Drum and Bass",
+	"d&b":                "Drum and Bass",
+	"alternative":        "Alternative Rock",
+	"alt rock":           "Alternative Rock",
+	"indie":              "Indie Rock",
+	"modern rock":        "Alternative Rock",
+	"rock & roll":        "Rock and Roll",
+	"rock n roll":        "Rock and Roll",
+	"punk rock":          "Punk",
+	"hard rock metal":    "Hard Rock",
+	"synthpop":           "Synth-Pop",
+	"electro pop":        "Electropop",
+	"jpop":               "J-Pop",
+	"kpop":               "K-Pop",
+	"country music":      "Country",
+	"christian & gospel": "Gospel",
+	"christian gospel":   "Gospel",
+	"soundtracks":        "Soundtrack",
+	"films games":        "Soundtrack",
+	"film games":         "Soundtrack",
+	"original score":     "Film Score",
+	"score":              "Film Score",
+	"triphop":            "Trip Hop",
+	"escape room":        "Alternative R&B",
+	"urban contemporary": "Contemporary R&B",
+	"latin urbano":       "Reggaeton",
+	"urbano latino":      "Reggaeton",
+	"musica mexicana":    "Latin",
+	"kids":               "Children's Music",
+	"kids family":        "Children's Music",
+	"vocal":              "Easy Listening",
+	"world music":        "World",
+	"worldwide":          "World",
+	"african music":      "World",
+	"african":            "World",
+	"reggae dancehall":   "Reggae",
+	"classic":            "Classical",
+	"classical music":    "Classical",
+	"jazz fusion":        "Fusion",
+	"trap music":         "Trap",
+	"uk drill":           "Drill",
+	"chicago drill":      "Drill",
+	"psychedelic":        "Psychedelic Rock",
+	"prog rock":          "Progressive Rock",
+	"prog":               "Progressive Rock",
+	"hardcore punk":      "Punk",
+	"edm dance":          "Electronic",
+	"dance electronic":   "Electronic",
+	"electronic dance":   "Electronic",
+	"electronic music":   "Electronic",
+	"lo fi":              "Downtempo",
+	"chillout":           "Downtempo",
+	"chill":              "Downtempo",
+	"pop rock":           "Rock",
+	"adult contemporary": "Soft Rock",
+}
+
+// GenreOptions configures a GenreNormalizer.
+type GenreOptions struct {
+	Aliases     map[string]string // extra provider genre → genre mappings, taking precedence over the built-in ones
+	Depth       int               // tree levels written per genre from the top, 0 for all
+	Whitelist   []string          // genres that may be written; empty allows any genre in the tree
+	KeepUnknown bool              // keep genres that are neither in the tree nor aliased
+	Separator   string            // joins the genres into a single value; empty writes one value per genre
+}
+
+// GenreNormalizer maps provider genres onto the canonical genre tree, so
+// Spotify's "Escape Room, Alternative R&B" and iTunes' "R&B/Soul" end up in
+// one vocabulary.
+type GenreNormalizer struct {
+	canonical map[string]string // genreKey → canonical genre
+	parents   map[string]string // canonical genre → parent
+	depth     int
+	whitelist map[string]bool // genreKey of allowed genres, nil allows all
+	keep      bool
+	separator string
+}
+
+// NewGenreNormalizer creates a GenreNormalizer with the built-in tree and
+// aliases extended by opts. An alias to a genre outside the tree adds it as
+// a top-level genre.
+func NewGenreNormalizer(opts GenreOptions) *GenreNormalizer {
+	n := &GenreNormalizer{
+		canonical: make(map[string]string, len(genreParents)+len(genreAliases)),
+		parents:   make(map[string]string, len(genreParents)),
+		depth:     opts.Depth,
+		keep:      opts.KeepUnknown,
+		separator: opts.Separator,
+	}
+	for g, parent := range genreParents {
+		n.parents[g] = parent
+		n.canonical[genreKey(g)] = g
+	}
+	for alias, g := range genreAliases {
+		n.canonical[alias] = g
+	}
+	for alias, g := range opts.Aliases {
+		if c, ok := n.canonical[genreKey(g)]; ok {
+			g = c
+		} else {
+			n.parents[g] = ""
+			n.canonical[genreKey(g)] = g
+		}
+		n.canonical[genreKey(alias)] = g
+	}
+	if len(opts.Whitelist) > 0 {
+		n.whitelist = make(map[string]bool, len(opts.Whitelist))
+		for _, g := range opts.Whitelist {
+			n.whitelist[genreKey(g)] = true
+		}
+	}
+	return n
+}
+
+// Normalize splits a provider genre string that isn't itself a known genre
+// on commas, semicolons and slashes and returns the canonical genres it names, each preceded by its
+// ancestors down to the configured depth ("Rock; Alternative Rock; Indie
+// Rock"). Unknown and non-whitelisted genres are dropped.
+func (n *GenreNormalizer) Normalize(raw string) []string {
+	var parts []string
+	if g, ok := n.canonical[genreKey(raw)]; ok {
+		parts = []string{g}
+	} else {
+		for _, p := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ';' || r == '/' }) {
+			if g, ok := n.lookup(p); ok {
+				parts = append(parts, g)
+			} else if p = strings.TrimSpace(p); p != "" && n.keep {
+				parts = append(parts, p)
+			}
+		}
+	}
+
+	var out []string
+	for _, g := range parts {
+		for _, v := range n.path(g) {
+			if n.whitelist != nil && !n.whitelist[genreKey(v)] {
+				continue
+			}
+			if !ContainsFold(out, v) {
+				out = append(out, v)
+			}
+		}
+	}
+	if n.separator != "" && len(out) > 1 {
+		return []string{strings.Join(out, n.separator)}
+	}
+	return out
+}
+
+// lookup finds the canonical genre of s, falling back to its longest known
+// suffix of two or more words so regional micro-genres like "German Indie
+// Rock" land on "Indie Rock". Single words are too ambiguous to fall back
+// on: "Melodic Hardcore" is punk, not the electronic "Hardcore".
+func (n *GenreNormalizer) lookup(s string) (string, bool) {
+	words := strings.Fields(genreKey(s))
+	for i := range words {
+		if i > 0 && len(words)-i < 2 {
+			break
+		}
+		if g, ok := n.canonical[strings.Join(words[i:], " ")]; ok {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// path returns g's ancestors from the top of the tree followed by g itself,
+// cut to the configured depth.
+func (n *GenreNormalizer) path(g string) []string {
+	var path []string
+	for cur := g; cur != ""; cur = n.parents[cur] {
+		path = append([]string{cur}, path...)
+	}
+	if n.depth > 0 && len(path) > n.depth {
+		path = path[:n.depth]
+	}
+	return path
+}
+
+// genreKey lowercases a genre and turns punctuation other than "&" into
+// spaces, so "Hip-Hop", "hip hop" and "HIP_HOP" compare equal.
+func genreKey(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', '/', '.', '\'', ',', ';', ':':
+			return ' '
+		}
+		return r
+	}, strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// normalizeGenres replaces info's provider genre with its canonical genres.
+func (r *Resolver) normalizeGenres(info TrackInfo) TrackInfo {
+	if r.genres == nil || info.Genre == "" {
+		return info
+	}
+	info.Genres = r.genres.Normalize(info.Genre)
+	info.Genre = strings.Join(info.Genres, "; ")
+	return info
+}
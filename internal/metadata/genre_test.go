@@ -0,0 +1,121 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+
+	"go.senan.xyz/taglib"
+)
+
+func TestGenreNormalizer(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenreOptions
+		raw  string
+		want []string
+	}{
+		{
+			name: "spotify micro-genres",
+			raw:  "Escape Room, Alternative R&B",
+			want: []string{"R&B", "Alternative R&B"},
+		},
+		{
+			name: "itunes combined genre",
+			raw:  "Hip-Hop/Rap",
+			want: []string{"Hip Hop"},
+		},
+		{
+			name: "nested genre with ancestors",
+			raw:  "indie rock",
+			want: []string{"Rock", "Alternative Rock", "Indie Rock"},
+		},
+		{
+			name: "regional prefix",
+			raw:  "German Indie Rock",
+			want: []string{"Rock", "Alternative Rock", "Indie Rock"},
+		},
+		{
+			name: "hardcore punk variants",
+			raw:  "Post-Hardcore, Melodic Hardcore",
+			want: []string{"Rock", "Punk", "Post-Hardcore", "Melodic Hardcore"},
+		},
+		{
+			name: "no single-word suffix fallback",
+			raw:  "Dark Techno, Hardcore",
+			want: []string{"Electronic", "Hardcore"},
+		},
+		{
+			name: "depth one",
+			opts: GenreOptions{Depth: 1},
+			raw:  "Indie Rock, Dream Pop",
+			want: []string{"Rock", "Pop"},
+		},
+		{
+			name: "depth two",
+			opts: GenreOptions{Depth: 2},
+			raw:  "Indie Rock",
+			want: []string{"Rock", "Alternative Rock"},
+		},
+		{
+			name: "unknown genres dropped",
+			raw:  "Bedroom Vibes, Techno",
+			want: []string{"Electronic", "Techno"},
+		},
+		{
+			name: "unknown genres kept",
+			opts: GenreOptions{KeepUnknown: true},
+			raw:  "Bedroom Vibes, Techno",
+			want: []string{"Bedroom Vibes", "Electronic", "Techno"},
+		},
+		{
+			name: "user alias to tree genre",
+			opts: GenreOptions{Aliases: map[string]string{"Escape Room": "soul"}},
+			raw:  "Escape Room",
+			want: []string{"R&B", "Soul"},
+		},
+		{
+			name: "user alias to new genre",
+			opts: GenreOptions{Aliases: map[string]string{"vapor": "Vaporwave"}},
+			raw:  "Vapor",
+			want: []string{"Vaporwave"},
+		},
+		{
+			name: "whitelist",
+			opts: GenreOptions{Whitelist: []string{"rock", "Pop"}},
+			raw:  "Indie Rock, Techno",
+			want: []string{"Rock"},
+		},
+		{
+			name: "separator joins values",
+			opts: GenreOptions{Separator: "; ", Depth: 2},
+			raw:  "Indie Rock",
+			want: []string{"Rock; Alternative Rock"},
+		},
+		{
+			name: "nothing usable",
+			raw:  "Bedroom Vibes",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewGenreNormalizer(tt.opts).Normalize(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeGenres_TagMap(t *testing.T) {
+	r := NewResolver(nil, nil, 0).WithGenreNormalizer(NewGenreNormalizer(GenreOptions{Depth: 2}))
+	info := r.normalizeGenres(TrackInfo{Genre: "Indie Rock, Synth Pop"})
+
+	want := []string{"Rock", "Alternative Rock", "Pop", "Synth-Pop"}
+	if got := tagMap(info)[taglib.Genre]; !reflect.DeepEqual(got, want) {
+		t.Errorf("GENRE = %q, want %q", got, want)
+	}
+	if info.Genre != "Rock; Alternative Rock; Pop; Synth-Pop" {
+		t.Errorf("Genre = %q", info.Genre)
+	}
+}
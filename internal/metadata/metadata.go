@@ -5,29 +5,110 @@ import (
 	"time"
 )
 
+// ArtistCredit is one artist in a track's ordered credit list.
+type ArtistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"join_phrase,omitempty"` // text joining this artist to the next, e.g. " & " or " feat. "; empty for the last
+	SortName   string `json:"sort_name,omitempty"`   // e.g. "Beatles, The", when the provider reports it
+}
+
+// MusicBrainzIDs holds the MusicBrainz identifiers of a matched track. All IDs
+// refer to the same release, so they are merged as a unit.
+type MusicBrainzIDs struct {
+	RecordingID    string   `json:"recording_id,omitempty"`     // MUSICBRAINZ_TRACKID
+	TrackID        string   `json:"track_id,omitempty"`         // MUSICBRAINZ_RELEASETRACKID, the track on a specific release
+	ReleaseID      string   `json:"release_id,omitempty"`       // MUSICBRAINZ_ALBUMID
+	ReleaseGroupID string   `json:"release_group_id,omitempty"` // MUSICBRAINZ_RELEASEGROUPID
+	ArtistIDs      []string `json:"artist_ids,omitempty"`       // MUSICBRAINZ_ARTISTID, in credit order
+	AlbumArtistIDs []string `json:"album_artist_ids,omitempty"` // MUSICBRAINZ_ALBUMARTISTID, in credit order
+}
+
+// IsZero reports whether no identifier is set.
+func (m MusicBrainzIDs) IsZero() bool {
+	return m.RecordingID == "" && m.TrackID == "" && m.ReleaseID == "" &&
+		m.ReleaseGroupID == "" && len(m.ArtistIDs) == 0 && len(m.AlbumArtistIDs) == 0
+}
+
+// ReleaseInfo holds the release-level details collectors tag: label,
+// catalog number, barcode, country, type, medium format and original date.
+type ReleaseInfo struct {
+	Label         string   `json:"label,omitempty"`          // LABEL
+	CatalogNumber string   `json:"catalog_number,omitempty"` // CATALOGNUMBER
+	Barcode       string   `json:"barcode,omitempty"`        // BARCODE
+	Country       string   `json:"country,omitempty"`        // RELEASECOUNTRY, e.g. "US" or "XW" for worldwide
+	Types         []string `json:"types,omitempty"`          // RELEASETYPE, primary then secondary, e.g. "album", "compilation"
+	Media         string   `json:"media,omitempty"`          // MEDIA, e.g. "CD" or "Digital Media"
+	OriginalDate  string   `json:"original_date,omitempty"`  // ORIGINALDATE, first release of the release group
+}
+
+// fill returns r with its empty fields taken from other.
+func (r ReleaseInfo) fill(other ReleaseInfo) ReleaseInfo {
+	fillString := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fillString(&r.Label, other.Label)
+	fillString(&r.CatalogNumber, other.CatalogNumber)
+	fillString(&r.Barcode, other.Barcode)
+	fillString(&r.Country, other.Country)
+	fillString(&r.Media, other.Media)
+	fillString(&r.OriginalDate, other.OriginalDate)
+	if len(r.Types) == 0 {
+		r.Types = other.Types
+	}
+	return r
+}
+
+// Credits lists the people credited on a recording and the work it records.
+type Credits struct {
+	Composers  []string `json:"composers,omitempty"`  // COMPOSER
+	Lyricists  []string `json:"lyricists,omitempty"`  // LYRICIST
+	Arrangers  []string `json:"arrangers,omitempty"`  // ARRANGER
+	Performers []string `json:"performers,omitempty"` // PERFORMER, "Name (instrument)"
+	Fetched    bool     `json:"-"`                    // looked up already, even if nobody is credited
+}
+
+// IsZero reports whether nobody is credited.
+func (c Credits) IsZero() bool {
+	return len(c.Composers) == 0 && len(c.Lyricists) == 0 && len(c.Arrangers) == 0 && len(c.Performers) == 0
+}
+
 // TrackInfo contains metadata for a single audio track.
 type TrackInfo struct {
-	Title       string
-	Artist      string
-	Album       string
-	AlbumArtist string
-	TrackNumber int
-	TotalTracks int
-	DiscNumber  int
-	Year        int
-	ReleaseDate string // full date "2020-03-20" when available
-	Genre       string
-	ISRC        string
-	ArtworkURL  string
-	Duration    time.Duration
-	Confidence  float64 // 0.0-1.0, how confident we are in the match
+	Title       string         `json:"title"`
+	Artist      string         `json:"artist"`            // display string, e.g. "Daft Punk feat. Pharrell Williams"
+	Artists     []ArtistCredit `json:"artists,omitempty"` // ordered credits when the provider reports them
+	Album       string         `json:"album,omitempty"`
+	AlbumID     string         `json:"album_id,omitempty"` // the provider's own album ID, for AlbumDetailsResolver
+	AlbumArtist string         `json:"album_artist,omitempty"`
+	TrackNumber int            `json:"track_number,omitempty"`
+	TotalTracks int            `json:"total_tracks,omitempty"` // tracks on this disc
+	DiscNumber  int            `json:"disc_number,omitempty"`
+	TotalDiscs  int            `json:"total_discs,omitempty"`
+	Compilation bool           `json:"compilation,omitempty"` // release credited to various artists
+	Year        int            `json:"year,omitempty"`
+	ReleaseDate string         `json:"release_date,omitempty"` // full date "2020-03-20" when available
+	Genre       string         `json:"genre,omitempty"`
+	Genres      []string       `json:"genres,omitempty"` // canonical genres, set by genre normalization
+	ISRC        string         `json:"isrc,omitempty"`
+	ArtworkURL  string         `json:"artwork_url,omitempty"`
+	Release     ReleaseInfo    `json:"release"`
+	Credits     Credits        `json:"credits"`
+	Sort        SortNames      `json:"sort"` // provider sort names; fallbacks are filled in before writing
+	Duration    time.Duration  `json:"duration,omitempty"`
+	Version     Version        `json:"version"`     // set by providers that report the variant separately from the title
+	MusicBrainz MusicBrainzIDs `json:"musicbrainz"` // set when the match came from MusicBrainz
+	Confidence  float64        `json:"confidence"`  // 0.0-1.0, how confident we are in the match
 }
 
 // SearchQuery represents a cleaned-up query for searching metadata providers.
 type SearchQuery struct {
-	Title  string
-	Artist string
-	Album  string
+	Title    string   `json:"title"`
+	Artist   string   `json:"artist"`
+	Album    string   `json:"album,omitempty"`
+	Version  Version  `json:"version"`            // version qualifier stripped from Title
+	Featured []string `json:"featured,omitempty"` // featured artists stripped from Title
 }
 
 // Provider is the interface that metadata providers must implement.
@@ -36,20 +117,32 @@ type Provider interface {
 	Search(ctx context.Context, query SearchQuery) ([]TrackInfo, error)
 }
 
+// ISRCLookup is an optional Provider capability: an exact lookup of the
+// recordings carrying an ISRC.
+type ISRCLookup interface {
+	LookupByISRC(ctx context.Context, isrc string) ([]TrackInfo, error)
+}
+
 // ReleaseTrack is a single track within a release tracklist.
 type ReleaseTrack struct {
 	TrackNumber int
 	DiscNumber  int
 	Title       string
-	MBID        string
+	MBID        string        // recording ID
+	TrackID     string        // MusicBrainz track ID on this release
+	Duration    time.Duration // 0 if unknown
 }
 
 // Tracklist is the complete track listing of a music release.
 type Tracklist struct {
-	ID     string
-	Title  string
-	Artist string
-	Tracks []ReleaseTrack
+	ID             string
+	ReleaseGroupID string // MusicBrainz release group, empty for other sources
+	Title          string
+	Artist         string
+	ArtistSort     string // sort name of Artist, empty if unknown
+	Compilation    bool   // credited to various artists
+	Release        ReleaseInfo
+	Tracks         []ReleaseTrack
 }
 
 // AlbumResolver looks up a release's complete tracklist by album name and artist.
@@ -57,11 +150,20 @@ type AlbumResolver interface {
 	ResolveAlbum(ctx context.Context, album, artist string) (Tracklist, bool, error)
 }
 
+// AlbumCandidates is an optional AlbumResolver capability: the tracklists of
+// up to limit releases matching an album, best match first, so the resolver
+// can choose the edition that fits the files (standard vs deluxe).
+type AlbumCandidates interface {
+	ResolveAlbumCandidates(ctx context.Context, album, artist string, limit int) ([]Tracklist, error)
+}
+
 // Fingerprinter identifies an audio file by its acoustic fingerprint and returns
-// the best matching TrackInfo. preferAlbum hints which release to prefer when a
-// recording appears in multiple albums. Returns (zero, false, nil) when no match is found.
+// the best matching TrackInfo. query holds the file's normalized tags: its title
+// and artist help choose among recordings sharing the fingerprint, and its album
+// hints which release to prefer when a recording appears in multiple albums.
+// Returns (zero, false, nil) when no match is found.
 type Fingerprinter interface {
-	LookupByFile(ctx context.Context, path, preferAlbum string) (TrackInfo, bool, error)
+	LookupByFile(ctx context.Context, path string, query SearchQuery) (TrackInfo, bool, error)
 }
 
 // FileMatch holds an audio file path and its AcoustID recording MBID.
@@ -76,8 +178,22 @@ type BatchFingerprinter interface {
 	BatchLookupByFiles(ctx context.Context, paths []string) []FileMatch
 }
 
+// CreditsResolver looks up the composer, lyricist, arranger and performer
+// credits of a MusicBrainz recording.
+type CreditsResolver interface {
+	Credits(ctx context.Context, recordingID string) (Credits, error)
+}
+
+// AlbumDetailsResolver is an optional Provider capability: the album details
+// its search results leave out (per-disc track total, disc total, label,
+// barcode), looked up for the chosen match only.
+type AlbumDetailsResolver interface {
+	AlbumDetails(ctx context.Context, info TrackInfo) (TrackInfo, error)
+}
+
 // ReleaseResolver looks up which releases contain a recording and fetches
-// a full tracklist by release ID.
+// a full tracklist by release ID. ReleaseIDsForRecording lists the most
+// preferred release first.
 type ReleaseResolver interface {
 	ReleaseIDsForRecording(ctx context.Context, mbid string) ([]string, error)
 	LookupTracklist(ctx context.Context, releaseID string) (Tracklist, error)
@@ -45,6 +45,112 @@ var vevoPattern = regexp.MustCompile(`(?i)vevo$`)
 // Pattern for "Artist - Title" format (common in YouTube titles)
 var artistTitleSeparator = regexp.MustCompile(`^(.+?)\s*[-–—]\s*(.+)$`)
 
+// VersionKind classifies a recording variant (live, remix, ...).
+// The zero value means the original studio recording.
+type VersionKind string
+
+const (
+	VersionOriginal     VersionKind = ""
+	VersionLive         VersionKind = "live"
+	VersionRemix        VersionKind = "remix"
+	VersionAcoustic     VersionKind = "acoustic"
+	VersionInstrumental VersionKind = "instrumental"
+	VersionDemo         VersionKind = "demo"
+	VersionRemaster     VersionKind = "remaster"
+)
+
+// Version is a structured version qualifier extracted from a title,
+// e.g. "(Live at Wembley 1986)" → {Kind: live, Label: "Live at Wembley 1986"}.
+type Version struct {
+	Kind  VersionKind `json:"kind,omitempty"`
+	Label string      `json:"label,omitempty"` // qualifier text without brackets or dash
+}
+
+// Matches returns true if both versions refer to the same recording variant.
+// Remasters are treated as the original recording.
+func (v Version) Matches(other Version) bool {
+	return v.baseKind() == other.baseKind()
+}
+
+func (v Version) baseKind() VersionKind {
+	if v.Kind == VersionRemaster {
+		return VersionOriginal
+	}
+	return v.Kind
+}
+
+// versionPatterns classify qualifier text. Order matters: the first match wins,
+// and remaster is checked last so "Live - Remastered" stays a live version.
+var versionPatterns = []struct {
+	kind VersionKind
+	re   *regexp.Regexp
+}{
+	{VersionOriginal, regexp.MustCompile(`(?i)^(original|album|single|radio)\s+(mix|version|edit)$`)},
+	{VersionLive, regexp.MustCompile(`(?i)^live\b(\s*@.*|\s+(at|from|in|on|version|session|recording)\b.*|\s+\d{4}.*)?$|\blive\s+(at|from|in|on)\b`)},
+	{VersionRemix, regexp.MustCompile(`(?i)\b(remix|rmx|re-?edit|(club|extended|dub)\s+mix)\b`)},
+	{VersionAcoustic, regexp.MustCompile(`(?i)\b(acoustic|unplugged|stripped)\b`)},
+	{VersionInstrumental, regexp.MustCompile(`(?i)\binstrumental\b`)},
+	{VersionDemo, regexp.MustCompile(`(?i)\bdemo\b`)},
+	{VersionRemaster, regexp.MustCompile(`(?i)\bremaster(ed)?\b`)},
+}
+
+// Pattern for parenthesized or bracketed qualifiers: "Song (Live)", "Song [Remix]"
+var versionGroupPattern = regexp.MustCompile(`\s*[\(\[]([^\(\)\[\]]+)[\)\]]`)
+
+// Pattern for dash-separated qualifiers: "Song - Remastered 2011"
+var versionDashPattern = regexp.MustCompile(`\s+[-–—]\s+([^-–—]+)$`)
+
+// ParseVersion classifies a bare qualifier string such as "Live" or "2011 Remaster".
+// Returns false if the text is not a recognized version qualifier.
+func ParseVersion(s string) (Version, bool) {
+	s = strings.TrimSpace(strings.Trim(strings.TrimSpace(s), "()[]"))
+	if s == "" {
+		return Version{}, false
+	}
+	for _, p := range versionPatterns {
+		if p.re.MatchString(s) {
+			if p.kind == VersionOriginal {
+				return Version{}, true
+			}
+			return Version{Kind: p.kind, Label: s}, true
+		}
+	}
+	return Version{}, false
+}
+
+// ExtractVersion removes recognized version qualifiers from title and returns
+// the cleaned title together with the most specific qualifier found. Unrecognized
+// parenthesized text (e.g. "(Don't Fear) The Reaper") is left untouched.
+func ExtractVersion(title string) (string, Version) {
+	var found Version
+	keep := func(v Version) {
+		if found.Kind == VersionOriginal || found.Kind == VersionRemaster {
+			if v.Kind != VersionOriginal {
+				found = v
+			}
+		}
+	}
+
+	title = versionGroupPattern.ReplaceAllStringFunc(title, func(m string) string {
+		inner := versionGroupPattern.FindStringSubmatch(m)[1]
+		v, ok := ParseVersion(inner)
+		if !ok {
+			return m
+		}
+		keep(v)
+		return ""
+	})
+
+	if m := versionDashPattern.FindStringSubmatchIndex(title); m != nil {
+		if v, ok := ParseVersion(title[m[2]:m[3]]); ok {
+			keep(v)
+			title = title[:m[0]]
+		}
+	}
+
+	return strings.TrimSpace(title), found
+}
+
 // NormalizeQuery takes raw metadata (typically from yt-dlp) and returns a cleaned SearchQuery.
 func NormalizeQuery(title, artist string) SearchQuery {
 	title = strings.TrimSpace(title)
@@ -65,7 +171,10 @@ func NormalizeQuery(title, artist string) SearchQuery {
 	}
 
 	// Extract featuring artists (keep them stripped from title for cleaner search)
-	title = featuringPattern.ReplaceAllString(title, "")
+	title, featured := ExtractFeatured(title)
+
+	// Extract the version qualifier so it can be scored separately from the title
+	title, version := ExtractVersion(title)
 
 	// If artist is empty, try to split "Artist - Title" from the title string
 	if artist == "" {
@@ -79,7 +188,9 @@ func NormalizeQuery(title, artist string) SearchQuery {
 	artist = strings.TrimSpace(artist)
 
 	return SearchQuery{
-		Title:  title,
-		Artist: artist,
+		Title:    title,
+		Artist:   artist,
+		Version:  version,
+		Featured: featured,
 	}
 }
@@ -164,3 +164,70 @@ func TestNormalizeQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		title     string
+		wantTitle string
+		wantKind  VersionKind
+	}{
+		{"no qualifier", "Bohemian Rhapsody", "Bohemian Rhapsody", VersionOriginal},
+		{"live parentheses", "Bohemian Rhapsody (Live)", "Bohemian Rhapsody", VersionLive},
+		{"live at venue", "Bohemian Rhapsody (Live at Wembley 1986)", "Bohemian Rhapsody", VersionLive},
+		{"live with at sign", "Salvador Dalí (Live @ Santeria Tour 2017)", "Salvador Dalí", VersionLive},
+		{"dash live", "Wonderwall - Live", "Wonderwall", VersionLive},
+		{"dash remaster", "Here Comes The Sun - Remastered 2009", "Here Comes The Sun", VersionRemaster},
+		{"bracket remaster", "Heroes [2017 Remaster]", "Heroes", VersionRemaster},
+		{"remix", "Titanium (David Guetta Remix)", "Titanium", VersionRemix},
+		{"extended mix", "Strobe (Extended Mix)", "Strobe", VersionRemix},
+		{"acoustic", "Layla (Acoustic)", "Layla", VersionAcoustic},
+		{"unplugged dash", "Layla - Unplugged", "Layla", VersionAcoustic},
+		{"original mix is original", "Strobe (Original Mix)", "Strobe", VersionOriginal},
+		{"live wins over remaster", "Heroes (Live) [Remastered]", "Heroes", VersionLive},
+		{"unrelated parentheses kept", "(Don't Fear) The Reaper", "(Don't Fear) The Reaper", VersionOriginal},
+		{"song named live forever kept", "Live Forever", "Live Forever", VersionOriginal},
+		{"artist dash title untouched", "Oasis - Live Forever", "Oasis - Live Forever", VersionOriginal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, v := ExtractVersion(tt.title)
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if v.Kind != tt.wantKind {
+				t.Errorf("kind = %q, want %q", v.Kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestNormalizeQuery_ExtractsVersion(t *testing.T) {
+	got := NormalizeQuery("Wonderwall - Live at Knebworth (Official Audio)", "Oasis")
+	if got.Title != "Wonderwall" {
+		t.Errorf("title = %q, want %q", got.Title, "Wonderwall")
+	}
+	if got.Version.Kind != VersionLive {
+		t.Errorf("version = %q, want %q", got.Version.Kind, VersionLive)
+	}
+	if got.Version.Label != "Live at Knebworth" {
+		t.Errorf("label = %q, want %q", got.Version.Label, "Live at Knebworth")
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	original := Version{}
+	remaster := Version{Kind: VersionRemaster}
+	live := Version{Kind: VersionLive}
+
+	if !original.Matches(remaster) {
+		t.Error("remaster should match original")
+	}
+	if original.Matches(live) {
+		t.Error("live should not match original")
+	}
+	if !live.Matches(Version{Kind: VersionLive, Label: "Live 1994"}) {
+		t.Error("live should match live regardless of label")
+	}
+}
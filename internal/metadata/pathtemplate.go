@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.senan.xyz/taglib"
+)
+
+// defaultPathTemplate files tracks as "Album Artist/Album".
+const defaultPathTemplate = "{albumartist}/{album}"
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// pathFields are the placeholders a path template may use. Sort placeholders
+// fall back to the plain name when the file has no sort tag.
+var pathFields = map[string]func(tags map[string][]string) string{
+	"artist": func(tags map[string][]string) string { return firstTag(tags, taglib.Artist) },
+	"artistsort": func(tags map[string][]string) string {
+		return firstNonEmpty(firstTag(tags, taglib.ArtistSort), SortName(firstTag(tags, taglib.Artist)))
+	},
+	"albumartist": dirArtist,
+	"albumartistsort": func(tags map[string][]string) string {
+		if a := firstTag(tags, taglib.AlbumArtist); a != "" && !IsVariousArtists(a) {
+			if s := firstTag(tags, taglib.AlbumArtistSort); s != "" {
+				return s
+			}
+		}
+		return SortName(dirArtist(tags))
+	},
+	"album": func(tags map[string][]string) string {
+		return firstNonEmpty(firstTag(tags, taglib.Album), "Unknown Album")
+	},
+	"albumsort": func(tags map[string][]string) string {
+		return firstNonEmpty(firstTag(tags, taglib.AlbumSort), SortName(firstTag(tags, taglib.Album)), "Unknown Album")
+	},
+	"title": func(tags map[string][]string) string { return firstTag(tags, taglib.Title) },
+	"titlesort": func(tags map[string][]string) string {
+		return firstNonEmpty(firstTag(tags, taglib.TitleSort), SortName(firstTag(tags, taglib.Title)))
+	},
+	"year": func(tags map[string][]string) string {
+		if d := firstTag(tags, taglib.Date); len(d) >= 4 {
+			return d[:4]
+		}
+		return ""
+	},
+	"genre": func(tags map[string][]string) string { return firstTag(tags, taglib.Genre) },
+	"label": func(tags map[string][]string) string { return firstTag(tags, taglib.Label) },
+}
+
+// ValidatePathTemplate reports placeholders in template that SubDirFunc does
+// not know, and templates that would leave the output directory.
+func ValidatePathTemplate(template string) error {
+	if strings.HasPrefix(template, "/") || filepath.IsAbs(template) {
+		return fmt.Errorf("template must be relative to output_dir, got %q", template)
+	}
+	for _, part := range strings.Split(template, "/") {
+		if p := strings.TrimSpace(part); p == "." || p == ".." {
+			return fmt.Errorf("template may not contain %q parts", p)
+		}
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := pathFields[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s}", m[1])
+		}
+	}
+	return nil
+}
+
+// SubDirFunc returns a function that reads a file's tags and builds its
+// output subdirectory from template, e.g. "{albumartistsort}/{year} - {album}".
+// Each "/"-separated part becomes one directory; parts that render empty are
+// dropped, and parts that render as "." or ".." are escaped so no file
+// leaves the output directory. An empty template gives the "Album
+// Artist/Album" layout.
+func SubDirFunc(template string) func(string) string {
+	if template == "" {
+		template = defaultPathTemplate
+	}
+	return func(path string) string {
+		tags, err := taglib.ReadTags(path)
+		if err != nil {
+			return ""
+		}
+		return renderPathTemplate(template, tags)
+	}
+}
+
+func renderPathTemplate(template string, tags map[string][]string) string {
+	var parts []string
+	for _, part := range strings.Split(template, "/") {
+		part = placeholderPattern.ReplaceAllStringFunc(part, func(m string) string {
+			if field, ok := pathFields[m[1:len(m)-1]]; ok {
+				return field(tags)
+			}
+			return m
+		})
+		part = sanitizePath(part)
+		if part == "." || part == ".." {
+			part = strings.Repeat("_", len(part))
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return filepath.Join(parts...)
+}
+
+// dirArtist is the artist a file is filed under: its album artist, or its
+// primary artist for compilations and files without one.
+func dirArtist(tags map[string][]string) string {
+	artist := firstTag(tags, taglib.AlbumArtist)
+	if artist == "" || IsVariousArtists(artist) {
+		artist = primaryArtist(tags)
+	}
+	return firstNonEmpty(artist, "Unknown Artist")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
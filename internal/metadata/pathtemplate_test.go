@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.senan.xyz/taglib"
+)
+
+func TestRenderPathTemplate(t *testing.T) {
+	beatles := map[string][]string{
+		taglib.Artist:          {"The Beatles"},
+		taglib.AlbumArtist:     {"The Beatles"},
+		taglib.AlbumArtistSort: {"Beatles, The"},
+		taglib.Album:           {"Abbey Road"},
+		taglib.Date:            {"1969-09-26"},
+	}
+	compilation := map[string][]string{
+		taglib.Artist:      {"Kavinsky"},
+		taglib.AlbumArtist: {"Various Artists"},
+		taglib.Album:       {"Drive: Original Motion Picture Soundtrack"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		tags     map[string][]string
+		want     string
+	}{
+		{"default layout", defaultPathTemplate, beatles, filepath.Join("The Beatles", "Abbey Road")},
+		{"album artist sort", "{albumartistsort}/{year} - {album}", beatles, filepath.Join("Beatles, The", "1969 - Abbey Road")},
+		{"sort fallback", "{artistsort}", beatles, "Beatles, The"},
+		{"compilation filed under the track artist", "{albumartistsort}/{album}", compilation, filepath.Join("Kavinsky", "Drive_ Original Motion Picture Soundtrack")},
+		{"empty part dropped", "{genre}/{album}", beatles, "Abbey Road"},
+		{"missing tags", defaultPathTemplate, map[string][]string{}, filepath.Join("Unknown Artist", "Unknown Album")},
+		{"dot parts escaped", "{genre}/{album}", map[string][]string{taglib.Genre: {".."}, taglib.Album: {"."}}, filepath.Join("__", "_")},
+		{"dots within a name kept", "{album}", map[string][]string{taglib.Album: {"..."}}, "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderPathTemplate(tt.template, tt.tags); got != tt.want {
+				t.Errorf("renderPathTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	if err := ValidatePathTemplate("{albumartistsort}/{year} - {albumsort}"); err != nil {
+		t.Errorf("valid template rejected: %v", err)
+	}
+	for _, template := range []string{"{albumartist}/{disc}", "/music/{album}", "../{album}", "{albumartist}/./{album}"} {
+		if err := ValidatePathTemplate(template); err == nil {
+			t.Errorf("ValidatePathTemplate(%q) accepted", template)
+		}
+	}
+}
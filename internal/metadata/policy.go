@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"strings"
+
+	"go.senan.xyz/taglib"
+)
+
+// WritePolicy controls whether the resolver may change a tag that already has
+// a value.
+type WritePolicy string
+
+const (
+	// PolicyOverwrite replaces the existing value.
+	PolicyOverwrite WritePolicy = "overwrite"
+	// PolicyFill writes only when the tag is empty.
+	PolicyFill WritePolicy = "fill"
+	// PolicyKeep never writes the tag.
+	PolicyKeep WritePolicy = "keep"
+)
+
+// LockTag is the marker tag that makes the resolver leave a file entirely
+// alone when set to any non-empty value.
+const LockTag = "YTMUSIC_LOCKED"
+
+// ArtworkField is the TagPolicy key for embedded artwork.
+const ArtworkField = "artwork"
+
+// TagPolicy maps fields to write policies. Keys are the tag names the
+// resolver writes, case-insensitive ("title", "albumartist",
+// "musicbrainz_albumid"), plus ArtworkField. Unlisted fields use
+// defaultPolicy.
+type TagPolicy map[string]WritePolicy
+
+// policyFields are the tags the resolver writes.
+var policyFields = []string{
+	taglib.Title, taglib.Artist, taglib.Artists, taglib.Album, taglib.AlbumArtist,
+	taglib.ArtistSort, taglib.AlbumArtistSort, taglib.AlbumSort, taglib.TitleSort,
+	taglib.TrackNumber, TotalTracksTag, taglib.DiscNumber, TotalDiscsTag,
+	taglib.Compilation, taglib.Date, taglib.Genre, taglib.ISRC,
+	taglib.Label, taglib.CatalogNumber, taglib.Barcode, taglib.ReleaseCountry,
+	taglib.ReleaseType, taglib.Media, taglib.OriginalDate,
+	taglib.Composer, taglib.Lyricist, taglib.Arranger, taglib.Performer,
+	taglib.MusicBrainzTrackID, taglib.MusicBrainzReleaseTrackID, taglib.MusicBrainzAlbumID,
+	taglib.MusicBrainzReleaseGroupID, taglib.MusicBrainzArtistID, taglib.MusicBrainzAlbumArtistID,
+}
+
+// IsPolicyField reports whether field is a valid TagPolicy key.
+func IsPolicyField(field string) bool {
+	return strings.EqualFold(field, ArtworkField) || ContainsFold(policyFields, field)
+}
+
+// defaultPolicy protects track and disc numbers, which yt-dlp or the
+// positional-tag phases already set, and overwrites everything else.
+func defaultPolicy(field string) WritePolicy {
+	switch field {
+	case taglib.TrackNumber, taglib.DiscNumber:
+		return PolicyFill
+	}
+	return PolicyOverwrite
+}
+
+// For returns the policy for a tag name or ArtworkField.
+func (p TagPolicy) For(field string) WritePolicy {
+	for k, v := range p {
+		if strings.EqualFold(k, field) && v != "" {
+			return v
+		}
+	}
+	return defaultPolicy(strings.ToUpper(field))
+}
+
+// filter returns the entries of tags that may be written to a file whose
+// current tags are existing.
+func (p TagPolicy) filter(tags, existing map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(tags))
+	for k, v := range tags {
+		switch p.For(k) {
+		case PolicyKeep:
+			continue
+		case PolicyFill:
+			if firstTag(existing, k) != "" {
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// IsLocked reports whether tags carry the LockTag marker.
+func IsLocked(tags map[string][]string) bool {
+	return strings.TrimSpace(firstTag(tags, LockTag)) != ""
+}
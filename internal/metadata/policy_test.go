@@ -0,0 +1,180 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"ytmusic/internal/logger"
+
+	"go.senan.xyz/taglib"
+)
+
+func TestTagPolicy_For(t *testing.T) {
+	p := TagPolicy{"title": PolicyKeep, "Genre": PolicyFill}
+
+	tests := []struct {
+		field string
+		want  WritePolicy
+	}{
+		{taglib.Title, PolicyKeep},
+		{taglib.Genre, PolicyFill},
+		{taglib.Artist, PolicyOverwrite},
+		{taglib.TrackNumber, PolicyFill},
+		{taglib.DiscNumber, PolicyFill},
+		{ArtworkField, PolicyOverwrite},
+	}
+	for _, tt := range tests {
+		if got := p.For(tt.field); got != tt.want {
+			t.Errorf("For(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	var empty TagPolicy
+	if got := empty.For(taglib.Title); got != PolicyOverwrite {
+		t.Errorf("nil policy For(TITLE) = %q, want overwrite", got)
+	}
+}
+
+func TestIsPolicyField(t *testing.T) {
+	full := TrackInfo{
+		Title: "t", Artist: "a", Artists: []ArtistCredit{{Name: "a"}}, Album: "al", AlbumArtist: "aa",
+		Sort:        SortNames{Artist: "a", AlbumArtist: "aa", Album: "al", Title: "t"},
+		TrackNumber: 1, TotalTracks: 2, DiscNumber: 1, TotalDiscs: 1, Compilation: true,
+		ReleaseDate: "2020", Genre: "Rock", ISRC: "US1234567890",
+		Release: ReleaseInfo{Label: "l", CatalogNumber: "c", Barcode: "b", Country: "US", Types: []string{"album"}, Media: "CD", OriginalDate: "2019"},
+		Credits: Credits{Composers: []string{"c"}, Lyricists: []string{"l"}, Arrangers: []string{"a"}, Performers: []string{"p"}},
+		MusicBrainz: MusicBrainzIDs{
+			RecordingID: "r", TrackID: "t", ReleaseID: "rl", ReleaseGroupID: "rg",
+			ArtistIDs: []string{"a"}, AlbumArtistIDs: []string{"aa"},
+		},
+	}
+	for tag := range tagMap(full) {
+		if !IsPolicyField(tag) {
+			t.Errorf("written tag %s is not a policy field", tag)
+		}
+	}
+	for _, field := range []string{"title", "AlbumArtist", "artwork", "musicbrainz_albumid"} {
+		if !IsPolicyField(field) {
+			t.Errorf("IsPolicyField(%q) = false", field)
+		}
+	}
+	if IsPolicyField("titel") {
+		t.Error("IsPolicyField(\"titel\") = true")
+	}
+}
+
+func TestTagPolicy_Filter(t *testing.T) {
+	p := TagPolicy{"title": PolicyKeep, "genre": PolicyFill, "tracknumber": PolicyOverwrite}
+	existing := map[string][]string{
+		taglib.Title:       {"Hand Corrected"},
+		taglib.Genre:       {"Shoegaze"},
+		taglib.Artist:      {"Old Artist"},
+		taglib.TrackNumber: {"5"},
+		taglib.DiscNumber:  {"1"},
+	}
+	tags := map[string][]string{
+		taglib.Title:       {"Provider Title"},
+		taglib.Genre:       {"Rock"},
+		taglib.Artist:      {"New Artist"},
+		taglib.Album:       {"New Album"},
+		taglib.TrackNumber: {"3"},
+		taglib.DiscNumber:  {"2"},
+	}
+
+	got := p.filter(tags, existing)
+
+	want := map[string]string{
+		taglib.Artist:      "New Artist", // default overwrite
+		taglib.Album:       "New Album",  // empty, written
+		taglib.TrackNumber: "3",          // overwrite configured
+	}
+	if len(got) != len(want) {
+		t.Errorf("filter wrote %v, want only %v", got, want)
+	}
+	for k, v := range want {
+		if firstTag(got, k) != v {
+			t.Errorf("%s = %q, want %q", k, firstTag(got, k), v)
+		}
+	}
+}
+
+func TestTagPolicy_DefaultPreservesPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string][]string
+		tags     map[string][]string
+		wantKeys []string
+	}{
+		{
+			name:     "slash track number kept",
+			existing: map[string][]string{taglib.TrackNumber: {"5/12"}},
+			tags:     map[string][]string{taglib.TrackNumber: {"3"}},
+		},
+		{
+			name:     "existing track number kept",
+			existing: map[string][]string{taglib.TrackNumber: {"5"}},
+			tags:     map[string][]string{taglib.TrackNumber: {"3"}},
+		},
+		{
+			name:     "missing track number filled",
+			existing: map[string][]string{taglib.Title: {"TRUST!"}},
+			tags:     map[string][]string{taglib.TrackNumber: {"5"}},
+			wantKeys: []string{taglib.TrackNumber},
+		},
+		{
+			name:     "existing disc number kept",
+			existing: map[string][]string{taglib.DiscNumber: {"1"}},
+			tags:     map[string][]string{taglib.DiscNumber: {"2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TagPolicy(nil).filter(tt.tags, tt.existing)
+			if len(got) != len(tt.wantKeys) {
+				t.Fatalf("filter wrote %v, want keys %v", got, tt.wantKeys)
+			}
+			for _, k := range tt.wantKeys {
+				if _, ok := got[k]; !ok {
+					t.Errorf("missing %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestIsLocked(t *testing.T) {
+	if IsLocked(map[string][]string{taglib.Title: {"x"}}) {
+		t.Error("file without marker reported locked")
+	}
+	if !IsLocked(map[string][]string{LockTag: {"1"}}) {
+		t.Error("file with marker not reported locked")
+	}
+	if IsLocked(map[string][]string{LockTag: {" "}}) {
+		t.Error("blank marker reported locked")
+	}
+}
+
+func TestResolve_SkipsLockedFiles(t *testing.T) {
+	path := newTestMP3(t)
+	if err := taglib.WriteTags(path, map[string][]string{
+		taglib.Title:  {"Hand Tagged"},
+		taglib.Artist: {"Someone"},
+		LockTag:       {"1"},
+	}, 0); err != nil {
+		t.Fatalf("write initial tags: %v", err)
+	}
+
+	mock := &mockProvider{name: "mock", results: []TrackInfo{{Title: "Hand Tagged", Artist: "Someone Else"}}}
+	r := NewResolver([]Provider{mock}, logger.New(false), 0.1)
+	if err := r.Resolve(context.Background(), []string{path}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if mock.called {
+		t.Error("provider consulted for a locked file")
+	}
+	tags, _ := taglib.ReadTags(path)
+	if got := firstTag(tags, taglib.Artist); got != "Someone" {
+		t.Errorf("Artist = %q, want untouched %q", got, "Someone")
+	}
+}
@@ -0,0 +1,269 @@
+package metadata
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.senan.xyz/taglib"
+)
+
+// Preview stages the tag and artwork writes of a dry run instead of applying
+// them. Reads through the resolver see the staged values, so later phases
+// behave as they would on a real run. Safe for concurrent use.
+type Preview struct {
+	mu    sync.Mutex
+	files map[string]*stagedFile
+	order []string
+}
+
+type stagedFile struct {
+	before     map[string][]string
+	after      map[string][]string
+	oldArtwork int    // size in bytes of the embedded picture, 0 if none
+	newArtwork []byte // nil unless artwork would be embedded
+}
+
+// FileChange is the difference between a file's current and previewed state.
+type FileChange struct {
+	Path    string
+	Tags    []TagChange    // sorted by field
+	Artwork *ArtworkChange // nil if the artwork stays as is
+}
+
+// TagChange is one tag whose value would change. Old is empty for added tags.
+type TagChange struct {
+	Field string
+	Old   []string
+	New   []string
+}
+
+// ArtworkChange describes embedded artwork that would be written.
+type ArtworkChange struct {
+	OldBytes int // 0 if the file has no artwork
+	NewBytes int
+}
+
+// NewPreview creates an empty Preview.
+func NewPreview() *Preview {
+	return &Preview{files: make(map[string]*stagedFile)}
+}
+
+// file returns the staged state of path, loading it from disk on first use.
+// Must be called with p.mu held.
+func (p *Preview) file(path string) (*stagedFile, error) {
+	if f, ok := p.files[path]; ok {
+		return f, nil
+	}
+	tags, err := taglib.ReadTags(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing tags: %w", err)
+	}
+	img, err := taglib.ReadImage(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artwork: %w", err)
+	}
+	f := &stagedFile{before: tags, after: copyTags(tags), oldArtwork: len(img)}
+	p.files[path] = f
+	p.order = append(p.order, path)
+	return f, nil
+}
+
+// tags returns the staged tags of path, or false if nothing is staged for it.
+func (p *Preview) tags(path string) (map[string][]string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok := p.files[path]
+	if !ok {
+		return nil, false
+	}
+	return copyTags(f.after), true
+}
+
+// hasArtwork reports whether path has or would get embedded artwork, or false
+// with ok unset if nothing is staged for it.
+func (p *Preview) hasArtwork(path string) (has, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, ok := p.files[path]
+	if !ok {
+		return false, false
+	}
+	return f.oldArtwork > 0 || f.newArtwork != nil, true
+}
+
+// artwork returns the artwork staged for path, or nil.
+func (p *Preview) artwork(path string) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if f, ok := p.files[path]; ok {
+		return f.newArtwork
+	}
+	return nil
+}
+
+func (p *Preview) stageTags(path string, tags map[string][]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := p.file(path)
+	if err != nil {
+		return err
+	}
+	for k, v := range tags {
+		f.after[k] = v
+	}
+	return nil
+}
+
+func (p *Preview) stageArtwork(path string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := p.file(path)
+	if err != nil {
+		return err
+	}
+	f.newArtwork = data
+	return nil
+}
+
+// Changes returns the files that would change, in the order they were first
+// staged.
+func (p *Preview) Changes() []FileChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []FileChange
+	for _, path := range p.order {
+		f := p.files[path]
+		c := FileChange{Path: path, Tags: diffTags(f.before, f.after)}
+		if f.newArtwork != nil {
+			c.Artwork = &ArtworkChange{OldBytes: f.oldArtwork, NewBytes: len(f.newArtwork)}
+		}
+		if len(c.Tags) > 0 || c.Artwork != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// WriteDiff prints the changes as a per-file old → new listing.
+func (p *Preview) WriteDiff(w io.Writer) {
+	changes := p.Changes()
+	for _, c := range changes {
+		fmt.Fprintln(w, c.Path)
+		for _, t := range c.Tags {
+			fmt.Fprintf(w, "  %-28s %s → %s\n", t.Field, formatValues(t.Old), formatValues(t.New))
+		}
+		if a := c.Artwork; a != nil {
+			fmt.Fprintf(w, "  %-28s %s → %s\n", ArtworkField, formatSize(a.OldBytes), formatSize(a.NewBytes))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d files would change\n", len(changes))
+}
+
+func diffTags(before, after map[string][]string) []TagChange {
+	var out []TagChange
+	for k, v := range after {
+		if !equalValues(before[k], v) {
+			out = append(out, TagChange{Field: k, Old: before[k], New: v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func copyTags(tags map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(tags))
+	for k, v := range tags {
+		out[k] = v
+	}
+	return out
+}
+
+func formatValues(v []string) string {
+	if len(v) == 0 {
+		return "(none)"
+	}
+	quoted := make([]string, len(v))
+	for i, s := range v {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func formatSize(n int) string {
+	if n == 0 {
+		return "(none)"
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+// readTags reads path's tags, including any writes staged by a preview.
+func (r *Resolver) readTags(path string) (map[string][]string, error) {
+	if r.preview != nil {
+		if tags, ok := r.preview.tags(path); ok {
+			return tags, nil
+		}
+	}
+	return taglib.ReadTags(path)
+}
+
+// writeTags writes tags to path, or stages them when previewing.
+func (r *Resolver) writeTags(path string, tags map[string][]string) error {
+	if r.preview != nil {
+		return r.preview.stageTags(path, tags)
+	}
+	return taglib.WriteTags(path, tags, 0)
+}
+
+// writeArtwork embeds data into path, or stages it when previewing.
+func (r *Resolver) writeArtwork(path string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if r.preview != nil {
+		return r.preview.stageArtwork(path, data)
+	}
+	return WriteArtwork(path, data)
+}
+
+// readImage returns path's embedded artwork, including artwork staged by a
+// preview, or nil if there is none or it can't be read.
+func (r *Resolver) readImage(path string) []byte {
+	if r.preview != nil {
+		if img := r.preview.artwork(path); img != nil {
+			return img
+		}
+	}
+	img, err := taglib.ReadImage(path)
+	if err != nil || len(img) == 0 {
+		return nil
+	}
+	return img
+}
+
+// hasArtwork reports whether path has embedded artwork, including artwork
+// staged by a preview.
+func (r *Resolver) hasArtwork(path string) bool {
+	if r.preview != nil {
+		if has, ok := r.preview.hasArtwork(path); ok {
+			return has
+		}
+	}
+	img, err := taglib.ReadImage(path)
+	return err == nil && len(img) > 0
+}
@@ -0,0 +1,127 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"ytmusic/internal/logger"
+
+	"go.senan.xyz/taglib"
+)
+
+// stagedPreview returns a preview with one file staged without touching disk.
+func stagedPreview(path string, before map[string][]string, oldArtwork int) *Preview {
+	p := NewPreview()
+	p.files[path] = &stagedFile{before: before, after: copyTags(before), oldArtwork: oldArtwork}
+	p.order = append(p.order, path)
+	return p
+}
+
+func TestPreview_Changes(t *testing.T) {
+	p := stagedPreview("/music/a.mp3", map[string][]string{
+		taglib.Title:       {"Song (Official Video)"},
+		taglib.Artist:      {"Band"},
+		taglib.TrackNumber: {"3"},
+	}, 0)
+	p.stageTags("/music/a.mp3", map[string][]string{
+		taglib.Title:  {"Song"},
+		taglib.Artist: {"Band"}, // unchanged, not listed
+		taglib.Genre:  {"Rock"},
+	})
+	p.stageArtwork("/music/a.mp3", make([]byte, 2048))
+
+	// Staged but unchanged files are left out.
+	p.files["/music/b.mp3"] = &stagedFile{before: map[string][]string{}, after: map[string][]string{}}
+	p.order = append(p.order, "/music/b.mp3")
+
+	changes := p.Changes()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 changed file, got %d", len(changes))
+	}
+	c := changes[0]
+	if len(c.Tags) != 2 || c.Tags[0].Field != taglib.Genre || c.Tags[1].Field != taglib.Title {
+		t.Fatalf("tag changes = %+v, want GENRE and TITLE", c.Tags)
+	}
+	if len(c.Tags[0].Old) != 0 || c.Tags[1].Old[0] != "Song (Official Video)" {
+		t.Errorf("old values not recorded: %+v", c.Tags)
+	}
+	if c.Artwork == nil || c.Artwork.OldBytes != 0 || c.Artwork.NewBytes != 2048 {
+		t.Errorf("artwork change = %+v, want none → 2048 bytes", c.Artwork)
+	}
+}
+
+func TestPreview_WriteDiff(t *testing.T) {
+	p := stagedPreview("/music/a.mp3", map[string][]string{taglib.Title: {"Old"}}, 1024)
+	p.stageTags("/music/a.mp3", map[string][]string{taglib.Title: {"New"}})
+	p.stageArtwork("/music/a.mp3", make([]byte, 2048))
+
+	var buf bytes.Buffer
+	p.WriteDiff(&buf)
+	out := buf.String()
+
+	for _, want := range []string{"/music/a.mp3", `"Old" → "New"`, "1.0 KB → 2.0 KB", "1 files would change"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestResolver_ReadsStagedTags(t *testing.T) {
+	p := stagedPreview("/music/a.mp3", map[string][]string{taglib.Title: {"Song"}}, 0)
+	r := NewResolver(nil, logger.New(false), 0.7).WithPreview(p)
+
+	if err := r.writeTags("/music/a.mp3", positionalTags(4, 1)); err != nil {
+		t.Fatalf("writeTags: %v", err)
+	}
+	tags, err := r.readTags("/music/a.mp3")
+	if err != nil {
+		t.Fatalf("readTags: %v", err)
+	}
+	if firstTag(tags, taglib.TrackNumber) != "4" || firstTag(tags, taglib.Title) != "Song" {
+		t.Errorf("staged read = %v, want title and new track number", tags)
+	}
+
+	// With the default fill policy, the staged position is now "existing".
+	if got := r.policy.filter(positionalTags(7, 0), tags); len(got) != 0 {
+		t.Errorf("staged track number overwritten: %v", got)
+	}
+}
+
+func TestResolve_PreviewLeavesFileUntouched(t *testing.T) {
+	path := newTestMP3(t)
+	if err := taglib.WriteTags(path, map[string][]string{
+		taglib.Title:  {"TRUST! (Official Video)"},
+		taglib.Artist: {"Tyler"},
+	}, 0); err != nil {
+		t.Fatalf("write initial tags: %v", err)
+	}
+
+	mock := &mockProvider{name: "mock", results: []TrackInfo{
+		{Title: "TRUST!", Artist: "Tyler", Album: "Chromakopia", Genre: "Hip-Hop"},
+	}}
+	p := NewPreview()
+	r := NewResolver([]Provider{mock}, logger.New(false), 0.5).WithPreview(p)
+	if err := r.Resolve(context.Background(), []string{path}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	tags, _ := taglib.ReadTags(path)
+	if got := firstTag(tags, taglib.Title); got != "TRUST! (Official Video)" {
+		t.Errorf("Title on disk = %q, dry run must not write", got)
+	}
+	changes := p.Changes()
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 changed file, got %d", len(changes))
+	}
+	var sawAlbum bool
+	for _, c := range changes[0].Tags {
+		if c.Field == taglib.Album && c.New[0] == "Chromakopia" {
+			sawAlbum = true
+		}
+	}
+	if !sawAlbum {
+		t.Errorf("album change missing from %+v", changes[0].Tags)
+	}
+}
@@ -0,0 +1,252 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.senan.xyz/taglib"
+)
+
+// Positional-tag phases recorded in PositionalResult.Phase.
+const (
+	PhaseBatchFingerprint = "batch_fingerprint"
+	PhaseAlbumFirst       = "album_first"
+)
+
+// Outcomes recorded in FileReport.Outcome.
+const (
+	OutcomeTagged         = "tagged"
+	OutcomeBelowThreshold = "below_threshold"
+	OutcomeNoTitle        = "no_title"
+	OutcomeFailed         = "failed"
+)
+
+// FileReport records why a file got the tags it has.
+type FileReport struct {
+	Path         string              `json:"path"`
+	OriginalTags map[string][]string `json:"original_tags,omitempty"`
+	Query        *SearchQuery        `json:"query,omitempty"`
+	Fingerprint  *FingerprintResult  `json:"fingerprint,omitempty"`
+	Positional   *PositionalResult   `json:"positional,omitempty"`
+	ISRC         string              `json:"isrc_lookup,omitempty"` // ISRC the match was looked up by
+	Candidates   []Candidate         `json:"candidates,omitempty"`  // every scored provider result
+	Chosen       *Candidate          `json:"chosen,omitempty"`
+	Reviewed     bool                `json:"reviewed,omitempty"`      // Chosen was picked interactively
+	GapFill      map[string]string   `json:"gap_fill,omitempty"`      // field → provider that filled it
+	Written      map[string][]string `json:"written,omitempty"`       // tags written from the match, after tag_policy
+	Reconciled   map[string]string   `json:"reconciled,omitempty"`    // field → value set by the album consistency pass
+	MixedRelease string              `json:"mixed_release,omitempty"` // why the album group looked like several releases
+	Outcome      string              `json:"outcome,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// FingerprintResult is the outcome of an AcoustID lookup for a file.
+type FingerprintResult struct {
+	Batch       bool    `json:"batch"` // from the batch phase rather than the per-file lookup
+	Matched     bool    `json:"matched"`
+	RecordingID string  `json:"recording_id,omitempty"`
+	Score       float64 `json:"score,omitempty"` // AcoustID score of the match
+	Title       string  `json:"title,omitempty"`
+	Artist      string  `json:"artist,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// PositionalResult records track/disc numbers assigned from a release tracklist.
+type PositionalResult struct {
+	Phase       string  `json:"phase"`
+	Release     string  `json:"release"`
+	ReleaseID   string  `json:"release_id,omitempty"`
+	TrackNumber int     `json:"track_number"`
+	DiscNumber  int     `json:"disc_number"`
+	Score       float64 `json:"score"`               // title similarity to the tracklist entry
+	Agreement   float64 `json:"agreement,omitempty"` // share of the album sources listing the track that agree on its position
+	Sources     int     `json:"sources,omitempty"`   // album sources listing the track
+}
+
+// Report collects a FileReport per resolved file. Safe for concurrent use.
+type Report struct {
+	mu    sync.Mutex
+	files map[string]*FileReport
+	order []string
+}
+
+// NewReport creates an empty Report.
+func NewReport() *Report {
+	return &Report{files: make(map[string]*FileReport)}
+}
+
+// File returns the report for path, creating it on first use.
+func (rp *Report) File(path string) *FileReport {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if f, ok := rp.files[path]; ok {
+		return f
+	}
+	f := &FileReport{Path: path}
+	rp.files[path] = f
+	rp.order = append(rp.order, path)
+	return f
+}
+
+// Files returns the file reports in the order files were first seen.
+func (rp *Report) Files() []*FileReport {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	out := make([]*FileReport, 0, len(rp.order))
+	for _, p := range rp.order {
+		out = append(out, rp.files[p])
+	}
+	return out
+}
+
+// WriteJSON writes the report to path as an indented JSON array, creating
+// its directory if needed.
+func (rp *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(rp.Files(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal match report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create match report directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write match report: %w", err)
+	}
+	return nil
+}
+
+// ReadReport loads a report written by WriteJSON.
+func ReadReport(path string) ([]FileReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read match report: %w", err)
+	}
+	var files []FileReport
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse match report %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// fileReport returns the report entry for path, or a throwaway entry when
+// reporting is disabled so callers can record unconditionally.
+func (r *Resolver) fileReport(path string) *FileReport {
+	if r.report == nil {
+		return &FileReport{Path: path}
+	}
+	return r.report.File(path)
+}
+
+// fingerprintResult summarizes a per-file fingerprint lookup for the report.
+func fingerprintResult(info TrackInfo, found bool, err error) *FingerprintResult {
+	fr := &FingerprintResult{Matched: err == nil && found}
+	if err != nil {
+		fr.Error = err.Error()
+	}
+	if fr.Matched {
+		fr.RecordingID = info.MusicBrainz.RecordingID
+		fr.Score = info.Confidence
+		fr.Title = info.Title
+		fr.Artist = info.Artist
+	}
+	return fr
+}
+
+// filledFields returns the report names of the fields mergeTrackInfo filled.
+func filledFields(before, after TrackInfo) []string {
+	var fields []string
+	if before.Genre != after.Genre {
+		fields = append(fields, "genre")
+	}
+	if before.TrackNumber != after.TrackNumber {
+		fields = append(fields, "track_number")
+	}
+	if before.TotalTracks != after.TotalTracks {
+		fields = append(fields, "total_tracks")
+	}
+	if before.TotalDiscs != after.TotalDiscs {
+		fields = append(fields, "total_discs")
+	}
+	if before.DiscNumber != after.DiscNumber {
+		fields = append(fields, "disc_number")
+	}
+	if before.Year != after.Year {
+		fields = append(fields, "year")
+	}
+	if before.ReleaseDate != after.ReleaseDate {
+		fields = append(fields, "release_date")
+	}
+	if before.ISRC != after.ISRC {
+		fields = append(fields, "isrc")
+	}
+	if before.ArtworkURL != after.ArtworkURL {
+		fields = append(fields, "artwork_url")
+	}
+	b, a := before.Release, after.Release
+	for _, f := range []struct {
+		name          string
+		before, after string
+	}{
+		{"label", b.Label, a.Label},
+		{"catalog_number", b.CatalogNumber, a.CatalogNumber},
+		{"barcode", b.Barcode, a.Barcode},
+		{"release_country", b.Country, a.Country},
+		{"release_type", strings.Join(b.Types, ";"), strings.Join(a.Types, ";")},
+		{"media", b.Media, a.Media},
+		{"original_date", b.OriginalDate, a.OriginalDate},
+	} {
+		if f.before != f.after {
+			fields = append(fields, f.name)
+		}
+	}
+	if before.MusicBrainz.IsZero() && !after.MusicBrainz.IsZero() {
+		fields = append(fields, "musicbrainz")
+	}
+	return fields
+}
+
+// filledFieldTags maps the fields filledFields reports to the tags they are
+// written as.
+var filledFieldTags = map[string][]string{
+	"genre":           {taglib.Genre},
+	"track_number":    {taglib.TrackNumber},
+	"total_tracks":    {TotalTracksTag},
+	"total_discs":     {TotalDiscsTag},
+	"disc_number":     {taglib.DiscNumber},
+	"year":            {taglib.Date},
+	"release_date":    {taglib.Date},
+	"isrc":            {taglib.ISRC},
+	"artwork_url":     {ArtworkField},
+	"label":           {taglib.Label},
+	"catalog_number":  {taglib.CatalogNumber},
+	"barcode":         {taglib.Barcode},
+	"release_country": {taglib.ReleaseCountry},
+	"release_type":    {taglib.ReleaseType},
+	"media":           {taglib.Media},
+	"original_date":   {taglib.OriginalDate},
+	"musicbrainz": {
+		taglib.MusicBrainzTrackID, taglib.MusicBrainzReleaseTrackID, taglib.MusicBrainzAlbumID,
+		taglib.MusicBrainzReleaseGroupID, taglib.MusicBrainzArtistID, taglib.MusicBrainzAlbumArtistID,
+	},
+}
+
+// dropUnwritten removes the gap-filled fields none of whose tags are in
+// written, so fields tag_policy held back are not credited to a provider.
+func (f *FileReport) dropUnwritten(written map[string][]string) {
+	for field := range f.GapFill {
+		kept := false
+		for _, tag := range filledFieldTags[field] {
+			if _, ok := written[tag]; ok {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			delete(f.GapFill, field)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package metadata
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ytmusic/internal/logger"
+
+	"go.senan.xyz/taglib"
+)
+
+func TestReport_FilesKeepFirstSeenOrder(t *testing.T) {
+	rp := NewReport()
+	rp.File("b.mp3").Outcome = OutcomeTagged
+	rp.File("a.mp3").Outcome = OutcomeBelowThreshold
+	rp.File("b.mp3").Reviewed = true
+
+	files := rp.Files()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 file reports, got %d", len(files))
+	}
+	if files[0].Path != "b.mp3" || files[1].Path != "a.mp3" {
+		t.Errorf("order = %q, %q; want b.mp3, a.mp3", files[0].Path, files[1].Path)
+	}
+	if !files[0].Reviewed || files[0].Outcome != OutcomeTagged {
+		t.Errorf("entries for the same path not shared: %+v", files[0])
+	}
+}
+
+func TestReport_WriteAndRead(t *testing.T) {
+	rp := NewReport()
+	f := rp.File("/music/song.mp3")
+	f.OriginalTags = map[string][]string{"TITLE": {"Song (Official Video)"}}
+	f.Query = &SearchQuery{Title: "Song", Artist: "Band"}
+	f.Candidates = []Candidate{{Provider: "deezer", Info: TrackInfo{Title: "Song", Artist: "Band", Confidence: 0.95}}}
+	f.Chosen = &f.Candidates[0]
+	f.GapFill = map[string]string{"genre": "spotify"}
+	f.Positional = &PositionalResult{Phase: PhaseAlbumFirst, Release: "Album", TrackNumber: 2, DiscNumber: 1, Score: 1}
+	f.Outcome = OutcomeTagged
+
+	path := filepath.Join(t.TempDir(), "logs", "run.report.json")
+	if err := rp.WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	files, err := ReadReport(path)
+	if err != nil {
+		t.Fatalf("ReadReport: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file report, got %d", len(files))
+	}
+	got := files[0]
+	if got.Query.Title != "Song" || got.Chosen.Provider != "deezer" || got.Chosen.Info.Confidence != 0.95 {
+		t.Errorf("round trip lost match data: %+v", got)
+	}
+	if got.GapFill["genre"] != "spotify" || got.Positional.Phase != PhaseAlbumFirst {
+		t.Errorf("round trip lost gap-fill or positional data: %+v", got)
+	}
+}
+
+func TestFillGaps_RecordsSources(t *testing.T) {
+	p1 := &mockProvider{name: "primary"}
+	p2 := &mockProvider{name: "genres", results: []TrackInfo{
+		{Title: "My Song", Artist: "My Artist", Genre: "Rock"},
+	}}
+	p3 := &mockProvider{name: "codes", results: []TrackInfo{
+		{Title: "My Song", Artist: "My Artist", Genre: "Pop", ISRC: "US1234567890"},
+	}}
+	r := NewResolver([]Provider{p1, p2, p3}, logger.New(false), 0.5)
+
+	query := SearchQuery{Title: "My Song", Artist: "My Artist"}
+	rep := &FileReport{}
+	r.fillGaps(context.Background(), query, TrackInfo{Title: "My Song", Artist: "My Artist"}, 0, rep)
+
+	if rep.GapFill["genre"] != "genres" {
+		t.Errorf("genre source = %q, want genres", rep.GapFill["genre"])
+	}
+	if rep.GapFill["isrc"] != "codes" {
+		t.Errorf("isrc source = %q, want codes", rep.GapFill["isrc"])
+	}
+	if len(rep.Candidates) != 2 {
+		t.Errorf("expected 2 gap-fill candidates recorded, got %d", len(rep.Candidates))
+	}
+}
+
+func TestFileReport_DropUnwritten(t *testing.T) {
+	rep := &FileReport{GapFill: map[string]string{"genre": "genres", "isrc": "codes", "musicbrainz": "musicbrainz"}}
+	rep.dropUnwritten(map[string][]string{
+		taglib.ISRC:               {"US1234567890"},
+		taglib.MusicBrainzAlbumID: {"rel-1"},
+	})
+
+	want := map[string]string{"isrc": "codes", "musicbrainz": "musicbrainz"}
+	if !reflect.DeepEqual(rep.GapFill, want) {
+		t.Errorf("GapFill = %v, want %v (genre was held back by tag_policy)", rep.GapFill, want)
+	}
+}
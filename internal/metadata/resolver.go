@@ -2,13 +2,14 @@ package metadata
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"ytmusic/internal/logger"
 
@@ -17,6 +18,10 @@ import (
 
 const defaultConfidenceThreshold = 0.7
 
+// versionMismatchPenalty is applied when a candidate is a different variant
+// than the query (e.g. live vs studio), pushing exact-title matches below threshold.
+const versionMismatchPenalty = 0.6
+
 // Resolver orchestrates metadata resolution: reads existing tags, normalizes,
 // searches providers, scores results, and writes back the best metadata.
 // When multiple providers are configured, the Resolver tries them in order for
@@ -27,9 +32,21 @@ type Resolver struct {
 	logger             *logger.Logger
 	threshold          float64
 	fingerprinter      Fingerprinter      // nil if not configured
-	albumResolver      AlbumResolver      // nil if not configured
+	albumResolver      AlbumResolver      // nil if not configured; providers may resolve albums too
+	albumAgreement     float64            // share of album sources that must agree on a position
 	batchFingerprinter BatchFingerprinter // nil if not configured
 	releaseResolver    ReleaseResolver    // nil if not configured
+	creditsResolver    CreditsResolver    // nil unless credits were requested
+	featConvention     FeatConvention
+	fold               folding
+	genres             *GenreNormalizer // nil leaves provider genres as they are
+	reviewer           Reviewer         // nil unless running interactively
+	albumDecisions     map[string]albumDecision
+	report             *Report // nil unless a match report was requested
+	policy             TagPolicy
+	preview            *Preview              // nil unless this is a dry run
+	tagged             map[string]bool       // files written from a match
+	tracklists         map[string]*Tracklist // tracklist that positioned each file
 	httpClient         *http.Client
 }
 
@@ -40,10 +57,14 @@ func NewResolver(providers []Provider, log *logger.Logger, threshold float64) *R
 		threshold = defaultConfidenceThreshold
 	}
 	return &Resolver{
-		providers:  providers,
-		logger:     log,
-		threshold:  threshold,
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		providers:      providers,
+		logger:         log,
+		threshold:      threshold,
+		albumAgreement: defaultAlbumAgreement,
+		fold:           defaultFolding,
+		tagged:         make(map[string]bool),
+		tracklists:     make(map[string]*Tracklist),
+		httpClient:     &http.Client{Timeout: 15 * time.Second},
 	}
 }
 
@@ -55,12 +76,36 @@ func (r *Resolver) WithFingerprinter(f Fingerprinter) *Resolver {
 }
 
 // WithAlbumResolver attaches an album resolver for the album-first positional-tag phase.
+// Providers that implement AlbumResolver are consulted as well, ahead of it.
 // Returns the same Resolver to allow chaining.
 func (r *Resolver) WithAlbumResolver(ar AlbumResolver) *Resolver {
 	r.albumResolver = ar
 	return r
 }
 
+// WithAlbumAgreement sets the share (0.0-1.0) of the album sources listing a
+// track that must give it the same position before the album-first phase
+// writes it. Defaults to 0.6; 0 writes the majority position regardless.
+func (r *Resolver) WithAlbumAgreement(level float64) *Resolver {
+	r.albumAgreement = level
+	return r
+}
+
+// albumResolvers returns the providers that resolve albums, in provider
+// order, followed by the attached album resolver unless it is one of them.
+func (r *Resolver) albumResolvers() []AlbumResolver {
+	var out []AlbumResolver
+	for _, p := range r.providers {
+		if ar, ok := p.(AlbumResolver); ok {
+			out = append(out, ar)
+		}
+	}
+	if r.albumResolver != nil && !slices.Contains(out, r.albumResolver) {
+		out = append(out, r.albumResolver)
+	}
+	return out
+}
+
 // WithBatchFingerprinter attaches a batch fingerprinter for the batch-fingerprint phase.
 func (r *Resolver) WithBatchFingerprinter(bf BatchFingerprinter) *Resolver {
 	r.batchFingerprinter = bf
@@ -73,12 +118,85 @@ func (r *Resolver) WithReleaseResolver(rr ReleaseResolver) *Resolver {
 	return r
 }
 
+// WithCreditsResolver looks up composer and performer credits for matches
+// that carry a MusicBrainz recording ID but no credits.
+func (r *Resolver) WithCreditsResolver(cr CreditsResolver) *Resolver {
+	r.creditsResolver = cr
+	return r
+}
+
+// WithFeatConvention selects whether featured artists go in the artist field
+// or the title. Defaults to FeatInArtist.
+func (r *Resolver) WithFeatConvention(c FeatConvention) *Resolver {
+	r.featConvention = c
+	return r
+}
+
+// WithTransliteration sets whether kana, hangul and Cyrillic are romanized
+// before titles and artists are compared and in generated sort names.
+// Enabled by default.
+func (r *Resolver) WithTransliteration(enabled bool) *Resolver {
+	r.fold.transliterate = enabled
+	return r
+}
+
+// WithGenreNormalizer maps provider genres onto canonical genres before they
+// are written.
+func (r *Resolver) WithGenreNormalizer(n *GenreNormalizer) *Resolver {
+	r.genres = n
+	return r
+}
+
+// WithReviewer attaches a reviewer that is asked to choose a match for files
+// whose best candidate falls below the confidence threshold.
+func (r *Resolver) WithReviewer(rv Reviewer) *Resolver {
+	r.reviewer = rv
+	r.albumDecisions = make(map[string]albumDecision)
+	return r
+}
+
+// WithReport records a FileReport for every file into rp.
+func (r *Resolver) WithReport(rp *Report) *Resolver {
+	r.report = rp
+	return r
+}
+
+// WithTagPolicy sets which fields may be overwritten, only filled, or never
+// touched. Unlisted fields use the default policy (see TagPolicy).
+func (r *Resolver) WithTagPolicy(p TagPolicy) *Resolver {
+	r.policy = p
+	return r
+}
+
+// WithPreview makes the resolver stage every tag and artwork write in p
+// instead of modifying files.
+// Returns the same Resolver to allow chaining.
+func (r *Resolver) WithPreview(p *Preview) *Resolver {
+	r.preview = p
+	return r
+}
+
 // Resolve processes a list of audio file paths: for each file, it reads existing
 // metadata, normalizes it, searches the provider, scores the best match, and
 // writes improved metadata back if confident enough.
 func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 	r.logger.Info("resolving metadata for %d files", len(files))
 
+	files = r.skipLocked(files)
+	if len(files) == 0 {
+		r.logger.Info("All files are locked, nothing to resolve")
+		return nil
+	}
+
+	if r.report != nil {
+		// Capture tags before phases A and B rewrite positions.
+		for _, path := range files {
+			if tags, err := taglib.ReadTags(path); err == nil {
+				r.report.File(path).OriginalTags = tags
+			}
+		}
+	}
+
 	groups := groupByAlbum(files)
 	resolvedByA := make(map[string]bool)
 
@@ -94,8 +212,9 @@ func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 		}
 	}
 
-	// Phase B: album-first text search (skips files already resolved by Phase A)
-	if r.albumResolver != nil {
+	// Phase B: album-first text search (skips files already resolved by Phase A).
+	// Every album resolver is asked and positions come from their consensus.
+	if resolvers := r.albumResolvers(); len(resolvers) > 0 {
 		for album, group := range groups {
 			if album == "" {
 				continue
@@ -104,7 +223,7 @@ func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 			if len(unresolved) == 0 {
 				continue
 			}
-			if err := r.resolveGroup(ctx, album, unresolved, r.albumResolver); err != nil {
+			if _, err := r.resolveGroup(ctx, album, unresolved, len(group), resolvers); err != nil {
 				r.logger.Warn("album-first phase failed for %q: %v", album, err)
 			}
 		}
@@ -122,6 +241,9 @@ func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 
 		if err := r.resolveFile(ctx, path); err != nil {
 			r.logger.Warn("[%d/%d] Failed to resolve metadata: %v", i+1, len(files), err)
+			rep := r.fileReport(path)
+			rep.Outcome = OutcomeFailed
+			rep.Error = err.Error()
 			failed++
 		}
 	}
@@ -130,6 +252,15 @@ func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 		return fmt.Errorf("all %d files failed metadata resolution", len(files))
 	}
 
+	// Album consistency: reconcile fields that per-file resolution left
+	// disagreeing within an album group.
+	for album, group := range groups {
+		if album == "" || len(group) < 2 {
+			continue
+		}
+		r.reconcileGroup(album, group)
+	}
+
 	if failed > 0 {
 		r.logger.Warn("%d of %d files failed metadata resolution", failed, len(files))
 	}
@@ -139,7 +270,9 @@ func (r *Resolver) Resolve(ctx context.Context, files []string) error {
 }
 
 func (r *Resolver) resolveFile(ctx context.Context, path string) error {
-	existingTags, err := taglib.ReadTags(path)
+	rep := r.fileReport(path)
+
+	existingTags, err := r.readTags(path)
 	if err != nil {
 		return fmt.Errorf("failed to read existing tags: %w", err)
 	}
@@ -150,65 +283,157 @@ func (r *Resolver) resolveFile(ctx context.Context, path string) error {
 
 	if rawTitle == "" {
 		r.logger.Debug("  Skipping: no title metadata")
+		rep.Outcome = OutcomeNoTitle
 		return nil
 	}
 
 	query := NormalizeQuery(rawTitle, rawArtist)
 	query.Album = strings.TrimSpace(rawAlbum)
 	r.logger.Debug("  Normalized: title=%q artist=%q album=%q", query.Title, query.Artist, query.Album)
+	rep.Query = &query
 
 	if query.Title == "" {
+		rep.Outcome = OutcomeNoTitle
 		return nil
 	}
 
 	// Try acoustic fingerprinting first for a definitive identification.
 	if r.fingerprinter != nil {
-		if info, found, err := r.fingerprinter.LookupByFile(ctx, path, query.Album); err == nil && found {
+		info, found, err := r.fingerprinter.LookupByFile(ctx, path, query)
+		rep.Fingerprint = fingerprintResult(info, found, err)
+		if err == nil && found {
 			r.logger.Debug("  Fingerprint match: %q by %q", info.Title, info.Artist)
-			info = r.fillGaps(ctx, query, info, -1)
-			info = mergeWithExisting(path, info)
-			if err := WriteTags(path, info); err != nil {
-				return fmt.Errorf("failed to write tags: %w", err)
-			}
-			if info.ArtworkURL != "" {
-				if err := r.downloadAndEmbedArtwork(ctx, path, info.ArtworkURL); err != nil {
-					r.logger.Warn("  Failed to embed artwork: %v", err)
-				}
-			}
-			ensureAlbumArtist(path)
-			return nil
+			rep.Chosen = &Candidate{Provider: "fingerprint", Info: info}
+			info = r.fillGaps(ctx, query, info, -1, rep)
+			info = applyFeatConvention(info, r.featConvention, query.Featured)
+			return r.writeMatch(ctx, path, info, rep)
+		}
+	}
+
+	// A known ISRC identifies the recording exactly; skip the fuzzy search.
+	if isrc := firstTag(existingTags, taglib.ISRC); isrc != "" {
+		info, idx, candidates, found := r.findByISRC(ctx, query, isrc)
+		rep.Candidates = append(rep.Candidates, candidates...)
+		if found {
+			r.logger.Debug("  ISRC match: %q by %q from %s", info.Title, info.Artist, r.providers[idx].Name())
+			rep.ISRC = isrc
+			rep.Chosen = &Candidate{Provider: r.providers[idx].Name(), Info: info}
+			info = r.fillAlbumDetails(ctx, info, idx)
+			info = r.fillGaps(ctx, query, info, idx, rep)
+			info = applyFeatConvention(info, r.featConvention, query.Featured)
+			return r.writeMatch(ctx, path, info, rep)
 		}
 	}
 
-	best, matchIdx := r.findPrimaryMatch(ctx, query)
+	best, matchIdx, candidates := r.findPrimaryMatch(ctx, query)
+	rep.Candidates = append(rep.Candidates, candidates...)
 
 	if best.Confidence < r.threshold {
-		r.logger.Debug("  Confidence %.2f below threshold %.2f, keeping original tags", best.Confidence, r.threshold)
-		ensureAlbumArtist(path)
-		return nil
+		picked, reviewed, ok := r.review(ctx, path, query, candidates)
+		if !ok {
+			r.logger.Debug("  Confidence %.2f below threshold %.2f, keeping original tags", best.Confidence, r.threshold)
+			rep.Outcome = OutcomeBelowThreshold
+			r.ensureAlbumArtist(path)
+			return nil
+		}
+		r.logger.Debug("  Reviewed: %q by %q from %s", picked.Info.Title, picked.Info.Artist, picked.Provider)
+		best, matchIdx, query = picked.Info, r.providerIndex(picked.Provider), reviewed
+		rep.Reviewed = true
 	}
+	if matchIdx >= 0 && matchIdx < len(r.providers) {
+		rep.Chosen = &Candidate{Provider: r.providers[matchIdx].Name(), Info: best}
+	}
+
+	best = r.fillAlbumDetails(ctx, best, matchIdx)
+	best = r.fillGaps(ctx, query, best, matchIdx, rep)
+	best = applyFeatConvention(best, r.featConvention, query.Featured)
+	return r.writeMatch(ctx, path, best, rep)
+}
 
-	best = r.fillGaps(ctx, query, best, matchIdx)
-	best = mergeWithExisting(path, best)
+// writeMatch writes info to path as allowed by the tag policy, embeds its
+// artwork and fills in a missing album artist.
+func (r *Resolver) writeMatch(ctx context.Context, path string, info TrackInfo, rep *FileReport) error {
+	info = r.fillCredits(ctx, info)
+	info = r.fold.fillSortNames(info)
+	info = r.normalizeGenres(info)
 
-	if err := WriteTags(path, best); err != nil {
-		return fmt.Errorf("failed to write tags: %w", err)
+	existing, err := r.readTags(path)
+	if err != nil {
+		return fmt.Errorf("failed to read existing tags: %w", err)
+	}
+	tags := r.policy.filter(tagMap(info), existing)
+	if len(tags) > 0 {
+		if err := r.writeTags(path, tags); err != nil {
+			return fmt.Errorf("failed to write tags: %w", err)
+		}
 	}
+	rep.Outcome = OutcomeTagged
+	r.tagged[path] = true
 
-	if best.ArtworkURL != "" {
-		if err := r.downloadAndEmbedArtwork(ctx, path, best.ArtworkURL); err != nil {
+	if info.ArtworkURL != "" && r.mayWriteArtwork(path) {
+		if err := r.downloadAndEmbedArtwork(ctx, path, info.ArtworkURL); err != nil {
 			r.logger.Warn("  Failed to embed artwork: %v", err)
+		} else {
+			tags[ArtworkField] = []string{info.ArtworkURL}
 		}
 	}
+	rep.Written = tags
+	rep.dropUnwritten(tags)
 
-	ensureAlbumArtist(path)
+	r.ensureAlbumArtist(path)
 	return nil
 }
 
+// fillCredits looks up the credits of a MusicBrainz match that has none.
+func (r *Resolver) fillCredits(ctx context.Context, info TrackInfo) TrackInfo {
+	if r.creditsResolver == nil || info.Credits.Fetched || !info.Credits.IsZero() || info.MusicBrainz.RecordingID == "" {
+		return info
+	}
+	credits, err := r.creditsResolver.Credits(ctx, info.MusicBrainz.RecordingID)
+	if err != nil {
+		r.logger.Debug("  Credits lookup failed: %v", err)
+		return info
+	}
+	info.Credits = credits
+	return info
+}
+
+// fillAlbumDetails asks the provider of a match for the album details its
+// search results leave out. idx is the provider's index, -1 for none.
+func (r *Resolver) fillAlbumDetails(ctx context.Context, info TrackInfo, idx int) TrackInfo {
+	if idx < 0 || idx >= len(r.providers) {
+		return info
+	}
+	ad, ok := r.providers[idx].(AlbumDetailsResolver)
+	if !ok {
+		return info
+	}
+	filled, err := ad.AlbumDetails(ctx, info)
+	if err != nil {
+		r.logger.Debug("  Album details lookup failed: %v", err)
+		return info
+	}
+	return filled
+}
+
+// mayWriteArtwork applies the artwork policy: fill only embeds into files
+// without a picture.
+func (r *Resolver) mayWriteArtwork(path string) bool {
+	switch r.policy.For(ArtworkField) {
+	case PolicyKeep:
+		return false
+	case PolicyFill:
+		return !r.hasArtwork(path)
+	}
+	return true
+}
+
 // findPrimaryMatch tries providers in order until one returns a match above threshold.
-func (r *Resolver) findPrimaryMatch(ctx context.Context, query SearchQuery) (TrackInfo, int) {
+// It also returns every scored result seen, for review of low-confidence matches.
+func (r *Resolver) findPrimaryMatch(ctx context.Context, query SearchQuery) (TrackInfo, int, []Candidate) {
 	var best TrackInfo
 	var matchIdx int
+	var candidates []Candidate
 	for i, p := range r.providers {
 		results, err := p.Search(ctx, query)
 		if err != nil {
@@ -220,34 +445,71 @@ func (r *Resolver) findPrimaryMatch(ctx context.Context, query SearchQuery) (Tra
 			continue
 		}
 
-		candidate := pickBest(query, results)
+		candidates = append(candidates, r.fold.scoreCandidates(p.Name(), query, results)...)
+
+		candidate := r.fold.pickBest(query, results)
 		r.logger.Debug("  %s: best %q by %q (confidence: %.2f)", p.Name(), candidate.Title, candidate.Artist, candidate.Confidence)
 
 		if candidate.Confidence >= r.threshold {
-			return candidate, i
+			return candidate, i, candidates
 		}
 		if candidate.Confidence > best.Confidence {
 			best = candidate
 			matchIdx = i
 		}
 	}
-	return best, matchIdx
+	return best, matchIdx, candidates
+}
+
+// isrcMinScore is the lowest query score an ISRC match may have. ISRC hits
+// are exact, but a file can carry a stale or wrong ISRC; a match whose title
+// and artist plainly disagree with the file's is not trusted.
+const isrcMinScore = 0.4
+
+// findByISRC looks isrc up with each provider supporting ISRCLookup, in order,
+// and returns the first result that agrees with the query, at full confidence.
+func (r *Resolver) findByISRC(ctx context.Context, query SearchQuery, isrc string) (TrackInfo, int, []Candidate, bool) {
+	var candidates []Candidate
+	for i, p := range r.providers {
+		lookup, ok := p.(ISRCLookup)
+		if !ok {
+			continue
+		}
+		results, err := lookup.LookupByISRC(ctx, isrc)
+		if err != nil {
+			r.logger.Debug("  %s ISRC lookup failed: %v", p.Name(), err)
+			continue
+		}
+		if len(results) == 0 {
+			continue
+		}
+		candidates = append(candidates, r.fold.scoreCandidates(p.Name(), query, results)...)
+
+		best := r.fold.pickBest(query, results)
+		if best.Confidence < isrcMinScore {
+			r.logger.Debug("  %s: ISRC %s is %q by %q, which doesn't match the file", p.Name(), isrc, best.Title, best.Artist)
+			continue
+		}
+		best.Confidence = 1.0
+		return best, i, candidates, true
+	}
+	return TrackInfo{}, -1, candidates, false
 }
 
 // pickBest scores all results and returns the one with the highest confidence.
 // Ties are broken by album similarity to the query album.
-func pickBest(query SearchQuery, results []TrackInfo) TrackInfo {
+func (f folding) pickBest(query SearchQuery, results []TrackInfo) TrackInfo {
 	best := results[0]
-	best.Confidence = score(query, best)
+	best.Confidence = f.score(query, best)
 	for _, r := range results[1:] {
-		r.Confidence = score(query, r)
+		r.Confidence = f.score(query, r)
 		if r.Confidence > best.Confidence {
 			best = r
 			continue
 		}
 		if r.Confidence == best.Confidence && query.Album != "" && r.Album != "" && best.Album != "" {
-			rAlbumSim := similarity(normalize(query.Album), normalize(r.Album))
-			bestAlbumSim := similarity(normalize(query.Album), normalize(best.Album))
+			rAlbumSim := f.compare(query.Album, r.Album)
+			bestAlbumSim := f.compare(query.Album, best.Album)
 			if rAlbumSim > bestAlbumSim {
 				best = r
 			}
@@ -257,7 +519,12 @@ func pickBest(query SearchQuery, results []TrackInfo) TrackInfo {
 }
 
 // fillGaps queries remaining providers to fill missing fields in the primary match.
-func (r *Resolver) fillGaps(ctx context.Context, query SearchQuery, base TrackInfo, fromIdx int) TrackInfo {
+// When rep is non-nil, the candidates seen and the provider filling each field
+// are recorded in it.
+func (r *Resolver) fillGaps(ctx context.Context, query SearchQuery, base TrackInfo, fromIdx int, rep *FileReport) TrackInfo {
+	if r.genres != nil && len(r.genres.Normalize(base.Genre)) == 0 {
+		base.Genre = "" // nothing usable, let the next provider fill it
+	}
 	if !hasMissingFields(base) {
 		return base
 	}
@@ -268,13 +535,26 @@ func (r *Resolver) fillGaps(ctx context.Context, query SearchQuery, base TrackIn
 			continue
 		}
 
-		filler := pickBest(query, results)
+		if rep != nil {
+			rep.Candidates = append(rep.Candidates, r.fold.scoreCandidates(p.Name(), query, results)...)
+		}
+
+		filler := r.fold.pickBest(query, results)
 		if filler.Confidence < r.threshold {
 			continue
 		}
 
 		r.logger.Debug("  gap fill from %s: %q by %q", p.Name(), filler.Title, filler.Artist)
-		base = mergeTrackInfo(base, filler)
+		merged := mergeTrackInfo(base, filler)
+		if rep != nil {
+			for _, field := range filledFields(base, merged) {
+				if rep.GapFill == nil {
+					rep.GapFill = make(map[string]string)
+				}
+				rep.GapFill[field] = p.Name()
+			}
+		}
+		base = merged
 
 		if !hasMissingFields(base) {
 			break
@@ -306,6 +586,9 @@ func mergeTrackInfo(base, filler TrackInfo) TrackInfo {
 	if base.TotalTracks == 0 && filler.TotalTracks != 0 {
 		base.TotalTracks = filler.TotalTracks
 	}
+	if base.TotalDiscs == 0 && filler.TotalDiscs != 0 {
+		base.TotalDiscs = filler.TotalDiscs
+	}
 	if base.DiscNumber == 0 && filler.DiscNumber != 0 {
 		base.DiscNumber = filler.DiscNumber
 	}
@@ -321,14 +604,37 @@ func mergeTrackInfo(base, filler TrackInfo) TrackInfo {
 	if base.ArtworkURL == "" && filler.ArtworkURL != "" {
 		base.ArtworkURL = filler.ArtworkURL
 	}
+	base.Release = base.Release.fill(filler.Release)
+	if base.Credits.IsZero() {
+		base.Credits = filler.Credits
+	}
+	// IDs all refer to one release, so never mix sets from different providers.
+	if base.MusicBrainz.IsZero() {
+		base.MusicBrainz = fillerMusicBrainz(base, filler)
+	}
 	return base
 }
 
-// ensureAlbumArtist sets AlbumArtist to the primary artist (first before comma)
-// if it's missing. This prevents music servers like Navidrome from creating
-// separate entries for featured tracks.
-func ensureAlbumArtist(path string) {
-	tags, err := taglib.ReadTags(path)
+// fillerMusicBrainz returns the MusicBrainz IDs of filler that apply to base:
+// the recording and its artists always, the release-level IDs only when
+// filler was found on the same album, since they would otherwise name a
+// release base is not on.
+func fillerMusicBrainz(base, filler TrackInfo) MusicBrainzIDs {
+	ids := filler.MusicBrainz
+	if base.Album == "" || FoldText(base.Album) == FoldText(filler.Album) {
+		return ids
+	}
+	return MusicBrainzIDs{RecordingID: ids.RecordingID, ArtistIDs: ids.ArtistIDs}
+}
+
+// ensureAlbumArtist sets AlbumArtist to the primary artist (see primaryArtist)
+// if it's missing and the policy allows it. This prevents music servers like
+// Navidrome from creating separate entries for featured tracks.
+func (r *Resolver) ensureAlbumArtist(path string) {
+	if r.policy.For(taglib.AlbumArtist) == PolicyKeep {
+		return
+	}
+	tags, err := r.readTags(path)
 	if err != nil {
 		return
 	}
@@ -337,18 +643,14 @@ func ensureAlbumArtist(path string) {
 		return
 	}
 
-	artist := firstTag(tags, taglib.Artist)
+	artist := primaryArtist(tags)
 	if artist == "" {
 		return
 	}
 
-	if i := strings.Index(artist, ","); i > 0 {
-		artist = strings.TrimSpace(artist[:i])
-	}
-
-	taglib.WriteTags(path, map[string][]string{
+	r.writeTags(path, map[string][]string{
 		taglib.AlbumArtist: {artist},
-	}, 0)
+	})
 }
 
 func (r *Resolver) downloadAndEmbedArtwork(ctx context.Context, filePath, artworkURL string) error {
@@ -373,13 +675,25 @@ func (r *Resolver) downloadAndEmbedArtwork(ctx context.Context, filePath, artwor
 		return fmt.Errorf("failed to read artwork data: %w", err)
 	}
 
-	return WriteArtwork(filePath, data)
+	return r.writeArtwork(filePath, data)
 }
 
 // score computes a similarity score (0.0-1.0) between the query and a result.
-func score(query SearchQuery, result TrackInfo) float64 {
-	titleScore := similarity(normalize(query.Title), normalize(result.Title))
-	artistScore := similarity(normalize(query.Artist), normalize(result.Artist))
+func (f folding) score(query SearchQuery, result TrackInfo) float64 {
+	resultTitle, resultVersion := ExtractVersion(result.Title)
+	if result.Version.Kind != VersionOriginal {
+		resultVersion = result.Version
+	}
+
+	titleScore := f.compare(query.Title, resultTitle)
+	artistScore := f.compare(query.Artist, result.Artist)
+	// Providers that credit several artists ("Daft Punk, Pharrell Williams")
+	// should still match a query for the main artist alone.
+	if len(result.Artists) > 1 {
+		if s := f.compare(query.Artist, result.Artists[0].Name); s > artistScore {
+			artistScore = s
+		}
+	}
 
 	var s float64
 	if query.Artist == "" {
@@ -391,14 +705,19 @@ func score(query SearchQuery, result TrackInfo) float64 {
 
 	// Boost results that match the existing album tag from yt-dlp
 	if query.Album != "" && result.Album != "" {
-		albumScore := similarity(normalize(query.Album), normalize(result.Album))
+		albumScore := f.compare(query.Album, result.Album)
 		if albumScore > 0.8 {
 			s *= 1.1
 		}
 	}
 
+	// Penalize a different variant of the same song (live vs studio, remix vs original)
+	if !query.Version.Matches(resultVersion) {
+		s *= versionMismatchPenalty
+	}
+
 	// Penalize compilation albums so original releases are preferred
-	if strings.EqualFold(result.AlbumArtist, "Various Artists") {
+	if IsVariousArtists(result.AlbumArtist) {
 		s *= 0.8
 	}
 
@@ -410,118 +729,92 @@ func score(query SearchQuery, result TrackInfo) float64 {
 	return s
 }
 
-// similarity returns how similar two strings are (0.0-1.0).
-// Uses both token overlap and compact string comparison to handle cases
-// like "theweeknd" vs "the weeknd".
-func similarity(a, b string) float64 {
-	if a == "" && b == "" {
-		return 1.0
-	}
-	if a == "" || b == "" {
-		return 0.0
-	}
-
-	// Check compact (no-space) equality first: handles "theweeknd" == "the weeknd"
-	compactA := strings.ReplaceAll(a, " ", "")
-	compactB := strings.ReplaceAll(b, " ", "")
-	if compactA == compactB {
-		return 1.0
-	}
-
-	// Token overlap
-	tokensA := tokenize(a)
-	tokensB := tokenize(b)
-
-	if len(tokensA) == 0 || len(tokensB) == 0 {
-		return 0.0
-	}
-
-	setB := make(map[string]bool, len(tokensB))
-	for _, t := range tokensB {
-		setB[t] = true
-	}
-
-	matches := 0
-	for _, t := range tokensA {
-		if setB[t] {
-			matches++
-		}
-	}
-
-	maxLen := len(tokensA)
-	if len(tokensB) > maxLen {
-		maxLen = len(tokensB)
-	}
-	return float64(matches) / float64(maxLen)
-}
-
-// normalize lowercases and strips non-alphanumeric characters for comparison.
-func normalize(s string) string {
-	var b strings.Builder
-	for _, r := range strings.ToLower(s) {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
-			b.WriteRune(r)
-		}
-	}
-	return b.String()
-}
-
-// tokenize splits a string into lowercase tokens.
-func tokenize(s string) []string {
-	fields := strings.Fields(s)
-	var result []string
-	for _, f := range fields {
-		if f != "" {
-			result = append(result, f)
-		}
-	}
-	return result
-}
-
 const trackMatchThreshold = 0.6
 
-// resolveGroup looks up the full tracklist for album via ar and writes
-// TrackNumber/DiscNumber to each file whose title matches a tracklist entry
-// with sufficient confidence.
-func (r *Resolver) resolveGroup(ctx context.Context, album string, files []string, ar AlbumResolver) error {
+// resolveGroup asks each album resolver for the tracklist of album that best
+// fits the files (by track lengths, and by track count against groupSize, the
+// size of the whole album group), aligns the tracklists into a consensus, and
+// writes TrackNumber/DiscNumber to each file whose title matches a consensus
+// track with sufficient confidence and on whose position enough sources
+// agree. Failing resolvers are logged and skipped; an error is returned only
+// when every resolver failed. It reports whether any file was positioned.
+func (r *Resolver) resolveGroup(ctx context.Context, album string, files []string, groupSize int, resolvers []AlbumResolver) (bool, error) {
 	artist := ""
 	if len(files) > 0 {
-		if tags, err := taglib.ReadTags(files[0]); err == nil {
+		if tags, err := r.readTags(files[0]); err == nil {
 			artist = firstTag(tags, taglib.Artist)
 		}
 	}
 
-	tl, found, err := ar.ResolveAlbum(ctx, album, artist)
-	if err != nil {
-		return fmt.Errorf("resolve album %q: %w", album, err)
+	group := r.readGroup(files)
+	if len(group) == 0 {
+		return false, nil
 	}
-	if !found || len(tl.Tracks) == 0 {
-		return nil
-	}
-
-	for _, path := range files {
-		tags, err := taglib.ReadTags(path)
+	var sources []albumSource
+	var errs []error
+	for _, ar := range resolvers {
+		name := sourceName(ar)
+		candidates, err := r.albumCandidates(ctx, ar, album, artist)
 		if err != nil {
+			r.logger.Warn("  album-first: %s failed for %q: %v", name, album, err)
+			errs = append(errs, fmt.Errorf("resolve album %q on %s: %w", album, name, err))
 			continue
 		}
-		title := firstTag(tags, taglib.Title)
-		if title == "" {
+		tl, fit, found := r.fold.pickTracklist(candidates, group, groupSize)
+		if !found || !r.fold.matchesAny(tl, group) {
 			continue
 		}
+		r.logger.Debug("  album-first: %s: %q (%s, %d tracks, fit %.2f) of %d releases", name, tl.Title, tl.ID, len(tl.Tracks), fit, len(candidates))
+		sources = append(sources, albumSource{name: name, tl: tl})
+	}
+	if len(errs) == len(resolvers) {
+		return false, errors.Join(errs...)
+	}
+	if len(sources) == 0 {
+		return false, nil
+	}
 
-		track, matchScore := matchTrackByTitle(title, tl.Tracks)
+	// The whole consensus is kept for the consistency pass, whose track
+	// totals must count the tracks the sources disagree on too; agreement
+	// only decides which files get a position.
+	tl, tracks := r.fold.consensus(sources)
+
+	var positioned bool
+	for _, f := range group {
+		track, matchScore := r.fold.matchTrack(f, tl.Tracks)
 		if matchScore < trackMatchThreshold {
-			r.logger.Debug("  album-first: low match %.2f for %q, skipping", matchScore, title)
+			r.logger.Debug("  album-first: low match %.2f for %q, skipping", matchScore, f.title)
+			continue
+		}
+		ct := tracks[slices.Index(tl.Tracks, track)]
+		if ct.agreement < r.albumAgreement {
+			r.logger.Info("  album-first: sources disagree on the position of %q (%s), leaving it unset", f.title, strings.Join(ct.positions, ", "))
 			continue
 		}
+		if ct.agreement < 1 {
+			r.logger.Debug("  album-first: %.0f%% of sources agree on %q (%s)", ct.agreement*100, f.title, strings.Join(ct.positions, ", "))
+		}
 
-		r.logger.Debug("  album-first: %q → track %d disc %d (score %.2f)", title, track.TrackNumber, track.DiscNumber, matchScore)
-		if err := writePositionalTags(path, track.TrackNumber, track.DiscNumber); err != nil {
-			r.logger.Warn("  album-first: failed to write positional tags for %q: %v", path, err)
+		r.logger.Debug("  album-first: %q → track %d disc %d (score %.2f)", f.title, track.TrackNumber, track.DiscNumber, matchScore)
+		if err := r.writePositions(f.path, track); err != nil {
+			r.logger.Warn("  album-first: failed to write positional tags for %q: %v", f.path, err)
+			continue
+		}
+		r.tracklists[f.path] = &tl
+		positioned = true
+		r.fileReport(f.path).Positional = &PositionalResult{
+			Phase:       PhaseAlbumFirst,
+			Release:     tl.Title,
+			ReleaseID:   tl.ID,
+			TrackNumber: track.TrackNumber,
+			DiscNumber:  track.DiscNumber,
+			Score:       matchScore,
+			Agreement:   ct.agreement,
+			Sources:     len(ct.positions),
 		}
 	}
 
-	return nil
+	return positioned, nil
 }
 
 // resolveGroupByFingerprint fingerprints all files in the group, finds the dominant
@@ -561,11 +854,12 @@ func (r *Resolver) resolveGroupByFingerprint(ctx context.Context, files []string
 	pathToMBID := make(map[string]string, len(matches))
 	for _, m := range matches {
 		pathToMBID[m.Path] = m.MBID
+		r.fileReport(m.Path).Fingerprint = &FingerprintResult{Batch: true, Matched: true, RecordingID: m.MBID}
 	}
 
 	var resolved []string
 	for _, path := range files {
-		tags, err := taglib.ReadTags(path)
+		tags, err := r.readTags(path)
 		if err != nil {
 			continue
 		}
@@ -574,17 +868,39 @@ func (r *Resolver) resolveGroupByFingerprint(ctx context.Context, files []string
 			continue
 		}
 
-		track, matchScore := matchTrackByTitle(title, tl.Tracks)
+		track, matchScore := r.fold.matchTrackByTitle(title, tl.Tracks)
 		if matchScore < trackMatchThreshold {
 			r.logger.Debug("  batch fingerprint: low match %.2f for %q, skipping", matchScore, title)
 			continue
 		}
 
 		r.logger.Debug("  batch fingerprint: %q → track %d disc %d (score %.2f)", title, track.TrackNumber, track.DiscNumber, matchScore)
-		if err := writePositionalTags(path, track.TrackNumber, track.DiscNumber); err != nil {
+		if err := r.writePositions(path, track); err != nil {
 			r.logger.Warn("  batch fingerprint: failed to write positional tags for %q: %v", path, err)
 			continue
 		}
+		r.tracklists[path] = &tl
+		r.fileReport(path).Positional = &PositionalResult{
+			Phase:       PhaseBatchFingerprint,
+			Release:     tl.Title,
+			ReleaseID:   tl.ID,
+			TrackNumber: track.TrackNumber,
+			DiscNumber:  track.DiscNumber,
+			Score:       matchScore,
+		}
+		recordingID := track.MBID
+		if recordingID == "" {
+			recordingID = pathToMBID[path]
+		}
+		ids := MusicBrainzIDs{
+			RecordingID:    recordingID,
+			TrackID:        track.TrackID,
+			ReleaseID:      tl.ID,
+			ReleaseGroupID: tl.ReleaseGroupID,
+		}
+		if err := r.writeTags(path, r.policy.filter(musicBrainzTags(ids), tags)); err != nil {
+			r.logger.Warn("  batch fingerprint: failed to write MusicBrainz IDs for %q: %v", path, err)
+		}
 		resolved = append(resolved, path)
 	}
 
@@ -600,13 +916,17 @@ func (r *Resolver) findDominantRelease(ctx context.Context, mbids []string) (str
 	}
 
 	counts := make(map[string]int)
+	ranks := make(map[string]int) // best position of the release in any list
 	for _, mbid := range mbids {
 		ids, err := r.releaseResolver.ReleaseIDsForRecording(ctx, mbid)
 		if err != nil {
 			continue
 		}
-		for _, id := range ids {
+		for i, id := range ids {
 			counts[id]++
+			if rank, ok := ranks[id]; !ok || i < rank {
+				ranks[id] = i
+			}
 		}
 	}
 
@@ -615,8 +935,13 @@ func (r *Resolver) findDominantRelease(ctx context.Context, mbids []string) (str
 	bestID := ""
 	bestCount := 0
 	for id, count := range counts {
-		// Strict > for count; lexicographic < on ID breaks ties deterministically.
-		if count > bestCount || (count == bestCount && (bestID == "" || id < bestID)) {
+		// Strict > for count; ties go to the release the resolver ranked
+		// higher, then to the lexicographically smaller ID.
+		better := count > bestCount
+		if count == bestCount {
+			better = bestID == "" || ranks[id] < ranks[bestID] || (ranks[id] == ranks[bestID] && id < bestID)
+		}
+		if better {
 			bestCount = count
 			bestID = id
 		}
@@ -653,8 +978,39 @@ func groupByAlbum(files []string) map[string][]string {
 	return groups
 }
 
-// writePositionalTags writes TrackNumber and DiscNumber to a file, skipping zeros.
-func writePositionalTags(path string, trackNum, discNum int) error {
+// writePositions writes a tracklist position unless the policy keeps the
+// field. Positions from a matched release are authoritative, so fill is
+// treated like overwrite here.
+func (r *Resolver) writePositions(path string, track ReleaseTrack) error {
+	trackNum, discNum := track.TrackNumber, track.DiscNumber
+	if r.policy.For(taglib.TrackNumber) == PolicyKeep {
+		trackNum = 0
+	}
+	if r.policy.For(taglib.DiscNumber) == PolicyKeep {
+		discNum = 0
+	}
+	tags := positionalTags(trackNum, discNum)
+	if len(tags) == 0 {
+		return nil
+	}
+	return r.writeTags(path, tags)
+}
+
+// skipLocked drops files carrying the LockTag marker.
+func (r *Resolver) skipLocked(files []string) []string {
+	var out []string
+	for _, path := range files {
+		if tags, err := taglib.ReadTags(path); err == nil && IsLocked(tags) {
+			r.logger.Debug("Skipping locked file: %s", path)
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+// positionalTags returns TrackNumber and DiscNumber tags, skipping zeros.
+func positionalTags(trackNum, discNum int) map[string][]string {
 	tags := make(map[string][]string)
 	if trackNum > 0 {
 		tags[taglib.TrackNumber] = []string{strconv.Itoa(trackNum)}
@@ -662,19 +1018,16 @@ func writePositionalTags(path string, trackNum, discNum int) error {
 	if discNum > 0 {
 		tags[taglib.DiscNumber] = []string{strconv.Itoa(discNum)}
 	}
-	if len(tags) == 0 {
-		return nil
-	}
-	return taglib.WriteTags(path, tags, 0)
+	return tags
 }
 
 // matchTrackByTitle finds the track in tracks whose title best matches fileTitle.
 // Returns the best match and its similarity score (0.0–1.0).
-func matchTrackByTitle(fileTitle string, tracks []ReleaseTrack) (ReleaseTrack, float64) {
+func (f folding) matchTrackByTitle(fileTitle string, tracks []ReleaseTrack) (ReleaseTrack, float64) {
 	best := tracks[0]
-	bestScore := similarity(normalize(fileTitle), normalize(tracks[0].Title))
+	bestScore := f.compare(fileTitle, tracks[0].Title)
 	for _, t := range tracks[1:] {
-		s := similarity(normalize(fileTitle), normalize(t.Title))
+		s := f.compare(fileTitle, t.Title)
 		if s > bestScore {
 			bestScore = s
 			best = t
@@ -683,40 +1036,6 @@ func matchTrackByTitle(fileTitle string, tracks []ReleaseTrack) (ReleaseTrack, f
 	return best, bestScore
 }
 
-// mergeWithExisting reads the file's current TrackNumber and DiscNumber tags and
-// keeps their non-zero values over whatever the provider returned. This prevents
-// a wrong release selection from overwriting correct positional data from yt-dlp.
-func mergeWithExisting(path string, info TrackInfo) TrackInfo {
-	tags, err := taglib.ReadTags(path)
-	if err != nil {
-		return info
-	}
-	if n := parseTagInt(tags, taglib.TrackNumber); n > 0 {
-		info.TrackNumber = n
-	}
-	if n := parseTagInt(tags, taglib.DiscNumber); n > 0 {
-		info.DiscNumber = n
-	}
-	return info
-}
-
-// parseTagInt reads a tag value as an integer. Returns 0 if absent or non-numeric.
-func parseTagInt(tags map[string][]string, key string) int {
-	s := firstTag(tags, key)
-	if s == "" {
-		return 0
-	}
-	// Handle "5/12" format (track number / total tracks) written by some taggers.
-	if i := strings.Index(s, "/"); i > 0 {
-		s = strings.TrimSpace(s[:i])
-	}
-	n, err := strconv.Atoi(s)
-	if err != nil {
-		return 0
-	}
-	return n
-}
-
 func firstTag(tags map[string][]string, key string) string {
 	if vals, ok := tags[key]; ok && len(vals) > 0 {
 		return vals[0]
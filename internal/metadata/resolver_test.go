@@ -2,9 +2,11 @@ package metadata
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"ytmusic/internal/logger"
@@ -153,7 +155,7 @@ func TestFallbackToSecondProvider(t *testing.T) {
 	r := NewResolver([]Provider{p1, p2}, log, 0.5)
 
 	query := SearchQuery{Title: "My Song", Artist: "My Artist"}
-	best, idx := r.findPrimaryMatch(context.Background(), query)
+	best, idx, _ := r.findPrimaryMatch(context.Background(), query)
 
 	if !p2.called {
 		t.Error("second provider was not consulted")
@@ -198,7 +200,7 @@ func TestGapFilling(t *testing.T) {
 		Year:   2020,
 	}
 
-	filled := r.fillGaps(context.Background(), query, base, 0)
+	filled := r.fillGaps(context.Background(), query, base, 0, nil)
 
 	if filled.Genre != "Rock" {
 		t.Errorf("Genre = %q, want %q", filled.Genre, "Rock")
@@ -238,7 +240,7 @@ func TestGapFilling_CompleteMatch_SkipsSecondProvider(t *testing.T) {
 	r := NewResolver([]Provider{p1, p2}, log, 0.5)
 
 	query := SearchQuery{Title: "My Song", Artist: "My Artist"}
-	filled := r.fillGaps(context.Background(), query, p1.results[0], 0)
+	filled := r.fillGaps(context.Background(), query, p1.results[0], 0, nil)
 
 	if p2.called {
 		t.Error("second provider should not be consulted when match is complete")
@@ -248,6 +250,25 @@ func TestGapFilling_CompleteMatch_SkipsSecondProvider(t *testing.T) {
 	}
 }
 
+func TestGapFilling_ReplacesUnknownGenre(t *testing.T) {
+	p1 := &mockProvider{name: "primary"}
+	p2 := &mockProvider{
+		name:    "secondary",
+		results: []TrackInfo{{Title: "My Song", Artist: "My Artist", Genre: "Hip-Hop/Rap"}},
+	}
+
+	r := NewResolver([]Provider{p1, p2}, logger.New(false), 0.5).
+		WithGenreNormalizer(NewGenreNormalizer(GenreOptions{}))
+
+	query := SearchQuery{Title: "My Song", Artist: "My Artist"}
+	base := TrackInfo{Title: "My Song", Artist: "My Artist", Genre: "Bedroom Vibes"}
+	filled := r.fillGaps(context.Background(), query, base, 0, nil)
+
+	if filled.Genre != "Hip-Hop/Rap" {
+		t.Errorf("Genre = %q, want the second provider's genre", filled.Genre)
+	}
+}
+
 func TestGapFilling_NoProviderFindsMatch(t *testing.T) {
 	p1 := &mockProvider{name: "fail1", err: fmt.Errorf("api down")}
 	p2 := &mockProvider{name: "fail2", err: fmt.Errorf("api down")}
@@ -256,7 +277,7 @@ func TestGapFilling_NoProviderFindsMatch(t *testing.T) {
 	r := NewResolver([]Provider{p1, p2}, log, 0.5)
 
 	query := SearchQuery{Title: "My Song", Artist: "My Artist"}
-	best, _ := r.findPrimaryMatch(context.Background(), query)
+	best, _, _ := r.findPrimaryMatch(context.Background(), query)
 
 	if best.Confidence >= 0.5 {
 		t.Errorf("expected no match above threshold, got confidence %.2f", best.Confidence)
@@ -298,6 +319,53 @@ func TestGapFilling_DoesNotOverwriteAuthoritativeFields(t *testing.T) {
 	}
 }
 
+func TestMergeTrackInfo_MusicBrainzIDsAsUnit(t *testing.T) {
+	filler := TrackInfo{MusicBrainz: MusicBrainzIDs{RecordingID: "rec-2", ReleaseID: "rel-2"}}
+
+	merged := mergeTrackInfo(TrackInfo{}, filler)
+	if merged.MusicBrainz.RecordingID != "rec-2" || merged.MusicBrainz.ReleaseID != "rel-2" {
+		t.Errorf("MusicBrainz = %+v, want filler IDs", merged.MusicBrainz)
+	}
+
+	base := TrackInfo{MusicBrainz: MusicBrainzIDs{RecordingID: "rec-1"}}
+	merged = mergeTrackInfo(base, filler)
+	if merged.MusicBrainz.RecordingID != "rec-1" || merged.MusicBrainz.ReleaseID != "" {
+		t.Errorf("MusicBrainz = %+v, want base IDs untouched", merged.MusicBrainz)
+	}
+}
+
+func TestMergeTrackInfo_MusicBrainzReleaseIDsOnlyFromSameAlbum(t *testing.T) {
+	filler := TrackInfo{Album: "Greatest Hits", MusicBrainz: MusicBrainzIDs{
+		RecordingID: "rec-2", TrackID: "track-2", ReleaseID: "rel-2", ReleaseGroupID: "rg-2",
+		ArtistIDs: []string{"artist-2"}, AlbumArtistIDs: []string{"va"},
+	}}
+
+	merged := mergeTrackInfo(TrackInfo{Album: "Rumours"}, filler)
+	want := MusicBrainzIDs{RecordingID: "rec-2", ArtistIDs: []string{"artist-2"}}
+	if !reflect.DeepEqual(merged.MusicBrainz, want) {
+		t.Errorf("other album: MusicBrainz = %+v, want %+v", merged.MusicBrainz, want)
+	}
+
+	merged = mergeTrackInfo(TrackInfo{Album: "GREATEST HITS"}, filler)
+	if !reflect.DeepEqual(merged.MusicBrainz, filler.MusicBrainz) {
+		t.Errorf("same album: MusicBrainz = %+v, want all filler IDs", merged.MusicBrainz)
+	}
+}
+
+func TestMergeTrackInfo_ReleaseInfo(t *testing.T) {
+	base := TrackInfo{Release: ReleaseInfo{Types: []string{"album"}, Country: "GB"}}
+	filler := TrackInfo{Release: ReleaseInfo{Types: []string{"single"}, Country: "US", Label: "XL", Barcode: "634904078027"}}
+
+	merged := mergeTrackInfo(base, filler)
+	want := ReleaseInfo{Types: []string{"album"}, Country: "GB", Label: "XL", Barcode: "634904078027"}
+	if !reflect.DeepEqual(merged.Release, want) {
+		t.Errorf("Release = %+v, want %+v", merged.Release, want)
+	}
+	if got := filledFields(base, merged); !reflect.DeepEqual(got, []string{"label", "barcode"}) {
+		t.Errorf("filledFields = %v, want label and barcode", got)
+	}
+}
+
 func TestHasMissingFields(t *testing.T) {
 	complete := TrackInfo{
 		Genre:       "Pop",
@@ -350,11 +418,41 @@ func TestScore(t *testing.T) {
 			result:    TrackInfo{Title: "Blinding Lights", Artist: "The Weeknd"},
 			wantAbove: 0.99,
 		},
+		{
+			name:      "live result for studio query",
+			query:     SearchQuery{Title: "Wonderwall", Artist: "Oasis"},
+			result:    TrackInfo{Title: "Wonderwall - Live", Artist: "Oasis"},
+			wantBelow: 0.69,
+		},
+		{
+			name:      "live result for live query",
+			query:     SearchQuery{Title: "Wonderwall", Artist: "Oasis", Version: Version{Kind: VersionLive}},
+			result:    TrackInfo{Title: "Wonderwall (Live at Knebworth)", Artist: "Oasis"},
+			wantAbove: 0.99,
+		},
+		{
+			name:      "provider-reported version",
+			query:     SearchQuery{Title: "Wonderwall", Artist: "Oasis"},
+			result:    TrackInfo{Title: "Wonderwall", Artist: "Oasis", Version: Version{Kind: VersionLive}},
+			wantBelow: 0.69,
+		},
+		{
+			name:      "remaster suffix ignored",
+			query:     SearchQuery{Title: "Here Comes The Sun", Artist: "The Beatles"},
+			result:    TrackInfo{Title: "Here Comes The Sun - Remastered 2009", Artist: "The Beatles"},
+			wantAbove: 0.99,
+		},
+		{
+			name:      "remix result for original query",
+			query:     SearchQuery{Title: "Titanium", Artist: "David Guetta"},
+			result:    TrackInfo{Title: "Titanium (Alesso Remix)", Artist: "David Guetta"},
+			wantBelow: 0.69,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := score(tt.query, tt.result)
+			got := defaultFolding.score(tt.query, tt.result)
 			if tt.wantAbove > 0 && got < tt.wantAbove {
 				t.Errorf("score = %.4f, want above %.4f", got, tt.wantAbove)
 			}
@@ -379,7 +477,26 @@ func TestSimilarity(t *testing.T) {
 	for _, tt := range tests {
 		got := similarity(tt.a, tt.b)
 		if got != tt.want {
-			t.Errorf("similarity(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+			t.Errorf("Similarity(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity_UnicodeVariants(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Beyoncé", "Beyonce", 1.0},
+		{"Ｂｌｉｎｄｉｎｇ Ｌｉｇｈｔｓ", "Blinding Lights", 1.0},
+		{"Кино", "Kino", 1.0},
+		{"よるにかける", "Yoru ni Kakeru", 1.0},
+	}
+
+	for _, tt := range tests {
+		got := Similarity(tt.a, tt.b)
+		if got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
 		}
 	}
 }
@@ -389,7 +506,7 @@ type stubFingerprinter struct {
 	found bool
 }
 
-func (s *stubFingerprinter) LookupByFile(_ context.Context, _, _ string) (TrackInfo, bool, error) {
+func (s *stubFingerprinter) LookupByFile(_ context.Context, _ string, _ SearchQuery) (TrackInfo, bool, error) {
 	return s.info, s.found, nil
 }
 
@@ -407,7 +524,7 @@ func TestMatchTrackByTitle_FindsBestMatch(t *testing.T) {
 		{TrackNumber: 2, DiscNumber: 1, Title: "DIRTY!"},
 		{TrackNumber: 3, DiscNumber: 1, Title: "NEMO!"},
 	}
-	got, score := matchTrackByTitle("TRUST!", tracks)
+	got, score := defaultFolding.matchTrackByTitle("TRUST!", tracks)
 	if got.TrackNumber != 1 {
 		t.Errorf("TrackNumber = %d, want 1", got.TrackNumber)
 	}
@@ -421,7 +538,7 @@ func TestMatchTrackByTitle_LowScoreForUnrelated(t *testing.T) {
 		{TrackNumber: 1, Title: "TRUST!"},
 		{TrackNumber: 2, Title: "DIRTY!"},
 	}
-	_, score := matchTrackByTitle("COMPLETELY DIFFERENT SONG", tracks)
+	_, score := defaultFolding.matchTrackByTitle("COMPLETELY DIFFERENT SONG", tracks)
 	if score > 0.3 {
 		t.Errorf("score = %.2f, want <= 0.3 for unrelated title", score)
 	}
@@ -432,7 +549,7 @@ func TestMatchTrackByTitle_NormalizesBeforeComparing(t *testing.T) {
 		{TrackNumber: 5, Title: "ARE U HAPPY?"},
 	}
 	// yt-dlp may give slightly different casing or punctuation
-	got, score := matchTrackByTitle("ARE U HAPPY?", tracks)
+	got, score := defaultFolding.matchTrackByTitle("ARE U HAPPY?", tracks)
 	if got.TrackNumber != 5 {
 		t.Errorf("TrackNumber = %d, want 5", got.TrackNumber)
 	}
@@ -441,6 +558,20 @@ func TestMatchTrackByTitle_NormalizesBeforeComparing(t *testing.T) {
 	}
 }
 
+func TestMatchTrackByTitle_FoldsUnicode(t *testing.T) {
+	tracks := []ReleaseTrack{
+		{TrackNumber: 1, Title: "Déjà Vu"},
+		{TrackNumber: 2, Title: "Halo"},
+	}
+	got, score := defaultFolding.matchTrackByTitle("Deja Vu", tracks)
+	if got.TrackNumber != 1 {
+		t.Errorf("TrackNumber = %d, want 1", got.TrackNumber)
+	}
+	if score < 0.9 {
+		t.Errorf("score = %.2f, want >= 0.9 for diacritic-only difference", score)
+	}
+}
+
 // newTestMP3 creates a minimal silent MP3 in a temp dir and returns its path.
 // Skips the test if ffmpeg is not available.
 func newTestMP3(t *testing.T) string {
@@ -493,131 +624,163 @@ func TestGroupByAlbum_FilesWithNoAlbumGetOwnGroup(t *testing.T) {
 	}
 }
 
-func TestWritePositionalTags_WritesTrackAndDisc(t *testing.T) {
-	p := newTestMP3(t)
-
-	if err := writePositionalTags(p, 5, 2); err != nil {
-		t.Fatalf("writePositionalTags: %v", err)
-	}
-
-	tags, _ := taglib.ReadTags(p)
-	if got := firstTag(tags, taglib.TrackNumber); got != "5" {
-		t.Errorf("TrackNumber = %q, want %q", got, "5")
+func TestPositionalTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		track, disc int
+		wantTrack   string
+		wantDisc    string
+	}{
+		{"track and disc", 5, 2, "5", "2"},
+		{"zero disc skipped", 4, 0, "4", ""},
+		{"both zero", 0, 0, "", ""},
 	}
-	if got := firstTag(tags, taglib.DiscNumber); got != "2" {
-		t.Errorf("DiscNumber = %q, want %q", got, "2")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := positionalTags(tt.track, tt.disc)
+			if got := firstTag(tags, taglib.TrackNumber); got != tt.wantTrack {
+				t.Errorf("TrackNumber = %q, want %q", got, tt.wantTrack)
+			}
+			if got := firstTag(tags, taglib.DiscNumber); got != tt.wantDisc {
+				t.Errorf("DiscNumber = %q, want %q", got, tt.wantDisc)
+			}
+		})
 	}
 }
 
-func TestWritePositionalTags_SkipsZeroValues(t *testing.T) {
-	p := newTestMP3(t)
-	taglib.WriteTags(p, map[string][]string{taglib.TrackNumber: {"3"}}, 0)
-
-	// disc = 0 means "unknown", should not write
-	if err := writePositionalTags(p, 4, 0); err != nil {
-		t.Fatalf("writePositionalTags: %v", err)
-	}
+type mockBatchFingerprinter struct {
+	matches []FileMatch
+}
 
-	tags, _ := taglib.ReadTags(p)
-	if got := firstTag(tags, taglib.TrackNumber); got != "4" {
-		t.Errorf("TrackNumber = %q, want %q", got, "4")
-	}
-	if got := firstTag(tags, taglib.DiscNumber); got != "" {
-		t.Errorf("DiscNumber = %q, want empty (zero not written)", got)
-	}
+func (m *mockBatchFingerprinter) BatchLookupByFiles(_ context.Context, _ []string) []FileMatch {
+	return m.matches
 }
 
-func TestMergeWithExisting_HandlesSlashTrackNumberFormat(t *testing.T) {
-	path := newTestMP3(t)
+type mockReleaseResolver struct {
+	releaseIDs map[string][]string  // mbid → release IDs
+	tracklists map[string]Tracklist // releaseID → tracklist
+}
 
-	if err := taglib.WriteTags(path, map[string][]string{
-		taglib.Title:       {"TRUST!"},
-		taglib.TrackNumber: {"5/12"},
-	}, 0); err != nil {
-		t.Fatalf("write initial tags: %v", err)
-	}
+func (m *mockReleaseResolver) ReleaseIDsForRecording(_ context.Context, mbid string) ([]string, error) {
+	return m.releaseIDs[mbid], nil
+}
 
-	// Provider returns track 3 (wrong release), existing tag is "5/12"
-	info := TrackInfo{Title: "TRUST!", TrackNumber: 3}
-	got := mergeWithExisting(path, info)
+func (m *mockReleaseResolver) LookupTracklist(_ context.Context, releaseID string) (Tracklist, error) {
+	return m.tracklists[releaseID], nil
+}
 
-	if got.TrackNumber != 5 {
-		t.Errorf("TrackNumber = %d, want 5 (slash format must be parsed, not ignored)", got.TrackNumber)
-	}
+type mockISRCProvider struct {
+	mockProvider
+	byISRC map[string][]TrackInfo
 }
 
-func TestMergeWithExisting_PreservesNonZeroTrackNumber(t *testing.T) {
-	path := newTestMP3(t)
+func (m *mockISRCProvider) LookupByISRC(_ context.Context, isrc string) ([]TrackInfo, error) {
+	return m.byISRC[isrc], nil
+}
 
-	if err := taglib.WriteTags(path, map[string][]string{
-		taglib.Title:       {"TRUST!"},
-		taglib.TrackNumber: {"5"},
-	}, 0); err != nil {
-		t.Fatalf("write initial tags: %v", err)
+func TestFindByISRC(t *testing.T) {
+	query := SearchQuery{Title: "Come Together", Artist: "The Beatles"}
+	plain := &mockProvider{name: "itunes"}
+	isrc := &mockISRCProvider{
+		mockProvider: mockProvider{name: "deezer"},
+		byISRC: map[string][]TrackInfo{
+			"GBAYE6900524": {{Title: "Come Together (Remastered 2009)", Artist: "The Beatles", Album: "Abbey Road"}},
+			"USWRONG00001": {{Title: "Something Else", Artist: "Another Band"}},
+		},
 	}
+	r := NewResolver([]Provider{plain, isrc}, logger.New(false), 0.7)
 
-	info := TrackInfo{Title: "TRUST!", TrackNumber: 3}
-	got := mergeWithExisting(path, info)
-
-	if got.TrackNumber != 5 {
-		t.Errorf("TrackNumber = %d, want 5 (yt-dlp value preserved)", got.TrackNumber)
+	info, idx, candidates, found := r.findByISRC(context.Background(), query, "GBAYE6900524")
+	if !found || idx != 1 || info.Album != "Abbey Road" || info.Confidence != 1.0 {
+		t.Errorf("found=%v idx=%d info=%+v, want the deezer match at full confidence", found, idx, info)
 	}
-}
-
-func TestMergeWithExisting_FillsZeroTrackNumber(t *testing.T) {
-	path := newTestMP3(t)
-
-	if err := taglib.WriteTags(path, map[string][]string{
-		taglib.Title: {"TRUST!"},
-	}, 0); err != nil {
-		t.Fatalf("write initial tags: %v", err)
+	if len(candidates) != 1 {
+		t.Errorf("candidates = %d, want 1", len(candidates))
 	}
-
-	info := TrackInfo{Title: "TRUST!", TrackNumber: 5}
-	got := mergeWithExisting(path, info)
-
-	if got.TrackNumber != 5 {
-		t.Errorf("TrackNumber = %d, want 5 (provider value used when no existing tag)", got.TrackNumber)
+	if plain.called {
+		t.Error("provider without ISRC lookup was searched")
 	}
-}
-
-func TestMergeWithExisting_PreservesNonZeroDiscNumber(t *testing.T) {
-	path := newTestMP3(t)
 
-	if err := taglib.WriteTags(path, map[string][]string{
-		taglib.Title:      {"TRUST!"},
-		taglib.DiscNumber: {"1"},
-	}, 0); err != nil {
-		t.Fatalf("write initial tags: %v", err)
+	if _, _, _, found := r.findByISRC(context.Background(), query, "USWRONG00001"); found {
+		t.Error("ISRC match disagreeing with the file was accepted")
 	}
-
-	info := TrackInfo{Title: "TRUST!", DiscNumber: 2}
-	got := mergeWithExisting(path, info)
-
-	if got.DiscNumber != 1 {
-		t.Errorf("DiscNumber = %d, want 1 (yt-dlp value preserved)", got.DiscNumber)
+	if _, _, _, found := r.findByISRC(context.Background(), query, "XXUNKNOWN000"); found {
+		t.Error("unknown ISRC matched")
 	}
 }
 
-type mockBatchFingerprinter struct {
-	matches []FileMatch
+type mockCreditsResolver struct {
+	credits Credits
+	calls   []string
 }
 
-func (m *mockBatchFingerprinter) BatchLookupByFiles(_ context.Context, _ []string) []FileMatch {
-	return m.matches
+func (m *mockCreditsResolver) Credits(_ context.Context, recordingID string) (Credits, error) {
+	m.calls = append(m.calls, recordingID)
+	return m.credits, nil
 }
 
-type mockReleaseResolver struct {
-	releaseIDs map[string][]string  // mbid → release IDs
-	tracklists map[string]Tracklist // releaseID → tracklist
+func TestFillCredits(t *testing.T) {
+	known := Credits{Composers: []string{"Paul McCartney"}}
+	tests := []struct {
+		name      string
+		info      TrackInfo
+		wantCalls int
+	}{
+		{"musicbrainz match without credits", TrackInfo{MusicBrainz: MusicBrainzIDs{RecordingID: "rec-1"}}, 1},
+		{"credits already known", TrackInfo{MusicBrainz: MusicBrainzIDs{RecordingID: "rec-1"}, Credits: known}, 0},
+		{"lookup found nobody", TrackInfo{MusicBrainz: MusicBrainzIDs{RecordingID: "rec-1"}, Credits: Credits{Fetched: true}}, 0},
+		{"no recording ID", TrackInfo{Title: "Yesterday"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &mockCreditsResolver{credits: known}
+			r := NewResolver(nil, logger.New(false), 0).WithCreditsResolver(cr)
+			got := r.fillCredits(context.Background(), tt.info)
+			if len(cr.calls) != tt.wantCalls {
+				t.Errorf("lookups = %v, want %d", cr.calls, tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && !reflect.DeepEqual(got.Credits, known) {
+				t.Errorf("Credits = %+v, want %+v", got.Credits, known)
+			}
+		})
+	}
 }
 
-func (m *mockReleaseResolver) ReleaseIDsForRecording(_ context.Context, mbid string) ([]string, error) {
-	return m.releaseIDs[mbid], nil
+type mockDetailsProvider struct {
+	mockProvider
+	calls []string
 }
 
-func (m *mockReleaseResolver) LookupTracklist(_ context.Context, releaseID string) (Tracklist, error) {
-	return m.tracklists[releaseID], nil
+func (m *mockDetailsProvider) AlbumDetails(_ context.Context, info TrackInfo) (TrackInfo, error) {
+	m.calls = append(m.calls, info.AlbumID)
+	info.TotalTracks, info.TotalDiscs = 10, 2
+	return info, nil
+}
+
+func TestFillAlbumDetails(t *testing.T) {
+	plain := &mockProvider{name: "plain"}
+	tests := []struct {
+		name      string
+		idx       int
+		wantCalls int
+	}{
+		{"match from a provider with album details", 0, 1},
+		{"match from a provider without", 1, 0},
+		{"match from no provider", -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := &mockDetailsProvider{mockProvider: mockProvider{name: "details"}}
+			r := NewResolver([]Provider{dp, plain}, logger.New(false), 0)
+			got := r.fillAlbumDetails(context.Background(), TrackInfo{Title: "Godspeed", AlbumID: "alb-1"}, tt.idx)
+			if len(dp.calls) != tt.wantCalls {
+				t.Errorf("lookups = %v, want %d", dp.calls, tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && (got.TotalTracks != 10 || got.TotalDiscs != 2) {
+				t.Errorf("totals = %d/%d, want 10/2", got.TotalTracks, got.TotalDiscs)
+			}
+		})
+	}
 }
 
 type mockAlbumResolver struct {
@@ -654,6 +817,25 @@ func TestFindDominantRelease_ReturnsMajority(t *testing.T) {
 	}
 }
 
+func TestFindDominantRelease_TieFollowsPreferenceOrder(t *testing.T) {
+	// Both recordings are on both releases; the resolver lists the preferred
+	// original before the bonus edition, whose ID sorts first.
+	rr := &mockReleaseResolver{
+		releaseIDs: map[string][]string{
+			"mbid-1": {"rel-original", "rel-bonus"},
+			"mbid-2": {"rel-original", "rel-bonus"},
+		},
+	}
+
+	r := NewResolver(nil, logger.New(false), 0)
+	r.WithReleaseResolver(rr)
+
+	dominantID, found := r.findDominantRelease(context.Background(), []string{"mbid-1", "mbid-2"})
+	if !found || dominantID != "rel-original" {
+		t.Errorf("dominant = %q (found=%v), want rel-original", dominantID, found)
+	}
+}
+
 func TestFindDominantRelease_NoQuorum_NotFound(t *testing.T) {
 	// Each MBID belongs to a different release — no quorum
 	rr := &mockReleaseResolver{
@@ -875,7 +1057,7 @@ func TestResolveGroup_WritesPositionalTags(t *testing.T) {
 
 	log := logger.New(false)
 	r := NewResolver(nil, log, 0)
-	if err := r.resolveGroup(context.Background(), "LP!", []string{p1, p2}, ar); err != nil {
+	if _, err := r.resolveGroup(context.Background(), "LP!", []string{p1, p2}, 2, []AlbumResolver{ar}); err != nil {
 		t.Fatalf("resolveGroup: %v", err)
 	}
 
@@ -893,6 +1075,40 @@ func TestResolveGroup_WritesPositionalTags(t *testing.T) {
 	}
 }
 
+func TestResolveGroup_FailingSourceIsSkipped(t *testing.T) {
+	lp := Tracklist{ID: "abc", Title: "LP!", Tracks: []ReleaseTrack{{TrackNumber: 3, DiscNumber: 1, Title: "TRUST!"}}}
+	failing := &mockAlbumResolver{err: errors.New("service unavailable")}
+	tests := []struct {
+		name      string
+		resolvers []AlbumResolver
+		wantErr   bool
+		wantTrack string
+	}{
+		{"another source answers", []AlbumResolver{failing, &mockAlbumResolver{found: true, tracklist: lp}}, false, "3"},
+		{"another source finds nothing", []AlbumResolver{failing, &mockAlbumResolver{}}, false, ""},
+		{"every source fails", []AlbumResolver{failing, failing}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestMP3(t)
+			taglib.WriteTags(p, map[string][]string{
+				taglib.Title: {"TRUST!"},
+				taglib.Album: {"LP!"},
+			}, 0)
+
+			r := NewResolver(nil, logger.New(false), 0)
+			_, err := r.resolveGroup(context.Background(), "LP!", []string{p}, 1, tt.resolvers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			tags, _ := taglib.ReadTags(p)
+			if got := firstTag(tags, taglib.TrackNumber); got != tt.wantTrack {
+				t.Errorf("TrackNumber = %q, want %q", got, tt.wantTrack)
+			}
+		})
+	}
+}
+
 func TestResolveGroup_NotFound_DoesNothing(t *testing.T) {
 	p := newTestMP3(t)
 	taglib.WriteTags(p, map[string][]string{
@@ -904,7 +1120,7 @@ func TestResolveGroup_NotFound_DoesNothing(t *testing.T) {
 
 	log := logger.New(false)
 	r := NewResolver(nil, log, 0)
-	if err := r.resolveGroup(context.Background(), "LP!", []string{p}, ar); err != nil {
+	if _, err := r.resolveGroup(context.Background(), "LP!", []string{p}, 1, []AlbumResolver{ar}); err != nil {
 		t.Fatalf("resolveGroup: %v", err)
 	}
 
@@ -933,7 +1149,7 @@ func TestResolveGroup_LowTitleMatch_DoesNotWriteTags(t *testing.T) {
 
 	log := logger.New(false)
 	r := NewResolver(nil, log, 0)
-	if err := r.resolveGroup(context.Background(), "LP!", []string{p}, ar); err != nil {
+	if _, err := r.resolveGroup(context.Background(), "LP!", []string{p}, 1, []AlbumResolver{ar}); err != nil {
 		t.Fatalf("resolveGroup: %v", err)
 	}
 
@@ -990,6 +1206,62 @@ func TestResolve_AlbumFirstPhaseWritesPositionalTags(t *testing.T) {
 	}
 }
 
+type mockAlbumProvider struct {
+	mockProvider
+	mockAlbumResolver
+}
+
+func TestAlbumResolvers_ProviderOrder(t *testing.T) {
+	deezer := &mockAlbumProvider{mockProvider: mockProvider{name: "deezer"}}
+	mb := &mockAlbumProvider{mockProvider: mockProvider{name: "musicbrainz"}}
+	attached := &mockAlbumResolver{}
+
+	r := NewResolver([]Provider{&mockProvider{name: "spotify"}, deezer, mb}, logger.New(false), 0)
+	r.WithAlbumResolver(mb)
+	if got := r.albumResolvers(); len(got) != 2 || got[0] != AlbumResolver(deezer) || got[1] != AlbumResolver(mb) {
+		t.Errorf("albumResolvers() = %v, want deezer then musicbrainz once", got)
+	}
+
+	r.WithAlbumResolver(attached)
+	if got := r.albumResolvers(); len(got) != 3 || got[2] != AlbumResolver(attached) {
+		t.Errorf("albumResolvers() = %v, want the attached resolver last", got)
+	}
+}
+
+func TestResolve_AlbumFirstFallsThroughResolvers(t *testing.T) {
+	p := newTestMP3(t)
+	taglib.WriteTags(p, map[string][]string{
+		taglib.Title:  {"TRUST!"},
+		taglib.Artist: {"JPEGMAFIA"},
+		taglib.Album:  {"LP!"},
+	}, 0)
+	p2 := newTestMP3(t)
+	taglib.WriteTags(p2, map[string][]string{
+		taglib.Title:  {"DIRTY!"},
+		taglib.Artist: {"JPEGMAFIA"},
+		taglib.Album:  {"LP!"},
+	}, 0)
+
+	first := &mockAlbumProvider{mockProvider: mockProvider{name: "deezer"}}
+	second := &mockAlbumProvider{
+		mockProvider: mockProvider{name: "itunes"},
+		mockAlbumResolver: mockAlbumResolver{found: true, tracklist: Tracklist{
+			Title:  "LP!",
+			Tracks: []ReleaseTrack{{TrackNumber: 1, DiscNumber: 1, Title: "TRUST!"}, {TrackNumber: 2, DiscNumber: 1, Title: "DIRTY!"}},
+		}},
+	}
+
+	r := NewResolver([]Provider{first, second}, logger.New(false), 0.9)
+	if err := r.Resolve(context.Background(), []string{p, p2}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	tags, _ := taglib.ReadTags(p2)
+	if got := firstTag(tags, taglib.TrackNumber); got != "2" {
+		t.Errorf("TrackNumber = %q, want 2 from the second album resolver", got)
+	}
+}
+
 func TestResolveFile_PreservesYtdlpTrackNumber(t *testing.T) {
 	path := newTestMP3(t)
 
@@ -0,0 +1,185 @@
+package metadata
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.senan.xyz/taglib"
+)
+
+// Candidate is one provider result considered for a file, with its score in
+// Info.Confidence.
+type Candidate struct {
+	Provider string    `json:"provider"`
+	Info     TrackInfo `json:"info"`
+}
+
+// ReviewAction is the user's answer to a ReviewRequest.
+type ReviewAction int
+
+const (
+	// ReviewSkip keeps the file's original tags.
+	ReviewSkip ReviewAction = iota
+	// ReviewPick applies Candidates[Choice].
+	ReviewPick
+	// ReviewSearch searches all providers again with Query.
+	ReviewSearch
+)
+
+// ReviewRequest describes a file whose best match fell below the confidence
+// threshold.
+type ReviewRequest struct {
+	Path       string
+	Query      SearchQuery
+	Duration   time.Duration // length of the audio file, 0 if unknown
+	Candidates []Candidate   // sorted by score, best first
+}
+
+// ReviewDecision is returned by a Reviewer.
+type ReviewDecision struct {
+	Action ReviewAction
+	Choice int         // index into ReviewRequest.Candidates for ReviewPick
+	Query  SearchQuery // manual query for ReviewSearch
+	// ApplyToAlbum reuses the decision for the remaining low-confidence files
+	// of the same album group: skip them all, or pick their candidate on the
+	// chosen album.
+	ApplyToAlbum bool
+}
+
+// Reviewer lets a user decide what to do with a low-confidence match.
+type Reviewer interface {
+	Review(ctx context.Context, req ReviewRequest) (ReviewDecision, error)
+}
+
+// albumDecision is a review decision remembered for an album group.
+type albumDecision struct {
+	skip  bool
+	album string // album of the picked candidate
+}
+
+// albumDecisionMatch is the minimum album similarity for a candidate to be
+// auto-picked by a decision applied to the whole album group.
+const albumDecisionMatch = 0.8
+
+// review asks the reviewer to choose among below-threshold candidates. Returns
+// the chosen candidate, the query that produced it, and false when the file
+// should keep its original tags.
+func (r *Resolver) review(ctx context.Context, path string, query SearchQuery, candidates []Candidate) (Candidate, SearchQuery, bool) {
+	if r.reviewer == nil {
+		return Candidate{}, query, false
+	}
+	sortCandidates(candidates)
+
+	if d, ok := r.albumDecisions[query.Album]; ok && query.Album != "" {
+		if d.skip {
+			r.logger.Debug("  review: skipped with album %q", query.Album)
+			return Candidate{}, query, false
+		}
+		if c, ok := r.fold.candidateOnAlbum(candidates, d.album); ok {
+			r.logger.Debug("  review: picked %q from album decision %q", c.Info.Title, d.album)
+			return c, query, true
+		}
+	}
+
+	req := ReviewRequest{
+		Path:       path,
+		Query:      query,
+		Duration:   fileDuration(path),
+		Candidates: candidates,
+	}
+	for {
+		d, err := r.reviewer.Review(ctx, req)
+		if err != nil {
+			r.logger.Warn("  review failed: %v", err)
+			return Candidate{}, query, false
+		}
+
+		switch d.Action {
+		case ReviewSearch:
+			req.Query = d.Query
+			req.Candidates = r.searchAll(ctx, d.Query)
+			continue
+
+		case ReviewPick:
+			if d.Choice < 0 || d.Choice >= len(req.Candidates) {
+				continue
+			}
+			c := req.Candidates[d.Choice]
+			if d.ApplyToAlbum && query.Album != "" {
+				r.albumDecisions[query.Album] = albumDecision{album: c.Info.Album}
+			}
+			return c, req.Query, true
+
+		default:
+			if d.ApplyToAlbum && query.Album != "" {
+				r.albumDecisions[query.Album] = albumDecision{skip: true}
+			}
+			return Candidate{}, query, false
+		}
+	}
+}
+
+// searchAll queries every provider and returns all scored results, best first.
+func (r *Resolver) searchAll(ctx context.Context, query SearchQuery) []Candidate {
+	var candidates []Candidate
+	for _, p := range r.providers {
+		results, err := p.Search(ctx, query)
+		if err != nil {
+			r.logger.Debug("  provider %s failed: %v", p.Name(), err)
+			continue
+		}
+		candidates = append(candidates, r.fold.scoreCandidates(p.Name(), query, results)...)
+	}
+	sortCandidates(candidates)
+	return candidates
+}
+
+// scoreCandidates wraps results from provider as candidates scored against query.
+func (f folding) scoreCandidates(provider string, query SearchQuery, results []TrackInfo) []Candidate {
+	out := make([]Candidate, 0, len(results))
+	for _, res := range results {
+		res.Confidence = f.score(query, res)
+		out = append(out, Candidate{Provider: provider, Info: res})
+	}
+	return out
+}
+
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Info.Confidence > candidates[j].Info.Confidence
+	})
+}
+
+// candidateOnAlbum returns the best candidate whose album matches album.
+// candidates must be sorted best first.
+func (f folding) candidateOnAlbum(candidates []Candidate, album string) (Candidate, bool) {
+	if album == "" {
+		return Candidate{}, false
+	}
+	for _, c := range candidates {
+		if f.compare(c.Info.Album, album) >= albumDecisionMatch {
+			return c, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// providerIndex returns the position of the named provider, or -1.
+func (r *Resolver) providerIndex(name string) int {
+	for i, p := range r.providers {
+		if p.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// fileDuration returns the audio length of path, or 0 if it can't be read.
+func fileDuration(path string) time.Duration {
+	props, err := taglib.ReadProperties(path)
+	if err != nil {
+		return 0
+	}
+	return props.Length
+}
@@ -0,0 +1,146 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"ytmusic/internal/logger"
+)
+
+// stubReviewer replays decisions in order and records the requests it saw.
+type stubReviewer struct {
+	decisions []ReviewDecision
+	requests  []ReviewRequest
+}
+
+func (s *stubReviewer) Review(_ context.Context, req ReviewRequest) (ReviewDecision, error) {
+	s.requests = append(s.requests, req)
+	d := s.decisions[0]
+	s.decisions = s.decisions[1:]
+	return d, nil
+}
+
+func reviewTestResolver(rv Reviewer) *Resolver {
+	p1 := &mockProvider{name: "first", results: []TrackInfo{
+		{Title: "Song (Live)", Artist: "Band", Album: "Live at Home"},
+	}}
+	p2 := &mockProvider{name: "second", results: []TrackInfo{
+		{Title: "Song", Artist: "Other Band", Album: "Studio Album"},
+	}}
+	r := NewResolver([]Provider{p1, p2}, logger.New(false), 0.99)
+	if rv != nil {
+		r = r.WithReviewer(rv)
+	}
+	return r
+}
+
+func TestFindPrimaryMatch_CollectsCandidatesFromAllProviders(t *testing.T) {
+	r := reviewTestResolver(nil)
+	query := SearchQuery{Title: "Song", Artist: "Band"}
+
+	_, _, candidates := r.findPrimaryMatch(context.Background(), query)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+	for _, c := range candidates {
+		if c.Info.Confidence == 0 {
+			t.Errorf("candidate from %s not scored", c.Provider)
+		}
+	}
+}
+
+func TestReview_NoReviewerKeepsOriginal(t *testing.T) {
+	r := reviewTestResolver(nil)
+	if _, _, ok := r.review(context.Background(), "a.mp3", SearchQuery{Title: "Song"}, nil); ok {
+		t.Error("expected no pick without a reviewer")
+	}
+}
+
+func TestReview_PickSortsCandidates(t *testing.T) {
+	rv := &stubReviewer{decisions: []ReviewDecision{{Action: ReviewPick, Choice: 0}}}
+	r := reviewTestResolver(rv)
+	query := SearchQuery{Title: "Song", Artist: "Band"}
+	_, _, candidates := r.findPrimaryMatch(context.Background(), query)
+
+	picked, _, ok := r.review(context.Background(), "a.mp3", query, candidates)
+	if !ok {
+		t.Fatal("expected a pick")
+	}
+	got := rv.requests[0].Candidates
+	if got[0].Info.Confidence < got[1].Info.Confidence {
+		t.Errorf("candidates not sorted best first: %.2f < %.2f", got[0].Info.Confidence, got[1].Info.Confidence)
+	}
+	if picked.Info.Title != got[0].Info.Title {
+		t.Errorf("picked %q, want %q", picked.Info.Title, got[0].Info.Title)
+	}
+}
+
+func TestReview_ManualSearch(t *testing.T) {
+	manual := SearchQuery{Title: "Song", Artist: "Other Band"}
+	rv := &stubReviewer{decisions: []ReviewDecision{
+		{Action: ReviewSearch, Query: manual},
+		{Action: ReviewPick, Choice: 0},
+	}}
+	r := reviewTestResolver(rv)
+
+	picked, query, ok := r.review(context.Background(), "a.mp3", SearchQuery{Title: "Sonng"}, nil)
+	if !ok {
+		t.Fatal("expected a pick after manual search")
+	}
+	if len(rv.requests) != 2 || len(rv.requests[1].Candidates) != 2 {
+		t.Fatalf("expected a second request with fresh candidates, got %+v", rv.requests)
+	}
+	if query.Artist != manual.Artist {
+		t.Errorf("query.Artist = %q, want %q", query.Artist, manual.Artist)
+	}
+	if picked.Provider != "second" {
+		t.Errorf("picked from %s, want second (best for manual query)", picked.Provider)
+	}
+}
+
+func TestReview_ApplyPickToAlbum(t *testing.T) {
+	rv := &stubReviewer{decisions: []ReviewDecision{{Action: ReviewPick, Choice: 1, ApplyToAlbum: true}}}
+	r := reviewTestResolver(rv)
+	query := SearchQuery{Title: "Song", Artist: "Band", Album: "Home Videos"}
+	_, _, candidates := r.findPrimaryMatch(context.Background(), query)
+
+	first, _, ok := r.review(context.Background(), "a.mp3", query, candidates)
+	if !ok {
+		t.Fatal("expected a pick")
+	}
+
+	// The next file of the group is resolved from the remembered album
+	// without asking again.
+	second, _, ok := r.review(context.Background(), "b.mp3", query, candidates)
+	if !ok {
+		t.Fatal("expected the album decision to pick")
+	}
+	if len(rv.requests) != 1 {
+		t.Errorf("reviewer asked %d times, want 1", len(rv.requests))
+	}
+	if second.Info.Album != first.Info.Album {
+		t.Errorf("album = %q, want %q", second.Info.Album, first.Info.Album)
+	}
+}
+
+func TestReview_ApplySkipToAlbum(t *testing.T) {
+	rv := &stubReviewer{decisions: []ReviewDecision{{Action: ReviewSkip, ApplyToAlbum: true}}}
+	r := reviewTestResolver(rv)
+	query := SearchQuery{Title: "Song", Album: "Home Videos"}
+
+	for _, path := range []string{"a.mp3", "b.mp3"} {
+		if _, _, ok := r.review(context.Background(), path, query, nil); ok {
+			t.Errorf("%s: expected skip", path)
+		}
+	}
+	if len(rv.requests) != 1 {
+		t.Errorf("reviewer asked %d times, want 1", len(rv.requests))
+	}
+
+	// Other albums are still reviewed.
+	rv.decisions = []ReviewDecision{{Action: ReviewSkip}}
+	r.review(context.Background(), "c.mp3", SearchQuery{Title: "Song", Album: "Elsewhere"}, nil)
+	if len(rv.requests) != 2 {
+		t.Errorf("reviewer asked %d times, want 2", len(rv.requests))
+	}
+}
@@ -0,0 +1,302 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// tokenMatchThreshold is the minimum Jaro-Winkler similarity for two tokens to
+// be considered the same word (tolerates single typos like "ligths"/"lights").
+const tokenMatchThreshold = 0.85
+
+// stopwordWeight is the weight of articles and conjunctions relative to other
+// tokens, so a missing "the" or "and" barely affects the score.
+const stopwordWeight = 0.25
+
+// AlbumMatchThreshold is the minimum Similarity between a search result's
+// album title and the requested album for a provider to take the result.
+// Editions clear it ("Abbey Road (Remastered)" scores 0.8 against "Abbey
+// Road"); unrelated albums by the same artist score near zero.
+const AlbumMatchThreshold = 0.5
+
+var stopwords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "of": true,
+	"el": true, "la": true, "le": true, "les": true, "il": true, "der": true, "die": true, "das": true,
+}
+
+// Similarity normalizes both strings and returns how similar they are (0.0-1.0).
+// Exported for providers that rank their own candidates (e.g. release titles)
+// with the same metric the resolver uses.
+func Similarity(a, b string) float64 {
+	return defaultFolding.compare(a, b)
+}
+
+// compare is Similarity with text folded as f selects.
+func (f folding) compare(a, b string) float64 {
+	return similarity(f.normalize(a), f.normalize(b))
+}
+
+// similarity returns how similar two normalized strings are (0.0-1.0).
+// Tokens are aligned fuzzily (Jaro-Winkler per token), stopwords count for
+// little, and the score is slightly reduced when matched tokens appear in a
+// different order. Compact (no-space) equality short-circuits to 1.0 to handle
+// cases like "theweeknd" vs "the weeknd".
+func similarity(a, b string) float64 {
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+
+	compactA := strings.ReplaceAll(a, " ", "")
+	compactB := strings.ReplaceAll(b, " ", "")
+	if compactA == compactB {
+		return 1.0
+	}
+
+	tokensA := tokenize(a)
+	tokensB := tokenize(b)
+
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0.0
+	}
+
+	pairs := alignTokens(tokensA, tokensB)
+
+	var matched, total float64
+	for _, p := range pairs {
+		matched += p.sim * (tokenWeight(tokensA[p.i]) + tokenWeight(tokensB[p.j]))
+	}
+	for _, t := range tokensA {
+		total += tokenWeight(t)
+	}
+	for _, t := range tokensB {
+		total += tokenWeight(t)
+	}
+
+	// Order accounts for 10% so "lights blinding" still scores high but below
+	// the correctly ordered title.
+	s := matched / total * (0.9 + 0.1*orderScore(pairs))
+
+	// "Song 2" vs "Song 3": differing numbers on both sides are a strong signal
+	// of a different song, not a typo.
+	if hasUnmatchedNumber(tokensA, pairs, true) && hasUnmatchedNumber(tokensB, pairs, false) {
+		s *= conflictingNumberPenalty
+	}
+	return s
+}
+
+// conflictingNumberPenalty is applied when both strings contain numbers that
+// could not be aligned with each other.
+const conflictingNumberPenalty = 0.5
+
+// hasUnmatchedNumber reports whether tokens contains a numeric token not used
+// by any pair. left selects which side of the pairs tokens belongs to.
+func hasUnmatchedNumber(tokens []string, pairs []tokenPair, left bool) bool {
+	used := make(map[int]bool, len(pairs))
+	for _, p := range pairs {
+		if left {
+			used[p.i] = true
+		} else {
+			used[p.j] = true
+		}
+	}
+	for i, t := range tokens {
+		if !used[i] && hasDigit(t) {
+			return true
+		}
+	}
+	return false
+}
+
+type tokenPair struct {
+	i, j int
+	sim  float64
+}
+
+// alignTokens pairs tokens of a with tokens of b, best matches first. Each token
+// is used at most once; pairs below tokenMatchThreshold are dropped.
+func alignTokens(a, b []string) []tokenPair {
+	var candidates []tokenPair
+	for i, ta := range a {
+		for j, tb := range b {
+			if s := tokenSimilarity(ta, tb); s >= tokenMatchThreshold {
+				candidates = append(candidates, tokenPair{i: i, j: j, sim: s})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(x, y int) bool {
+		if candidates[x].sim != candidates[y].sim {
+			return candidates[x].sim > candidates[y].sim
+		}
+		return absInt(candidates[x].i-candidates[x].j) < absInt(candidates[y].i-candidates[y].j)
+	})
+
+	usedA := make(map[int]bool)
+	usedB := make(map[int]bool)
+	var pairs []tokenPair
+	for _, c := range candidates {
+		if usedA[c.i] || usedB[c.j] {
+			continue
+		}
+		usedA[c.i] = true
+		usedB[c.j] = true
+		pairs = append(pairs, c)
+	}
+	return pairs
+}
+
+// orderScore returns the fraction of aligned pairs that appear in the same
+// relative order in both strings (longest increasing subsequence).
+func orderScore(pairs []tokenPair) float64 {
+	if len(pairs) < 2 {
+		return 1.0
+	}
+	sorted := make([]tokenPair, len(pairs))
+	copy(sorted, pairs)
+	sort.Slice(sorted, func(x, y int) bool { return sorted[x].i < sorted[y].i })
+
+	// O(n²) LIS is fine for title-sized inputs.
+	lis := make([]int, len(sorted))
+	longest := 0
+	for x := range sorted {
+		lis[x] = 1
+		for y := 0; y < x; y++ {
+			if sorted[y].j < sorted[x].j && lis[y]+1 > lis[x] {
+				lis[x] = lis[y] + 1
+			}
+		}
+		if lis[x] > longest {
+			longest = lis[x]
+		}
+	}
+	return float64(longest) / float64(len(sorted))
+}
+
+func tokenWeight(t string) float64 {
+	if stopwords[t] {
+		return stopwordWeight
+	}
+	return 1.0
+}
+
+// tokenSimilarity compares two tokens. Short tokens and tokens containing digits
+// must match exactly: "2" vs "3" or "1999" vs "1998" are different songs.
+func tokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1.0
+	}
+	if len([]rune(a)) < 4 || len([]rune(b)) < 4 || hasDigit(a) || hasDigit(b) {
+		return 0.0
+	}
+	return jaroWinkler(a, b)
+}
+
+func hasDigit(s string) bool {
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b (0.0-1.0).
+func jaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0.0
+	}
+
+	window := len(ra)
+	if len(rb) > window {
+		window = len(rb)
+	}
+	window = window/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo := i - window
+		if lo < 0 {
+			lo = 0
+		}
+		hi := i + window + 1
+		if hi > len(rb) {
+			hi = len(rb)
+		}
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i] = true
+				matchedB[j] = true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0.0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// normalize folds Unicode variants (see FoldText) and strips non-alphanumeric
+// characters for comparison.
+func (f folding) normalize(s string) string {
+	var b strings.Builder
+	for _, r := range f.fold(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokenize splits a string into lowercase tokens.
+func tokenize(s string) []string {
+	fields := strings.Fields(s)
+	var result []string
+	for _, f := range fields {
+		if f != "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import "testing"
+
+func TestSimilarity_Fuzzy(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		wantAbove float64
+		wantBelow float64
+	}{
+		{name: "single typo", a: "blinding ligths", b: "blinding lights", wantAbove: 0.9},
+		{name: "missing article", a: "beatles", b: "the beatles", wantAbove: 0.85},
+		{name: "apostrophe dropped", a: "Don't Stop Me Now", b: "Dont Stop Me Now", wantAbove: 0.99},
+		{name: "reordered tokens", a: "lights blinding", b: "blinding lights", wantAbove: 0.85, wantBelow: 0.99},
+		{name: "different number", a: "Song 2", b: "Song 3", wantBelow: 0.6},
+		{name: "different year", a: "1999", b: "1998", wantBelow: 0.01},
+		{name: "unrelated", a: "blinding lights", b: "bohemian rhapsody", wantBelow: 0.1},
+		{name: "extra word", a: "hotel california", b: "hotel california live", wantAbove: 0.75, wantBelow: 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Similarity(tt.a, tt.b)
+			if tt.wantAbove > 0 && got < tt.wantAbove {
+				t.Errorf("Similarity(%q, %q) = %.4f, want above %.4f", tt.a, tt.b, got, tt.wantAbove)
+			}
+			if tt.wantBelow > 0 && got > tt.wantBelow {
+				t.Errorf("Similarity(%q, %q) = %.4f, want below %.4f", tt.a, tt.b, got, tt.wantBelow)
+			}
+		})
+	}
+}
+
+func TestSimilarity_Symmetric(t *testing.T) {
+	pairs := [][2]string{
+		{"the weeknd", "weeknd"},
+		{"blinding ligths", "blinding lights"},
+		{"smells like teen spirit", "teen spirit"},
+	}
+	for _, p := range pairs {
+		if a, b := Similarity(p[0], p[1]), Similarity(p[1], p[0]); a != b {
+			t.Errorf("Similarity not symmetric for %q/%q: %.4f vs %.4f", p[0], p[1], a, b)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.84},
+		{"abc", "xyz", 0.0},
+		{"same", "same", 1.0},
+	}
+	for _, tt := range tests {
+		got := jaroWinkler(tt.a, tt.b)
+		if diff := got - tt.want; diff > 0.001 || diff < -0.001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// youtubeCorpus is a regression corpus of real YouTube titles/channels (as
+// written by yt-dlp) paired with provider results. match reports whether the
+// candidate should clear the default confidence threshold.
+var youtubeCorpus = []struct {
+	ytTitle, ytArtist string
+	title, artist     string
+	match             bool
+}{
+	{"The Weeknd - Blinding Lights (Official Video)", "TheWeekndVEVO", "Blinding Lights", "The Weeknd", true},
+	{"Queen – Bohemian Rhapsody (Official Video Remastered)", "Queen Official", "Bohemian Rhapsody", "Queen", true},
+	{"Beyonce - Halo", "beyonceVEVO", "Halo", "Beyoncé", true},
+	{"Daft Punk - Get Lucky (Official Audio) (feat. Pharrell Williams)", "", "Get Lucky", "Daft Punk", true},
+	{"Nirvana - Smells Like Teen Spirit (Official Music Video)", "NirvanaVEVO", "Smells Like Teen Spirit", "Nirvana", true},
+	{"Don't Stop Me Now (Remastered 2011)", "Queen", "Don't Stop Me Now - Remastered 2011", "Queen", true},
+	{"Beatles - Here Comes The Sun", "", "Here Comes The Sun - Remastered 2009", "The Beatles", true},
+	{"Marracash - Crazy Love (Visual)", "", "CRAZY LOVE", "Marracash", true},
+	{"Кино - Группа крови", "", "Gruppa krovi", "Kino", true},
+	{"Blur - Song 2", "", "Song 2", "Blur", true},
+	{"Blur - Song 2", "", "Song 3", "Blur", false},
+	{"Oasis - Wonderwall", "", "Wonderwall - Live at Knebworth", "Oasis", false},
+	{"Wonderwall (Live at Knebworth)", "Oasis", "Wonderwall - Live", "Oasis", true},
+	{"Coldplay - Yellow", "", "Yellow Submarine", "The Beatles", false},
+	{"Eagles - Hotel California", "", "Hotel California Dreaming", "Various Artists", false},
+	{"Rick Astley - Never Gonna Give You Up (Official Music Video)", "Rick Astley", "Never Gonna Give You Up", "Rick Astley", true},
+	{"Linkin Park - In The End [Official Music Video]", "Linkin Park", "In the End", "Linkin Park", true},
+	{"Mötley Crüe - Kickstart My Heart", "", "Kickstart My Heart", "Motley Crue", true},
+}
+
+func TestScore_YouTubeCorpus(t *testing.T) {
+	for _, tt := range youtubeCorpus {
+		t.Run(tt.ytTitle, func(t *testing.T) {
+			query := NormalizeQuery(tt.ytTitle, tt.ytArtist)
+			got := defaultFolding.score(query, TrackInfo{Title: tt.title, Artist: tt.artist})
+			if matched := got >= defaultConfidenceThreshold; matched != tt.match {
+				t.Errorf("score(%q by %q → %q by %q) = %.4f, want match=%v",
+					query.Title, query.Artist, tt.title, tt.artist, got, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package metadata
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortNames holds the sort-order variants of a track's names, written as
+// ARTISTSORT, ALBUMARTISTSORT, ALBUMSORT and TITLESORT.
+type SortNames struct {
+	Artist      string `json:"artist,omitempty"`
+	AlbumArtist string `json:"album_artist,omitempty"`
+	Album       string `json:"album,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// SortName returns the sort form of a name that has no provider-supplied
+// one: kana, hangul and Cyrillic are romanized and a leading "The" moves to
+// the end, so "The Beatles" sorts as "Beatles, The" and "Кино" as "Kino".
+func SortName(name string) string {
+	return defaultFolding.sortName(name)
+}
+
+// sortName is SortName with transliteration as f selects.
+func (f folding) sortName(name string) string {
+	name = strings.TrimSpace(name)
+	if f.transliterate {
+		if lower := strings.ToLower(name); transliterate(lower) != lower {
+			name = capitalizeWords(transliterate(lower))
+		}
+	}
+	if rest, ok := cutPrefixFold(name, "The "); ok && rest != "" {
+		return rest + ", " + name[:3]
+	}
+	return name
+}
+
+// sortCredits joins the credits' sort names with their join phrases, using
+// SortName for credits without one.
+func (f folding) sortCredits(credits []ArtistCredit) string {
+	sorted := make([]ArtistCredit, len(credits))
+	for i, c := range credits {
+		sorted[i] = c
+		if c.SortName != "" {
+			sorted[i].Name = c.SortName
+		} else {
+			sorted[i].Name = f.sortName(c.Name)
+		}
+	}
+	return JoinArtists(sorted)
+}
+
+// artistSort returns the sort form of info.Artist, built from the credits
+// that make up the displayed artist (all of them, or only the main artists
+// when featured artists moved to the title).
+func (f folding) artistSort(info TrackInfo) string {
+	credits := info.Artists
+	for n := len(credits); n > 0; n-- {
+		shown := append([]ArtistCredit{}, credits[:n]...)
+		shown[n-1].JoinPhrase = ""
+		if JoinArtists(shown) == info.Artist {
+			return f.sortCredits(shown)
+		}
+	}
+	return f.sortName(info.Artist)
+}
+
+// fillSortNames sets the artist sort name from the credits and completes the
+// other sort names the provider left empty with SortName fallbacks.
+func (f folding) fillSortNames(info TrackInfo) TrackInfo {
+	if info.Artist != "" {
+		info.Sort.Artist = f.artistSort(info)
+	}
+	fill := func(dst *string, name string) {
+		if *dst == "" && name != "" {
+			*dst = f.sortName(name)
+		}
+	}
+	fill(&info.Sort.AlbumArtist, info.AlbumArtist)
+	fill(&info.Sort.Album, info.Album)
+	fill(&info.Sort.Title, info.Title)
+	return info
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
+
+// capitalizeWords upper-cases the first letter of each space-separated word.
+func capitalizeWords(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
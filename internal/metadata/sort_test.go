@@ -0,0 +1,64 @@
+package metadata
+
+import "testing"
+
+func TestSortName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"The Beatles", "Beatles, The"},
+		{"the national", "national, the"},
+		{"Theo Katzman", "Theo Katzman"},
+		{"The", "The"},
+		{"Radiohead", "Radiohead"},
+		{"Кино", "Kino"},
+		{"ヨルシカ", "Yorushika"},
+		{"米津玄師", "米津玄師"}, // kanji is not romanized
+	}
+	for _, tt := range tests {
+		if got := SortName(tt.name); got != tt.want {
+			t.Errorf("SortName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFillSortNames(t *testing.T) {
+	credits := []ArtistCredit{
+		{Name: "The Beatles", JoinPhrase: " feat. ", SortName: "Beatles, The"},
+		{Name: "Billy Preston"},
+	}
+	tests := []struct {
+		name string
+		info TrackInfo
+		want SortNames
+	}{
+		{
+			name: "credits with featured artist",
+			info: TrackInfo{Artist: "The Beatles feat. Billy Preston", Artists: credits, Title: "Get Back", Album: "Let It Be"},
+			want: SortNames{Artist: "Beatles, The feat. Billy Preston", Title: "Get Back", Album: "Let It Be"},
+		},
+		{
+			name: "featured artist moved to the title",
+			info: TrackInfo{Artist: "The Beatles", Artists: credits, Title: "Get Back (feat. Billy Preston)"},
+			want: SortNames{Artist: "Beatles, The", Title: "Get Back (feat. Billy Preston)"},
+		},
+		{
+			name: "provider album artist sort kept",
+			info: TrackInfo{Artist: "The Beatles", AlbumArtist: "米津玄師", Sort: SortNames{AlbumArtist: "Yonezu, Kenshi"}},
+			want: SortNames{Artist: "Beatles, The", AlbumArtist: "Yonezu, Kenshi"},
+		},
+		{
+			name: "fallbacks without credits",
+			info: TrackInfo{Artist: "The National", AlbumArtist: "The National", Album: "The National", Title: "The System Only Dreams in Total Darkness"},
+			want: SortNames{Artist: "National, The", AlbumArtist: "National, The", Album: "National, The", Title: "System Only Dreams in Total Darkness, The"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultFolding.fillSortNames(tt.info).Sort; got != tt.want {
+				t.Errorf("Sort = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,21 @@ package metadata
 
 import (
 	"fmt"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"go.senan.xyz/taglib"
 )
 
-// WriteTags writes the given TrackInfo metadata to an audio file.
-func WriteTags(path string, info TrackInfo) error {
+// Release totals. taglib has no constants for them; these are the names
+// Picard and most players read.
+const (
+	TotalTracksTag = "TOTALTRACKS"
+	TotalDiscsTag  = "TOTALDISCS"
+)
+
+// tagMap converts the non-empty fields of info to tags.
+func tagMap(info TrackInfo) map[string][]string {
 	tags := make(map[string][]string)
 
 	if info.Title != "" {
@@ -19,61 +25,125 @@ func WriteTags(path string, info TrackInfo) error {
 	if info.Artist != "" {
 		tags[taglib.Artist] = []string{info.Artist}
 	}
+	if len(info.Artists) > 0 {
+		tags[taglib.Artists] = ArtistNames(info.Artists)
+	}
 	if info.Album != "" {
 		tags[taglib.Album] = []string{info.Album}
 	}
+	for key, v := range map[string]string{
+		taglib.ArtistSort:      info.Sort.Artist,
+		taglib.AlbumArtistSort: info.Sort.AlbumArtist,
+		taglib.AlbumSort:       info.Sort.Album,
+		taglib.TitleSort:       info.Sort.Title,
+	} {
+		if v != "" {
+			tags[key] = []string{v}
+		}
+	}
 	if info.AlbumArtist != "" {
 		tags[taglib.AlbumArtist] = []string{info.AlbumArtist}
 	}
 	if info.TrackNumber > 0 {
 		tags[taglib.TrackNumber] = []string{strconv.Itoa(info.TrackNumber)}
 	}
+	if info.TotalTracks > 0 {
+		tags[TotalTracksTag] = []string{strconv.Itoa(info.TotalTracks)}
+	}
 	if info.DiscNumber > 0 {
 		tags[taglib.DiscNumber] = []string{strconv.Itoa(info.DiscNumber)}
 	}
+	if info.TotalDiscs > 0 {
+		tags[TotalDiscsTag] = []string{strconv.Itoa(info.TotalDiscs)}
+	}
+	if info.Compilation || IsVariousArtists(info.AlbumArtist) {
+		tags[taglib.Compilation] = []string{"1"}
+	}
 	if info.ReleaseDate != "" {
 		tags[taglib.Date] = []string{info.ReleaseDate}
 	} else if info.Year > 0 {
 		tags[taglib.Date] = []string{strconv.Itoa(info.Year)}
 	}
-	if info.Genre != "" {
+	if len(info.Genres) > 0 {
+		tags[taglib.Genre] = info.Genres
+	} else if info.Genre != "" {
 		tags[taglib.Genre] = []string{info.Genre}
 	}
 	if info.ISRC != "" {
 		tags[taglib.ISRC] = []string{info.ISRC}
 	}
-
-	if err := taglib.WriteTags(path, tags, 0); err != nil {
-		return fmt.Errorf("failed to write tags to %s: %w", path, err)
+	for k, v := range releaseTags(info.Release) {
+		tags[k] = v
 	}
-	return nil
+	for k, v := range creditTags(info.Credits) {
+		tags[k] = v
+	}
+	for k, v := range musicBrainzTags(info.MusicBrainz) {
+		tags[k] = v
+	}
+	return tags
 }
 
-// SubDirFromTags reads an audio file's tags and returns an "Artist/Album"
-// subdirectory path for organizing files. Returns "" if tags can't be read.
-func SubDirFromTags(path string) string {
-	tags, err := taglib.ReadTags(path)
-	if err != nil {
-		return ""
+// releaseTags maps the non-empty release details to their standard tags.
+func releaseTags(rel ReleaseInfo) map[string][]string {
+	tags := make(map[string][]string)
+	for key, v := range map[string]string{
+		taglib.Label:          rel.Label,
+		taglib.CatalogNumber:  rel.CatalogNumber,
+		taglib.Barcode:        rel.Barcode,
+		taglib.ReleaseCountry: rel.Country,
+		taglib.Media:          rel.Media,
+		taglib.OriginalDate:   rel.OriginalDate,
+	} {
+		if v != "" {
+			tags[key] = []string{v}
+		}
+	}
+	if len(rel.Types) > 0 {
+		tags[taglib.ReleaseType] = rel.Types
 	}
+	return tags
+}
 
-	artist := firstTag(tags, taglib.AlbumArtist)
-	if artist == "" || strings.EqualFold(artist, "Various Artists") {
-		artist = firstTag(tags, taglib.Artist)
-		if i := strings.Index(artist, ","); i > 0 {
-			artist = strings.TrimSpace(artist[:i])
+// creditTags maps the credited people to multi-valued tags.
+func creditTags(c Credits) map[string][]string {
+	tags := make(map[string][]string)
+	for key, names := range map[string][]string{
+		taglib.Composer:  c.Composers,
+		taglib.Lyricist:  c.Lyricists,
+		taglib.Arranger:  c.Arrangers,
+		taglib.Performer: c.Performers,
+	} {
+		if len(names) > 0 {
+			tags[key] = names
 		}
 	}
-	album := firstTag(tags, taglib.Album)
+	return tags
+}
 
-	if artist == "" {
-		artist = "Unknown Artist"
+// musicBrainzTags maps the non-empty MusicBrainz identifiers to the standard
+// MUSICBRAINZ_* tags read by Picard, beets and Navidrome.
+func musicBrainzTags(ids MusicBrainzIDs) map[string][]string {
+	tags := make(map[string][]string)
+	if ids.RecordingID != "" {
+		tags[taglib.MusicBrainzTrackID] = []string{ids.RecordingID}
 	}
-	if album == "" {
-		album = "Unknown Album"
+	if ids.TrackID != "" {
+		tags[taglib.MusicBrainzReleaseTrackID] = []string{ids.TrackID}
 	}
-
-	return filepath.Join(sanitizePath(artist), sanitizePath(album))
+	if ids.ReleaseID != "" {
+		tags[taglib.MusicBrainzAlbumID] = []string{ids.ReleaseID}
+	}
+	if ids.ReleaseGroupID != "" {
+		tags[taglib.MusicBrainzReleaseGroupID] = []string{ids.ReleaseGroupID}
+	}
+	if len(ids.ArtistIDs) > 0 {
+		tags[taglib.MusicBrainzArtistID] = ids.ArtistIDs
+	}
+	if len(ids.AlbumArtistIDs) > 0 {
+		tags[taglib.MusicBrainzAlbumArtistID] = ids.AlbumArtistIDs
+	}
+	return tags
 }
 
 // sanitizePath removes or replaces characters that are problematic in file paths.
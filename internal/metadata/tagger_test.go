@@ -27,6 +27,11 @@ func createTestAudioFile(t *testing.T, dir string) string {
 	return path
 }
 
+// writeInfo writes the tags of info to path the way the resolver does.
+func writeInfo(path string, info TrackInfo) error {
+	return taglib.WriteTags(path, tagMap(info), 0)
+}
+
 func TestWriteTags(t *testing.T) {
 	dir := t.TempDir()
 	path := createTestAudioFile(t, dir)
@@ -42,8 +47,8 @@ func TestWriteTags(t *testing.T) {
 		Genre:       "Pop",
 	}
 
-	if err := WriteTags(path, info); err != nil {
-		t.Fatalf("WriteTags failed: %v", err)
+	if err := writeInfo(path, info); err != nil {
+		t.Fatalf("writing tags failed: %v", err)
 	}
 
 	// Verify written tags
@@ -74,6 +79,47 @@ func TestWriteTags(t *testing.T) {
 	}
 }
 
+func TestWriteTags_MusicBrainzIDs(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestAudioFile(t, dir)
+
+	info := TrackInfo{
+		Title: "Bohemian Rhapsody",
+		MusicBrainz: MusicBrainzIDs{
+			RecordingID:    "rec-1",
+			TrackID:        "trk-1",
+			ReleaseID:      "rel-1",
+			ReleaseGroupID: "rg-1",
+			ArtistIDs:      []string{"a1", "a2"},
+			AlbumArtistIDs: []string{"a1"},
+		},
+	}
+	if err := writeInfo(path, info); err != nil {
+		t.Fatalf("writing tags failed: %v", err)
+	}
+
+	tags, err := taglib.ReadTags(path)
+	if err != nil {
+		t.Fatalf("failed to read tags: %v", err)
+	}
+
+	checks := map[string]string{
+		taglib.MusicBrainzTrackID:        "rec-1",
+		taglib.MusicBrainzReleaseTrackID: "trk-1",
+		taglib.MusicBrainzAlbumID:        "rel-1",
+		taglib.MusicBrainzReleaseGroupID: "rg-1",
+		taglib.MusicBrainzAlbumArtistID:  "a1",
+	}
+	for key, want := range checks {
+		if got := firstTag(tags, key); got != want {
+			t.Errorf("tag %s = %q, want %q", key, got, want)
+		}
+	}
+	if got := tags[taglib.MusicBrainzArtistID]; len(got) != 2 || got[1] != "a2" {
+		t.Errorf("tag %s = %v, want [a1 a2]", taglib.MusicBrainzArtistID, got)
+	}
+}
+
 func TestWriteArtwork(t *testing.T) {
 	dir := t.TempDir()
 	path := createTestAudioFile(t, dir)
@@ -104,20 +150,13 @@ func TestWriteArtworkEmpty(t *testing.T) {
 	}
 }
 
-func TestWriteTagsNonexistentFile(t *testing.T) {
-	err := WriteTags("/nonexistent/file.mp3", TrackInfo{Title: "x"})
-	if err == nil {
-		t.Error("expected error for nonexistent file")
-	}
-}
-
 func TestWriteTagsEmptyInfo(t *testing.T) {
 	dir := t.TempDir()
 	path := createTestAudioFile(t, dir)
 
 	// Writing empty info should not error (just writes nothing)
-	if err := WriteTags(path, TrackInfo{}); err != nil {
-		t.Fatalf("WriteTags with empty info failed: %v", err)
+	if err := writeInfo(path, TrackInfo{}); err != nil {
+		t.Fatalf("writing empty info failed: %v", err)
 	}
 
 	// Verify file still readable
@@ -125,3 +164,64 @@ func TestWriteTagsEmptyInfo(t *testing.T) {
 		t.Fatalf("file missing after empty write: %v", err)
 	}
 }
+
+func TestTagMap_TotalsAndCompilation(t *testing.T) {
+	tests := []struct {
+		name string
+		info TrackInfo
+		want map[string]string
+	}{
+		{
+			name: "totals",
+			info: TrackInfo{TrackNumber: 3, TotalTracks: 12, DiscNumber: 2, TotalDiscs: 2},
+			want: map[string]string{TotalTracksTag: "12", TotalDiscsTag: "2", taglib.Compilation: ""},
+		},
+		{
+			name: "compilation flag",
+			info: TrackInfo{Compilation: true},
+			want: map[string]string{taglib.Compilation: "1"},
+		},
+		{
+			name: "various artists album artist",
+			info: TrackInfo{AlbumArtist: "various artists"},
+			want: map[string]string{taglib.Compilation: "1"},
+		},
+		{
+			name: "release details",
+			info: TrackInfo{Release: ReleaseInfo{
+				Label: "Columbia", CatalogNumber: "88985 44896 2", Barcode: "889854489621",
+				Country: "US", Types: []string{"album", "live"}, Media: "CD", OriginalDate: "1997-05-21",
+			}},
+			want: map[string]string{
+				taglib.Label: "Columbia", taglib.CatalogNumber: "88985 44896 2", taglib.Barcode: "889854489621",
+				taglib.ReleaseCountry: "US", taglib.ReleaseType: "album", taglib.Media: "CD", taglib.OriginalDate: "1997-05-21",
+			},
+		},
+		{
+			name: "credits",
+			info: TrackInfo{Credits: Credits{
+				Composers: []string{"Paul McCartney", "John Lennon"}, Lyricists: []string{"Paul McCartney"},
+				Arrangers: []string{"George Martin"}, Performers: []string{"Paul McCartney (lead vocals)"},
+			}},
+			want: map[string]string{
+				taglib.Composer: "Paul McCartney", taglib.Lyricist: "Paul McCartney",
+				taglib.Arranger: "George Martin", taglib.Performer: "Paul McCartney (lead vocals)",
+			},
+		},
+		{
+			name: "unknown totals omitted",
+			info: TrackInfo{TrackNumber: 3},
+			want: map[string]string{TotalTracksTag: "", TotalDiscsTag: ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := tagMap(tt.info)
+			for k, want := range tt.want {
+				if got := firstTag(tags, k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
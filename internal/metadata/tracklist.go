@@ -0,0 +1,287 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.senan.xyz/taglib"
+)
+
+// maxAlbumCandidates is how many releases an AlbumCandidates resolver is
+// asked for in the album-first phase.
+const maxAlbumCandidates = 5
+
+// durationTolerance is how far a file's length may be from a track's and
+// still fully agree; encoders and trimmed silence shift lengths by a second
+// or two.
+const durationTolerance = 3 * time.Second
+
+// defaultAlbumAgreement is the share of the album sources listing a track
+// that must give it the same position before the position is written.
+const defaultAlbumAgreement = 0.6
+
+// groupFile is a file of an album group as the album-first phase sees it.
+type groupFile struct {
+	path     string
+	title    string
+	duration time.Duration // 0 if unknown
+}
+
+// readGroup returns the files of a group that have a title, with their
+// audio lengths.
+func (r *Resolver) readGroup(files []string) []groupFile {
+	var out []groupFile
+	for _, path := range files {
+		tags, err := r.readTags(path)
+		if err != nil {
+			continue
+		}
+		title := firstTag(tags, taglib.Title)
+		if title == "" {
+			continue
+		}
+		out = append(out, groupFile{path: path, title: title, duration: fileDuration(path)})
+	}
+	return out
+}
+
+// albumCandidates returns the tracklists ar offers for album: several
+// releases when it implements AlbumCandidates, otherwise its single pick.
+func (r *Resolver) albumCandidates(ctx context.Context, ar AlbumResolver, album, artist string) ([]Tracklist, error) {
+	if ac, ok := ar.(AlbumCandidates); ok {
+		return ac.ResolveAlbumCandidates(ctx, album, artist, maxAlbumCandidates)
+	}
+	tl, found, err := ar.ResolveAlbum(ctx, album, artist)
+	if err != nil || !found {
+		return nil, err
+	}
+	return []Tracklist{tl}, nil
+}
+
+// pickTracklist returns the candidate that best fits files, out of an album
+// group of groupSize files, and its fit. Ties go to the earlier candidate,
+// which the resolver ranked higher.
+func (f folding) pickTracklist(candidates []Tracklist, files []groupFile, groupSize int) (Tracklist, float64, bool) {
+	var best Tracklist
+	bestFit, found := -1.0, false
+	for _, tl := range candidates {
+		if len(tl.Tracks) == 0 {
+			continue
+		}
+		if fit := f.tracklistFit(tl, files, groupSize); fit > bestFit {
+			best, bestFit, found = tl, fit, true
+		}
+	}
+	return best, bestFit, found
+}
+
+// tracklistFit scores (0.0-1.0) how well tl fits the files of a group: how
+// well their titles match its tracks, how closely the matched tracks' lengths
+// agree with the files', and how close its track count is to groupSize, the
+// number of files in the whole album group. files may be only part of the
+// group (the rest positioned by fingerprint); the titles and lengths are
+// matched for them alone. Lengths are left out when neither side has them.
+func (f folding) tracklistFit(tl Tracklist, files []groupFile, groupSize int) float64 {
+	if len(tl.Tracks) == 0 || len(files) == 0 {
+		return 0
+	}
+
+	var titleSum, durationSum float64
+	var timed int
+	for _, file := range files {
+		track, s := f.matchTrack(file, tl.Tracks)
+		if s < trackMatchThreshold {
+			continue
+		}
+		titleSum += s
+		if fit, ok := durationFit(file.duration, track.Duration); ok {
+			durationSum += fit
+			timed++
+		}
+	}
+	titleFit := titleSum / float64(len(files))
+	n, m := max(groupSize, len(files)), len(tl.Tracks)
+	countFit := float64(min(n, m)) / float64(max(n, m))
+
+	if timed == 0 {
+		return titleFit*0.7 + countFit*0.3
+	}
+	return titleFit*0.5 + durationSum/float64(timed)*0.3 + countFit*0.2
+}
+
+// matchesAny reports whether any file's title matches a track of tl.
+func (f folding) matchesAny(tl Tracklist, files []groupFile) bool {
+	for _, file := range files {
+		if _, s := f.matchTrackByTitle(file.title, tl.Tracks); s >= trackMatchThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTrack finds the track whose title best matches the file's, preferring
+// the track closest in length among equally good titles (a bonus track that
+// repeats a title, an edit next to the album version).
+func (f folding) matchTrack(file groupFile, tracks []ReleaseTrack) (ReleaseTrack, float64) {
+	best, bestScore := f.matchTrackByTitle(file.title, tracks)
+	if file.duration == 0 {
+		return best, bestScore
+	}
+	title := f.normalize(file.title)
+	for _, t := range tracks {
+		if similarity(title, f.normalize(t.Title)) != bestScore || t.Duration == 0 {
+			continue
+		}
+		if best.Duration == 0 || absDuration(file.duration-t.Duration) < absDuration(file.duration-best.Duration) {
+			best = t
+		}
+	}
+	return best, bestScore
+}
+
+// durationFit scores how well a file's length agrees with a track's: 1.0
+// within durationTolerance, falling to 0 at four times that. It reports
+// false when either length is unknown.
+func durationFit(file, track time.Duration) (float64, bool) {
+	if file <= 0 || track <= 0 {
+		return 0, false
+	}
+	diff := absDuration(file - track)
+	switch {
+	case diff <= durationTolerance:
+		return 1, true
+	case diff >= 4*durationTolerance:
+		return 0, true
+	}
+	return 1 - float64(diff-durationTolerance)/float64(3*durationTolerance), true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// albumSource is the tracklist one album resolver chose for a group.
+type albumSource struct {
+	name string
+	tl   Tracklist
+}
+
+// sourceName names an album resolver in logs.
+func sourceName(ar AlbumResolver) string {
+	if p, ok := ar.(Provider); ok {
+		return p.Name()
+	}
+	return "album resolver"
+}
+
+// consensusTrack is a track of a consensus tracklist.
+type consensusTrack struct {
+	ReleaseTrack
+	votes     int      // sources giving the track this position
+	agreement float64  // share of the sources listing the track that give it this position
+	positions []string // "source disc-track" of every source listing it, for logs
+}
+
+// consensus aligns the tracklists several sources give for one album by
+// title and places each track where most of the sources listing it do; ties
+// go to the earlier source. A position claimed by two tracks stays with the
+// one more sources agree on, and the other's agreement drops to 0. The
+// returned tracklist has the first source's release details.
+func (f folding) consensus(sources []albumSource) (Tracklist, []consensusTrack) {
+	type entry struct {
+		track     ReleaseTrack
+		votes     map[[2]int]int
+		order     [][2]int // positions in the order sources gave them
+		positions []string
+		listed    int
+	}
+
+	var entries []*entry
+	for _, src := range sources {
+		claimed := make(map[*entry]bool)
+		for _, t := range src.tl.Tracks {
+			pos := [2]int{max(t.DiscNumber, 1), t.TrackNumber}
+			var match *entry
+			bestScore := trackMatchThreshold
+			for _, e := range entries {
+				if claimed[e] {
+					continue
+				}
+				if s := f.compare(t.Title, e.track.Title); s >= bestScore {
+					match, bestScore = e, s
+				}
+			}
+			if match == nil {
+				match = &entry{track: t, votes: make(map[[2]int]int)}
+				entries = append(entries, match)
+			}
+			claimed[match] = true
+			if match.votes[pos] == 0 {
+				match.order = append(match.order, pos)
+			}
+			match.votes[pos]++
+			match.listed++
+			match.positions = append(match.positions, fmt.Sprintf("%s %d-%d", src.name, pos[0], pos[1]))
+			if match.track.MBID == "" {
+				match.track.MBID, match.track.TrackID = t.MBID, t.TrackID
+			}
+			if match.track.Duration == 0 {
+				match.track.Duration = t.Duration
+			}
+		}
+	}
+
+	tracks := make([]consensusTrack, 0, len(entries))
+	for _, e := range entries {
+		best := e.order[0]
+		for _, pos := range e.order[1:] {
+			if e.votes[pos] > e.votes[best] {
+				best = pos
+			}
+		}
+		ct := consensusTrack{
+			ReleaseTrack: e.track,
+			votes:        e.votes[best],
+			agreement:    float64(e.votes[best]) / float64(e.listed),
+			positions:    e.positions,
+		}
+		if best != e.order[0] {
+			ct.DiscNumber, ct.TrackNumber = best[0], best[1]
+		}
+		tracks = append(tracks, ct)
+	}
+
+	holder := make(map[[2]int]int) // position → index of the track keeping it
+	for i, ct := range tracks {
+		pos := [2]int{max(ct.DiscNumber, 1), ct.TrackNumber}
+		j, taken := holder[pos]
+		switch {
+		case !taken:
+			holder[pos] = i
+		case ct.votes > tracks[j].votes:
+			tracks[j].agreement = 0
+			holder[pos] = i
+		default:
+			tracks[i].agreement = 0
+		}
+	}
+
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if di, dj := max(tracks[i].DiscNumber, 1), max(tracks[j].DiscNumber, 1); di != dj {
+			return di < dj
+		}
+		return tracks[i].TrackNumber < tracks[j].TrackNumber
+	})
+
+	tl := sources[0].tl
+	tl.Tracks = make([]ReleaseTrack, len(tracks))
+	for i, ct := range tracks {
+		tl.Tracks[i] = ct.ReleaseTrack
+	}
+	return tl, tracks
+}
@@ -0,0 +1,213 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ytmusic/internal/logger"
+)
+
+func tracks(titles ...string) []ReleaseTrack {
+	out := make([]ReleaseTrack, len(titles))
+	for i, title := range titles {
+		out[i] = ReleaseTrack{TrackNumber: i + 1, DiscNumber: 1, Title: title}
+	}
+	return out
+}
+
+func TestPickTracklist_PrefersEditionMatchingTrackCount(t *testing.T) {
+	deluxe := Tracklist{ID: "deluxe", Tracks: tracks("Intro", "Bonus Demo", "Runaway", "Outro", "Live Session")}
+	standard := Tracklist{ID: "standard", Tracks: tracks("Intro", "Runaway", "Outro")}
+	files := []groupFile{{title: "Intro"}, {title: "Runaway"}, {title: "Outro"}}
+
+	tl, _, found := defaultFolding.pickTracklist([]Tracklist{deluxe, standard}, files, len(files))
+	if !found || tl.ID != "standard" {
+		t.Fatalf("picked %q, want standard", tl.ID)
+	}
+	if track, _ := defaultFolding.matchTrack(files[1], tl.Tracks); track.TrackNumber != 2 {
+		t.Errorf("Runaway → track %d, want 2", track.TrackNumber)
+	}
+}
+
+func TestPickTracklist_CountsWholeGroup(t *testing.T) {
+	// Phase A positioned ten of the twelve files; the two left over must
+	// not make a two-track single look like the better fit.
+	single := Tracklist{ID: "single", Tracks: tracks("Runaway", "Outro")}
+	album := Tracklist{ID: "album", Tracks: tracks("Intro", "Runaway", "Song 3", "Song 4", "Song 5", "Song 6",
+		"Song 7", "Song 8", "Song 9", "Song 10", "Song 11", "Outro")}
+	files := []groupFile{{title: "Runaway"}, {title: "Outro"}}
+
+	tl, _, found := defaultFolding.pickTracklist([]Tracklist{single, album}, files, 12)
+	if !found || tl.ID != "album" {
+		t.Errorf("picked %q, want album", tl.ID)
+	}
+}
+
+func TestPickTracklist_PrefersEditionMatchingDurations(t *testing.T) {
+	withLengths := func(id string, lengths ...time.Duration) Tracklist {
+		tl := Tracklist{ID: id, Tracks: tracks("Intro", "Runaway")}
+		for i := range tl.Tracks {
+			tl.Tracks[i].Duration = lengths[i]
+		}
+		return tl
+	}
+	original := withLengths("original", 90*time.Second, 4*time.Minute)
+	extended := withLengths("extended", 90*time.Second, 9*time.Minute)
+	files := []groupFile{
+		{title: "Intro", duration: 91 * time.Second},
+		{title: "Runaway", duration: 8*time.Minute + 58*time.Second},
+	}
+
+	tl, _, found := defaultFolding.pickTracklist([]Tracklist{original, extended}, files, len(files))
+	if !found || tl.ID != "extended" {
+		t.Errorf("picked %q, want extended", tl.ID)
+	}
+}
+
+func TestPickTracklist_TiesKeepResolverOrder(t *testing.T) {
+	a := Tracklist{ID: "a", Tracks: tracks("Intro")}
+	b := Tracklist{ID: "b", Tracks: tracks("Intro")}
+	tl, _, _ := defaultFolding.pickTracklist([]Tracklist{{ID: "empty"}, a, b}, []groupFile{{title: "Intro"}}, 1)
+	if tl.ID != "a" {
+		t.Errorf("picked %q, want a", tl.ID)
+	}
+	if _, _, found := defaultFolding.pickTracklist([]Tracklist{{ID: "empty"}}, []groupFile{{title: "Intro"}}, 1); found {
+		t.Error("expected no pick from empty tracklists")
+	}
+}
+
+func TestMatchTrack_RepeatedTitlePrefersCloserLength(t *testing.T) {
+	list := []ReleaseTrack{
+		{TrackNumber: 1, Title: "Runaway", Duration: 4 * time.Minute},
+		{TrackNumber: 9, Title: "Runaway", Duration: 9 * time.Minute},
+	}
+	track, score := defaultFolding.matchTrack(groupFile{title: "Runaway", duration: 9 * time.Minute}, list)
+	if track.TrackNumber != 9 || score != 1 {
+		t.Errorf("got track %d (score %.2f), want track 9", track.TrackNumber, score)
+	}
+	if track, _ := defaultFolding.matchTrack(groupFile{title: "Runaway"}, list); track.TrackNumber != 1 {
+		t.Errorf("without a file length got track %d, want the first", track.TrackNumber)
+	}
+}
+
+func TestDurationFit(t *testing.T) {
+	tests := []struct {
+		file, track time.Duration
+		want        float64
+		known       bool
+	}{
+		{0, time.Minute, 0, false},
+		{time.Minute, 0, 0, false},
+		{time.Minute, time.Minute + 2*time.Second, 1, true},
+		{time.Minute, time.Minute + 7500*time.Millisecond, 0.5, true},
+		{time.Minute, 2 * time.Minute, 0, true},
+	}
+	for _, tt := range tests {
+		got, known := durationFit(tt.file, tt.track)
+		if known != tt.known || got != tt.want {
+			t.Errorf("durationFit(%v, %v) = %v, %v; want %v, %v", tt.file, tt.track, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+type mockCandidatesResolver struct {
+	mockAlbumResolver
+	candidates []Tracklist
+	limit      int
+}
+
+func (m *mockCandidatesResolver) ResolveAlbumCandidates(_ context.Context, _, _ string, limit int) ([]Tracklist, error) {
+	m.limit = limit
+	return m.candidates, nil
+}
+
+func TestAlbumCandidates(t *testing.T) {
+	r := NewResolver(nil, logger.New(false), 0)
+
+	single := &mockAlbumResolver{found: true, tracklist: Tracklist{ID: "only"}}
+	got, err := r.albumCandidates(context.Background(), single, "LP!", "")
+	if err != nil || len(got) != 1 || got[0].ID != "only" {
+		t.Errorf("single resolver: got %+v, %v", got, err)
+	}
+
+	multi := &mockCandidatesResolver{candidates: []Tracklist{{ID: "a"}, {ID: "b"}}}
+	got, err = r.albumCandidates(context.Background(), multi, "LP!", "")
+	if err != nil || len(got) != 2 || multi.limit != maxAlbumCandidates {
+		t.Errorf("candidates resolver: got %+v, %v (limit %d)", got, err, multi.limit)
+	}
+
+	none := &mockAlbumResolver{found: false}
+	if got, err := r.albumCandidates(context.Background(), none, "LP!", ""); err != nil || len(got) != 0 {
+		t.Errorf("not found: got %+v, %v", got, err)
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	mb := albumSource{name: "musicbrainz", tl: Tracklist{ID: "mb", Title: "Album", Tracks: []ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Intro", MBID: "rec-1"},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Runaway", MBID: "rec-2"},
+		{TrackNumber: 3, DiscNumber: 1, Title: "Hidden Track", MBID: "rec-3"},
+	}}}
+	spotify := albumSource{name: "spotify", tl: Tracklist{ID: "sp", Tracks: []ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Intro", Duration: time.Minute},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Runaway - Remastered"},
+		{TrackNumber: 3, DiscNumber: 1, Title: "Bonus"},
+	}}}
+	deezer := albumSource{name: "deezer", tl: Tracklist{ID: "dz", Tracks: []ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Intro"},
+		{TrackNumber: 3, DiscNumber: 1, Title: "Runaway"},
+	}}}
+
+	tl, tracks := defaultFolding.consensus([]albumSource{mb, spotify, deezer})
+	if tl.ID != "mb" || tl.Title != "Album" {
+		t.Errorf("release details from %q, want the first source", tl.ID)
+	}
+	if len(tl.Tracks) != len(tracks) {
+		t.Fatalf("%d tracks, %d consensus tracks", len(tl.Tracks), len(tracks))
+	}
+
+	byTitle := make(map[string]consensusTrack)
+	for _, ct := range tracks {
+		byTitle[ct.Title] = ct
+	}
+	tests := []struct {
+		title     string
+		track     int
+		agreement float64
+	}{
+		{"Intro", 1, 1},
+		{"Runaway", 2, 2.0 / 3},
+		{"Hidden Track", 3, 1}, // keeps 3 over "Bonus": both listed once, first source wins
+		{"Bonus", 3, 0},
+	}
+	for _, tt := range tests {
+		ct, ok := byTitle[tt.title]
+		if !ok {
+			t.Errorf("%q missing from consensus", tt.title)
+			continue
+		}
+		if ct.TrackNumber != tt.track || ct.agreement != tt.agreement {
+			t.Errorf("%q: track %d agreement %.2f, want track %d agreement %.2f", tt.title, ct.TrackNumber, ct.agreement, tt.track, tt.agreement)
+		}
+	}
+	if intro := byTitle["Intro"]; intro.MBID != "rec-1" || intro.Duration != time.Minute {
+		t.Errorf("Intro = %+v, want MBID from musicbrainz and length from spotify", intro.ReleaseTrack)
+	}
+	if got := byTitle["Runaway"].positions; len(got) != 3 || got[2] != "deezer 1-3" {
+		t.Errorf("Runaway positions = %v", got)
+	}
+}
+
+func TestConsensus_SingleSourceUnchanged(t *testing.T) {
+	src := albumSource{name: "deezer", tl: Tracklist{Tracks: []ReleaseTrack{
+		{TrackNumber: 1, Title: "Intro"},
+		{TrackNumber: 2, Title: "Outro"},
+	}}}
+	tl, tracks := defaultFolding.consensus([]albumSource{src})
+	for i, ct := range tracks {
+		if ct.agreement != 1 || tl.Tracks[i] != src.tl.Tracks[i] {
+			t.Errorf("track %d = %+v (agreement %.2f), want %+v", i, tl.Tracks[i], ct.agreement, src.tl.Tracks[i])
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"ytmusic/internal/backup"
 	"ytmusic/internal/config"
 	"ytmusic/internal/downloader"
 	"ytmusic/internal/fingerprint"
@@ -16,7 +17,9 @@ import (
 	"ytmusic/internal/lyrics"
 	"ytmusic/internal/metadata"
 	"ytmusic/internal/provider/deezer"
+	"ytmusic/internal/provider/discogs"
 	"ytmusic/internal/provider/itunes"
+	"ytmusic/internal/provider/lastfm"
 	"ytmusic/internal/provider/musicbrainz"
 	"ytmusic/internal/provider/spotify"
 	"ytmusic/pkg/utils"
@@ -28,6 +31,9 @@ type Hooks struct {
 	OnURLsExtracted func(total int)
 	OnProgress      func()
 	OnWarning       func(msg string)
+	Reviewer        metadata.Reviewer // asked about low-confidence matches; nil to keep original tags
+	ReportPath      string            // where to write the JSON match report; empty to skip
+	Backup          *backup.Run       // snapshots tags before they are rewritten; nil to skip
 }
 
 // Run executes the full download pipeline: extract URLs → download → merge → resolve metadata → move.
@@ -73,14 +79,7 @@ func Run(ctx context.Context, cfg config.Config, log *logger.Logger, tmpDir stri
 
 	c := buildComponents(cfg, log)
 	if len(c.providers) > 0 || c.fingerprinter != nil {
-		imp := importer.New(cfg, log, c.providers, c.fingerprinter)
-		if c.albumResolver != nil {
-			imp.WithAlbumResolver(c.albumResolver)
-		}
-		if c.fingerprinter != nil && c.releaseResolver != nil {
-			imp.WithBatchFingerprinter(c.fingerprinter)
-			imp.WithReleaseResolver(c.releaseResolver)
-		}
+		imp := newImporter(cfg, log, c, hooks)
 		if err := imp.Import(ctx, mergedDir); err != nil {
 			msg := fmt.Sprintf("metadata resolution failed: %v", err)
 			log.Warn(msg)
@@ -97,7 +96,7 @@ func Run(ctx context.Context, cfg config.Config, log *logger.Logger, tmpDir stri
 	}
 
 	log.Info("moving files to %s", cfg.OutputDir)
-	moved, failed, err := utils.MoveAudioFiles(mergedDir, cfg.OutputDir, metadata.SubDirFromTags)
+	moved, failed, err := utils.MoveAudioFiles(mergedDir, cfg.OutputDir, metadata.SubDirFunc(cfg.PathTemplate))
 	if err != nil {
 		return fmt.Errorf("failed to move files to output: %w", err)
 	}
@@ -110,17 +109,12 @@ func Run(ctx context.Context, cfg config.Config, log *logger.Logger, tmpDir stri
 }
 
 // RunImportOnly resolves metadata and lyrics for existing audio files in dir.
-func RunImportOnly(ctx context.Context, cfg config.Config, log *logger.Logger, dir string) error {
+// With cfg.DryRun the resolver runs in full but only prints the tag changes it
+// would make.
+func RunImportOnly(ctx context.Context, cfg config.Config, log *logger.Logger, dir string, hooks Hooks) error {
 	c := buildComponents(cfg, log)
 	if len(c.providers) > 0 || c.fingerprinter != nil {
-		imp := importer.New(cfg, log, c.providers, c.fingerprinter)
-		if c.albumResolver != nil {
-			imp.WithAlbumResolver(c.albumResolver)
-		}
-		if c.fingerprinter != nil && c.releaseResolver != nil {
-			imp.WithBatchFingerprinter(c.fingerprinter)
-			imp.WithReleaseResolver(c.releaseResolver)
-		}
+		imp := newImporter(cfg, log, c, hooks)
 		if err := imp.Import(ctx, dir); err != nil {
 			return fmt.Errorf("metadata resolution failed: %w", err)
 		}
@@ -128,18 +122,44 @@ func RunImportOnly(ctx context.Context, cfg config.Config, log *logger.Logger, d
 		log.Info("No metadata providers configured, skipping metadata resolution")
 	}
 
-	if !cfg.SkipLyrics {
+	if !cfg.SkipLyrics && !cfg.DryRun {
 		ResolveLyrics(ctx, dir, log)
 	}
 
 	return nil
 }
 
+// newImporter wires the metadata components and hooks into an Importer.
+func newImporter(cfg config.Config, log *logger.Logger, c components, hooks Hooks) *importer.Importer {
+	imp := importer.New(cfg, log, c.providers, c.fingerprinter)
+	if c.albumResolver != nil {
+		imp.WithAlbumResolver(c.albumResolver)
+	}
+	if c.fingerprinter != nil && c.releaseResolver != nil {
+		imp.WithBatchFingerprinter(c.fingerprinter)
+		imp.WithReleaseResolver(c.releaseResolver)
+	}
+	if c.creditsResolver != nil {
+		imp.WithCreditsResolver(c.creditsResolver)
+	}
+	if hooks.Reviewer != nil {
+		imp.WithReviewer(hooks.Reviewer)
+	}
+	if hooks.ReportPath != "" {
+		imp.WithReportPath(hooks.ReportPath)
+	}
+	if hooks.Backup != nil {
+		imp.WithBackup(hooks.Backup)
+	}
+	return imp
+}
+
 type components struct {
 	providers       []metadata.Provider
 	fingerprinter   *fingerprint.Fingerprinter // nil if AcoustID not configured
-	albumResolver   metadata.AlbumResolver     // nil if musicbrainz not in providers
+	albumResolver   metadata.AlbumResolver     // MusicBrainz when configured; album-resolving providers are used as well
 	releaseResolver metadata.ReleaseResolver   // nil if musicbrainz not in providers
+	creditsResolver metadata.CreditsResolver   // nil unless fetch_credits is set
 }
 
 // buildComponents creates all metadata-related components, sharing a single
@@ -148,14 +168,14 @@ func buildComponents(cfg config.Config, log *logger.Logger) components {
 	var mbClient *musicbrainz.Client
 	for _, p := range cfg.MetadataProviders {
 		if p == "musicbrainz" {
-			mbClient = musicbrainz.New()
+			mbClient = musicbrainz.New().WithReleasePreferences(releasePreferences(cfg.ReleasePreferences))
 			break
 		}
 	}
 	// Also need a MusicBrainz client for fingerprint MBID lookups even when the
 	// musicbrainz search provider is not in the provider list.
 	if mbClient == nil && cfg.AcoustIDAPIKey != "" {
-		mbClient = musicbrainz.New()
+		mbClient = musicbrainz.New().WithReleasePreferences(releasePreferences(cfg.ReleasePreferences))
 	}
 
 	var providers []metadata.Provider
@@ -169,6 +189,10 @@ func buildComponents(cfg config.Config, log *logger.Logger) components {
 			providers = append(providers, deezer.New())
 		case "itunes":
 			providers = append(providers, itunes.New())
+		case "lastfm":
+			providers = append(providers, lastfm.New(cfg.LastFMAPIKey))
+		case "discogs":
+			providers = append(providers, discogs.New(cfg.DiscogsToken))
 		}
 	}
 
@@ -177,14 +201,19 @@ func buildComponents(cfg config.Config, log *logger.Logger) components {
 		acoustid := fingerprint.NewAcoustIDClient(cfg.AcoustIDAPIKey, "")
 		fp = fingerprint.New(acoustid, func(ctx context.Context, mbid, preferAlbum string) (metadata.TrackInfo, error) {
 			return mbClient.LookupByMBID(ctx, mbid, preferAlbum)
-		})
+		}).WithMinScore(cfg.AcoustIDMinScore)
 	}
 
 	var ar metadata.AlbumResolver
 	var rr metadata.ReleaseResolver
+	var cr metadata.CreditsResolver
 	if mbClient != nil {
 		ar = mbClient
 		rr = mbClient
+		if cfg.FetchCredits {
+			mbClient.WithCredits(true)
+			cr = mbClient
+		}
 	}
 
 	return components{
@@ -192,7 +221,24 @@ func buildComponents(cfg config.Config, log *logger.Logger) components {
 		fingerprinter:   fp,
 		albumResolver:   ar,
 		releaseResolver: rr,
+		creditsResolver: cr,
+	}
+}
+
+// releasePreferences converts the configured release preferences, keeping
+// the client's defaults for fields left empty.
+func releasePreferences(cfg config.ReleasePreferences) musicbrainz.ReleasePreferences {
+	p := musicbrainz.DefaultReleasePreferences()
+	if len(cfg.Types) > 0 {
+		p.PrimaryTypes = cfg.Types
+	}
+	if len(cfg.Exclude) > 0 {
+		p.ExcludeSecondary = cfg.Exclude
 	}
+	p.Countries = cfg.Countries
+	p.Formats = cfg.Formats
+	p.Latest = cfg.Date == "latest"
+	return p
 }
 
 // ResolveLyrics fetches lyrics from LRCLib for each audio file in dir.
@@ -3,10 +3,14 @@ package deezer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -66,6 +70,212 @@ func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]meta
 	return parseResults(searchResp.Data), nil
 }
 
+// LookupByISRC returns the track with the given ISRC, or nothing if Deezer
+// doesn't know it. Implements metadata.ISRCLookup.
+func (c *Client) LookupByISRC(ctx context.Context, isrc string) ([]metadata.TrackInfo, error) {
+	reqURL := fmt.Sprintf("%s/track/isrc:%s", c.apiURL, url.PathEscape(isrc))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deezer request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ytmusic/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("deezer isrc lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("deezer isrc lookup returned %d: %s", resp.StatusCode, body)
+	}
+
+	var track struct {
+		trackItem
+		Error *apiError `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&track); err != nil {
+		return nil, fmt.Errorf("failed to decode deezer track: %w", err)
+	}
+	if track.Error != nil {
+		if track.Error.Code == errNoData {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("deezer API error: %s", track.Error.Message)
+	}
+	return parseResults([]trackItem{track.trackItem}), nil
+}
+
+// ResolveAlbum implements metadata.AlbumResolver: searches for the album and
+// returns its tracklist with disc numbers.
+func (c *Client) ResolveAlbum(ctx context.Context, album, artist string) (metadata.Tracklist, bool, error) {
+	albums, err := c.searchAlbums(ctx, album, artist)
+	if err != nil || len(albums) == 0 {
+		return metadata.Tracklist{}, false, err
+	}
+	tl, err := c.albumTracklist(ctx, albums[0].ID)
+	if err != nil {
+		return metadata.Tracklist{}, false, err
+	}
+	return tl, true, nil
+}
+
+// ResolveAlbumCandidates implements metadata.AlbumCandidates: returns the
+// tracklists of up to limit albums from the album search, most similar to
+// album first. An album with the same title and track count as an earlier
+// one is another release of the same edition and is skipped, as is one whose
+// lookup fails; an error is returned only when no album could be fetched.
+func (c *Client) ResolveAlbumCandidates(ctx context.Context, album, artist string, limit int) ([]metadata.Tracklist, error) {
+	albums, err := c.searchAlbums(ctx, album, artist)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var out []metadata.Tracklist
+	var errs []error
+	for _, a := range albums {
+		if len(out) == limit {
+			break
+		}
+		var edition string
+		if a.NbTracks > 0 {
+			edition = fmt.Sprintf("%s/%d", metadata.FoldText(a.Title), a.NbTracks)
+			if seen[edition] {
+				continue
+			}
+		}
+		tl, err := c.albumTracklist(ctx, a.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		seen[edition] = true
+		out = append(out, tl)
+	}
+	if len(out) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// searchAlbums searches for album by artist, ordered by rankAlbums.
+func (c *Client) searchAlbums(ctx context.Context, album, artist string) ([]albumItem, error) {
+	q := buildQuery(metadata.SearchQuery{Album: album, Artist: artist})
+	if q == "" {
+		return nil, nil
+	}
+
+	var search struct {
+		Data  []albumItem `json:"data"`
+		Error *apiError   `json:"error,omitempty"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/search/album?q=%s&limit=10", url.QueryEscape(q)), &search); err != nil {
+		return nil, fmt.Errorf("album search failed: %w", err)
+	}
+	if search.Error != nil {
+		return nil, fmt.Errorf("deezer API error: %s", search.Error.Message)
+	}
+	return rankAlbums(search.Data, album), nil
+}
+
+// albumTracklist fetches an album and its tracks.
+func (c *Client) albumTracklist(ctx context.Context, id int) (metadata.Tracklist, error) {
+	var detail struct {
+		albumItem
+		Error *apiError `json:"error,omitempty"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/album/%d", id), &detail); err != nil {
+		return metadata.Tracklist{}, fmt.Errorf("album lookup failed: %w", err)
+	}
+	if detail.Error != nil {
+		return metadata.Tracklist{}, fmt.Errorf("deezer API error: %s", detail.Error.Message)
+	}
+
+	// The album's embedded track list lacks disc numbers; the tracks
+	// endpoint has them.
+	var tracks struct {
+		Data  []trackItem `json:"data"`
+		Error *apiError   `json:"error,omitempty"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/album/%d/tracks?limit=500", id), &tracks); err != nil {
+		return metadata.Tracklist{}, fmt.Errorf("album tracks lookup failed: %w", err)
+	}
+	if tracks.Error != nil {
+		return metadata.Tracklist{}, fmt.Errorf("deezer API error: %s", tracks.Error.Message)
+	}
+	return toTracklist(detail.albumItem, tracks.Data), nil
+}
+
+// get fetches path from the API and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create deezer request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ytmusic/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deezer request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("deezer returned %d: %s", resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode deezer response: %w", err)
+	}
+	return nil
+}
+
+// rankAlbums drops the albums whose title is below
+// metadata.AlbumMatchThreshold against album and orders the rest most similar
+// first, keeping the search order on ties.
+func rankAlbums(albums []albumItem, album string) []albumItem {
+	ranked := slices.DeleteFunc(slices.Clone(albums), func(a albumItem) bool {
+		return metadata.Similarity(a.Title, album) < metadata.AlbumMatchThreshold
+	})
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return metadata.Similarity(ranked[i].Title, album) > metadata.Similarity(ranked[j].Title, album)
+	})
+	return ranked
+}
+
+func toTracklist(album albumItem, tracks []trackItem) metadata.Tracklist {
+	tl := metadata.Tracklist{
+		ID:          strconv.Itoa(album.ID),
+		Title:       album.Title,
+		Artist:      album.Artist.Name,
+		Compilation: metadata.IsVariousArtists(album.Artist.Name),
+		Release: metadata.ReleaseInfo{
+			Label:   album.Label,
+			Barcode: album.UPC,
+		},
+	}
+	switch album.RecordType {
+	case "":
+	case "compile":
+		tl.Release.Types = []string{"compilation"}
+	default:
+		tl.Release.Types = []string{album.RecordType}
+	}
+	for _, t := range tracks {
+		tl.Tracks = append(tl.Tracks, metadata.ReleaseTrack{
+			TrackNumber: t.TrackPosition,
+			DiscNumber:  t.DiskNumber,
+			Title:       t.TitleShort,
+			Duration:    time.Duration(t.Duration) * time.Second,
+		})
+	}
+	return tl
+}
+
+// errNoData is Deezer's error code for an unknown ID or ISRC.
+const errNoData = 800
+
 func buildQuery(query metadata.SearchQuery) string {
 	escape := func(s string) string {
 		return strings.ReplaceAll(s, "\"", "")
@@ -102,8 +312,16 @@ func parseResults(items []trackItem) []metadata.TrackInfo {
 			DiscNumber:  item.DiskNumber,
 			ISRC:        item.ISRC,
 			ArtworkURL:  artworkURL,
+			ReleaseDate: item.ReleaseDate,
 			Duration:    time.Duration(item.Duration) * time.Second,
 		}
+		if len(item.ReleaseDate) >= 4 {
+			info.Year, _ = strconv.Atoi(item.ReleaseDate[:4])
+		}
+		// title_short drops the variant; keep it so the resolver can score live/remix mismatches
+		if v, ok := metadata.ParseVersion(item.TitleVersion); ok {
+			info.Version = v
+		}
 		results = append(results, info)
 	}
 	return results
@@ -123,16 +341,27 @@ type apiError struct {
 }
 
 type trackItem struct {
-	ID             int       `json:"id"`
-	Title          string    `json:"title"`
-	TitleShort     string    `json:"title_short"`
-	TitleVersion   string    `json:"title_version"`
-	ISRC           string    `json:"isrc"`
-	Duration       int       `json:"duration"`
-	TrackPosition  int       `json:"track_position"`
-	DiskNumber     int       `json:"disk_number"`
-	Artist         artist    `json:"artist"`
-	Album          albumInfo `json:"album"`
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	TitleShort    string    `json:"title_short"`
+	TitleVersion  string    `json:"title_version"`
+	ISRC          string    `json:"isrc"`
+	Duration      int       `json:"duration"`
+	TrackPosition int       `json:"track_position"`
+	DiskNumber    int       `json:"disk_number"`
+	ReleaseDate   string    `json:"release_date"` // only in track lookups, not search results
+	Artist        artist    `json:"artist"`
+	Album         albumInfo `json:"album"`
+}
+
+type albumItem struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Artist     artist `json:"artist"`
+	NbTracks   int    `json:"nb_tracks"`
+	Label      string `json:"label"`       // only in album lookups
+	UPC        string `json:"upc"`         // only in album lookups
+	RecordType string `json:"record_type"` // "album", "ep", "single" or "compile"
 }
 
 type artist struct {
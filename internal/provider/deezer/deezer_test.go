@@ -3,9 +3,12 @@ package deezer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"ytmusic/internal/metadata"
 )
@@ -175,3 +178,235 @@ func TestParseTitleShort(t *testing.T) {
 		t.Errorf("expected TitleShort, got %q", results[0].Title)
 	}
 }
+
+func TestParseTitleVersion(t *testing.T) {
+	items := []trackItem{
+		{
+			Title:        "Salvador Dalí (Live @ Santeria Tour 2017)",
+			TitleShort:   "Salvador Dalí",
+			TitleVersion: "(Live @ Santeria Tour 2017)",
+			Artist:       artist{Name: "Marracash"},
+		},
+		{
+			Title:      "Santeria",
+			TitleShort: "Santeria",
+			Artist:     artist{Name: "Marracash"},
+		},
+	}
+	results := parseResults(items)
+	if results[0].Version.Kind != metadata.VersionLive {
+		t.Errorf("Version.Kind = %q, want %q", results[0].Version.Kind, metadata.VersionLive)
+	}
+	if results[1].Version.Kind != metadata.VersionOriginal {
+		t.Errorf("Version.Kind = %q, want original", results[1].Version.Kind)
+	}
+}
+
+func TestLookupByISRC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/track/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/track/isrc:ITXXX1700001":
+			w.Write([]byte(`{
+				"id": 1, "title": "Santeria", "title_short": "Santeria", "isrc": "ITXXX1700001",
+				"track_position": 3, "disk_number": 1, "release_date": "2016-06-24",
+				"artist": {"id": 100, "name": "Marracash"},
+				"album": {"id": 200, "title": "Santeria", "cover_xl": "https://example.com/cover-xl.jpg"}
+			}`))
+		default:
+			w.Write([]byte(`{"error": {"type": "DataException", "message": "no data", "code": 800}}`))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New()
+	c.apiURL = srv.URL
+
+	var _ metadata.ISRCLookup = c
+	results, err := c.LookupByISRC(context.Background(), "ITXXX1700001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if r := results[0]; r.Title != "Santeria" || r.TrackNumber != 3 || r.Year != 2016 || r.ReleaseDate != "2016-06-24" {
+		t.Errorf("result = %+v", r)
+	}
+
+	results, err = c.LookupByISRC(context.Background(), "ZZZZZ0000000")
+	if err != nil || len(results) != 0 {
+		t.Errorf("unknown ISRC: results=%v err=%v, want none", results, err)
+	}
+}
+
+func TestResolveAlbum(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/album", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != `artist:"Marracash" album:"Persona"` {
+			t.Errorf("q = %q", q)
+		}
+		w.Write([]byte(`{"data": [
+			{"id": 1, "title": "Persona (Deluxe)", "artist": {"name": "Marracash"}},
+			{"id": 2, "title": "Persona", "artist": {"name": "Marracash"}}
+		]}`))
+	})
+	mux.HandleFunc("/album/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 2, "title": "Persona", "artist": {"name": "Marracash"},
+			"label": "Universal", "upc": "602508396301", "record_type": "album"}`))
+	})
+	mux.HandleFunc("/album/2/tracks", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [
+			{"title": "Body Parts - I Denti", "title_short": "Body Parts - I Denti", "track_position": 1, "disk_number": 1},
+			{"title": "Qualcosa In Cui Credere (Live)", "title_short": "Qualcosa In Cui Credere", "track_position": 1, "disk_number": 2}
+		]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New()
+	c.apiURL = srv.URL
+
+	var _ metadata.AlbumResolver = c
+	tl, found, err := c.ResolveAlbum(context.Background(), "Persona", "Marracash")
+	if err != nil || !found {
+		t.Fatalf("ResolveAlbum: found=%v err=%v", found, err)
+	}
+	if tl.ID != "2" || tl.Title != "Persona" || tl.Artist != "Marracash" {
+		t.Errorf("tracklist = %+v", tl)
+	}
+	if tl.Release.Label != "Universal" || tl.Release.Barcode != "602508396301" || len(tl.Release.Types) != 1 || tl.Release.Types[0] != "album" {
+		t.Errorf("release = %+v", tl.Release)
+	}
+	want := []metadata.ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Body Parts - I Denti"},
+		{TrackNumber: 1, DiscNumber: 2, Title: "Qualcosa In Cui Credere"},
+	}
+	if len(tl.Tracks) != len(want) {
+		t.Fatalf("tracks = %+v", tl.Tracks)
+	}
+	for i, w := range want {
+		if tl.Tracks[i] != w {
+			t.Errorf("track %d = %+v, want %+v", i, tl.Tracks[i], w)
+		}
+	}
+}
+
+func TestResolveAlbumCandidates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/album", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [
+			{"id": 1, "title": "Persona (Deluxe)", "artist": {"name": "Marracash"}},
+			{"id": 2, "title": "Persona", "artist": {"name": "Marracash"}},
+			{"id": 3, "title": "Persona (Live)", "artist": {"name": "Marracash"}}
+		]}`))
+	})
+	for _, id := range []string{"1", "2", "3"} {
+		mux.HandleFunc("/album/"+id, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"id": %s, "title": "Persona", "artist": {"name": "Marracash"}}`, id)
+		})
+		mux.HandleFunc("/album/"+id+"/tracks", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data": [{"title_short": "Body Parts - I Denti", "track_position": 1, "disk_number": 1, "duration": 245}]}`))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New()
+	c.apiURL = srv.URL
+
+	var _ metadata.AlbumCandidates = c
+	tls, err := c.ResolveAlbumCandidates(context.Background(), "Persona", "Marracash", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tls) != 2 || tls[0].ID != "2" || tls[1].ID != "1" {
+		t.Fatalf("candidates = %+v, want albums 2 then 1", tls)
+	}
+	if d := tls[0].Tracks[0].Duration; d != 245*time.Second {
+		t.Errorf("track length = %v, want 4m5s", d)
+	}
+}
+
+func TestResolveAlbumCandidates_SkipsFailedLookups(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing map[string]bool
+		want    []string
+		wantErr bool
+	}{
+		{"one lookup fails", map[string]bool{"2": true}, []string{"3", "1"}, false},
+		{"other releases are skipped", nil, []string{"2", "1"}, false},
+		{"every lookup fails", map[string]bool{"1": true, "2": true, "3": true}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/album", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data": [
+					{"id": 2, "title": "Persona", "nb_tracks": 1, "artist": {"name": "Marracash"}},
+					{"id": 3, "title": "Persona", "nb_tracks": 1, "artist": {"name": "Marracash"}},
+					{"id": 1, "title": "Persona (Deluxe)", "nb_tracks": 2, "artist": {"name": "Marracash"}}
+				]}`))
+			})
+			for _, id := range []string{"1", "2", "3"} {
+				mux.HandleFunc("/album/"+id, func(w http.ResponseWriter, r *http.Request) {
+					if tt.failing[id] {
+						w.Write([]byte(`{"error": {"type": "DataException", "message": "no data", "code": 800}}`))
+						return
+					}
+					fmt.Fprintf(w, `{"id": %s, "title": "Persona", "artist": {"name": "Marracash"}}`, id)
+				})
+				mux.HandleFunc("/album/"+id+"/tracks", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": [{"title_short": "Body Parts - I Denti", "track_position": 1, "disk_number": 1}]}`))
+				})
+			}
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			c := New()
+			c.apiURL = srv.URL
+			tls, err := c.ResolveAlbumCandidates(context.Background(), "Persona", "Marracash", 5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, tl := range tls {
+				ids = append(ids, tl.ID)
+			}
+			// Album 3 is another release of the album 2 edition, looked up
+			// only when album 2 fails.
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("candidates = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAlbum_NotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no results", `{"data": [], "total": 0}`},
+		{"unrelated album", `{"data": [{"id": 4, "title": "Noi, Loro, Gli Altri", "artist": {"name": "Marracash"}}], "total": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/search/album" {
+					t.Errorf("unexpected request: %s", r.URL.Path)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := New()
+			c.apiURL = srv.URL
+			if _, found, err := c.ResolveAlbum(context.Background(), "Persona", "Marracash"); err != nil || found {
+				t.Errorf("found=%v err=%v, want not found", found, err)
+			}
+		})
+	}
+}
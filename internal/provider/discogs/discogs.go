@@ -0,0 +1,528 @@
+package discogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ytmusic/internal/metadata"
+)
+
+// requestInterval spaces requests to stay within Discogs' limit of 60
+// authenticated requests per minute.
+const requestInterval = time.Second
+
+// Client is a Discogs API client that implements metadata.Provider and
+// metadata.AlbumResolver. Discogs covers many electronic, vinyl-only and
+// regional releases that MusicBrainz lacks.
+type Client struct {
+	httpClient  *http.Client
+	apiURL      string
+	token       string
+	interval    time.Duration
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// New creates a new Discogs client authenticating with a personal access
+// token.
+func New(token string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		apiURL:     "https://api.discogs.com",
+		token:      token,
+		interval:   requestInterval,
+	}
+}
+
+func (c *Client) Name() string { return "discogs" }
+
+// Search finds releases containing the track and returns it as positioned on
+// the best matching release. Only the first release is fetched, as every
+// release costs a request.
+func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]metadata.TrackInfo, error) {
+	if query.Title == "" {
+		return nil, nil
+	}
+
+	params := url.Values{}
+	params.Set("type", "release")
+	params.Set("track", query.Title)
+	if query.Artist != "" {
+		params.Set("artist", query.Artist)
+	}
+	if query.Album != "" {
+		params.Set("release_title", query.Album)
+	}
+	results, err := c.search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 && query.Album != "" {
+		// The file's album is often a YouTube playlist name; retry without it.
+		params.Del("release_title")
+		if results, err = c.search(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	rel, err := c.release(ctx, results[0].ID)
+	if err != nil {
+		return nil, err
+	}
+	tl := toTracklist(rel)
+	track, ok := findTrack(tl.Tracks, query.Title)
+	if !ok {
+		return nil, nil
+	}
+
+	info := releaseTrackInfo(rel, tl)
+	info.Title = track.Title
+	info.TrackNumber = track.TrackNumber
+	info.DiscNumber = track.DiscNumber
+	info.TotalTracks = tracksOnDisc(tl.Tracks, track.DiscNumber)
+	if rt := rel.track(track); rt != nil {
+		if len(rt.Artists) > 0 {
+			info.Artists = toArtistCredits(rt.Artists)
+			info.Artist = metadata.JoinArtists(info.Artists)
+		}
+		info.Duration = parseDuration(rt.Duration)
+	}
+	return []metadata.TrackInfo{info}, nil
+}
+
+// ResolveAlbum implements metadata.AlbumResolver: searches for the release
+// and returns its tracklist with vinyl sides and CD positions mapped to disc
+// and track numbers.
+func (c *Client) ResolveAlbum(ctx context.Context, album, artist string) (metadata.Tracklist, bool, error) {
+	params := url.Values{}
+	params.Set("type", "release")
+	params.Set("release_title", album)
+	if artist != "" {
+		params.Set("artist", artist)
+	}
+	results, err := c.search(ctx, params)
+	if err != nil {
+		return metadata.Tracklist{}, false, fmt.Errorf("release search failed: %w", err)
+	}
+	best, ok := pickRelease(results, album)
+	if !ok {
+		return metadata.Tracklist{}, false, nil
+	}
+
+	rel, err := c.release(ctx, best.ID)
+	if err != nil {
+		return metadata.Tracklist{}, false, fmt.Errorf("release lookup failed: %w", err)
+	}
+	return toTracklist(rel), true, nil
+}
+
+func (c *Client) search(ctx context.Context, params url.Values) ([]searchResult, error) {
+	params.Set("per_page", "10")
+	var resp searchResponse
+	if err := c.get(ctx, "/database/search?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("discogs search failed: %w", err)
+	}
+	return resp.Results, nil
+}
+
+func (c *Client) release(ctx context.Context, id int) (release, error) {
+	var rel release
+	if err := c.get(ctx, fmt.Sprintf("/releases/%d", id), &rel); err != nil {
+		return release{}, fmt.Errorf("discogs release lookup failed: %w", err)
+	}
+	return rel, nil
+}
+
+// get performs a rate-limited, authenticated request and decodes the JSON
+// response into v, retrying once when Discogs asks to slow down.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	for attempt := 0; ; attempt++ {
+		c.rateLimit()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", "ytmusic/1.0")
+		req.Header.Set("Authorization", "Discogs token="+c.token)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			resp.Body.Close()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay(resp.Header)):
+			}
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("returned %d: %s", resp.StatusCode, body)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+}
+
+// rateLimit waits until the request interval has passed since the last request.
+func (c *Client) rateLimit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elapsed := time.Since(c.lastRequest); elapsed < c.interval {
+		time.Sleep(c.interval - elapsed)
+	}
+	c.lastRequest = time.Now()
+}
+
+// retryDelay reads Retry-After, defaulting to the time it takes Discogs'
+// moving one-minute window to free up a request.
+func retryDelay(h http.Header) time.Duration {
+	if s, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		return time.Duration(s) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// pickRelease returns the result whose title is most similar to album, the
+// first on ties. It reports false when no title reaches
+// metadata.AlbumMatchThreshold, so an unrelated release is never taken.
+func pickRelease(results []searchResult, album string) (searchResult, bool) {
+	var best searchResult
+	bestScore := metadata.AlbumMatchThreshold
+	found := false
+	for _, r := range results {
+		// Search titles read "Artist - Album".
+		title := r.Title
+		if _, t, ok := strings.Cut(title, " - "); ok {
+			title = t
+		}
+		if s := metadata.Similarity(title, album); s > bestScore || (!found && s == bestScore) {
+			best, bestScore, found = r, s, true
+		}
+	}
+	return best, found
+}
+
+// Positions as Discogs writes them: "1-03", "CD2.5" and "2/4" carry a disc
+// number, "A1" and "B" a vinyl or cassette side, "7" just a track number.
+var (
+	discTrackPosition = regexp.MustCompile(`^(?i:cd|dvd|disc|disk)?\s*(\d+)\s*[-./]\s*(\d+)`)
+	sidePosition      = regexp.MustCompile(`^([A-Za-z])\d*`)
+	trackPosition     = regexp.MustCompile(`^(\d+)`)
+)
+
+// toTracklist converts a release to a tracklist. Vinyl sides pair up into
+// discs (A/B → disc 1, C/D → disc 2) and are numbered through, so A1, A2,
+// B1 become tracks 1, 2 and 3. Index tracks are expanded into their
+// sub-tracks, numbered in sequence.
+func toTracklist(rel release) metadata.Tracklist {
+	tl := metadata.Tracklist{
+		ID:          strconv.Itoa(rel.ID),
+		Title:       rel.Title,
+		Artist:      joinArtists(rel.Artists),
+		Compilation: isVarious(rel.Artists),
+		Release:     releaseInfo(rel),
+	}
+	if tl.Compilation {
+		tl.Artist = metadata.VariousArtists
+	}
+
+	counts := make(map[int]int) // tracks numbered so far per disc
+	for _, t := range rel.tracks() {
+		disc, num := 1, 0
+		switch {
+		case discTrackPosition.MatchString(t.Position):
+			m := discTrackPosition.FindStringSubmatch(t.Position)
+			disc, _ = strconv.Atoi(m[1])
+			num, _ = strconv.Atoi(m[2])
+		case trackPosition.MatchString(t.Position):
+			num, _ = strconv.Atoi(trackPosition.FindStringSubmatch(t.Position)[1])
+		case sidePosition.MatchString(t.Position):
+			side := strings.ToUpper(sidePosition.FindStringSubmatch(t.Position)[1])[0]
+			disc = int(side-'A')/2 + 1
+		}
+		if num <= counts[disc] {
+			// No number, or a sub-track such as "2b" repeating one.
+			num = counts[disc] + 1
+		}
+		counts[disc] = num
+		tl.Tracks = append(tl.Tracks, metadata.ReleaseTrack{
+			TrackNumber: num,
+			DiscNumber:  disc,
+			Title:       t.Title,
+			Duration:    parseDuration(t.Duration),
+		})
+	}
+	return tl
+}
+
+// releaseTrackInfo fills the release-level fields of a track on rel.
+func releaseTrackInfo(rel release, tl metadata.Tracklist) metadata.TrackInfo {
+	info := metadata.TrackInfo{
+		Artists:     toArtistCredits(rel.Artists),
+		Album:       rel.Title,
+		AlbumArtist: tl.Artist,
+		Compilation: tl.Compilation,
+		Year:        rel.Year,
+		ReleaseDate: releaseDate(rel.Released),
+		Genre:       strings.Join(append(append([]string{}, rel.Genres...), rel.Styles...), ", "),
+		ArtworkURL:  primaryImage(rel.Images),
+		Release:     tl.Release,
+	}
+	info.Artist = metadata.JoinArtists(info.Artists)
+	var discs int
+	for _, t := range tl.Tracks {
+		discs = max(discs, t.DiscNumber)
+	}
+	info.TotalDiscs = discs
+	return info
+}
+
+func releaseInfo(rel release) metadata.ReleaseInfo {
+	info := metadata.ReleaseInfo{Country: rel.Country}
+	if len(rel.Labels) > 0 {
+		info.Label = stripSuffix(rel.Labels[0].Name)
+		if rel.Labels[0].CatNo != "none" {
+			info.CatalogNumber = rel.Labels[0].CatNo
+		}
+	}
+	for _, id := range rel.Identifiers {
+		if id.Type == "Barcode" {
+			info.Barcode = strings.ReplaceAll(id.Value, " ", "")
+			break
+		}
+	}
+	var formats []string
+	for _, f := range rel.Formats {
+		if f.Name != "" && !containsString(formats, f.Name) {
+			formats = append(formats, f.Name)
+		}
+	}
+	info.Media = strings.Join(formats, " + ")
+	return info
+}
+
+// findTrack returns the track whose title matches title, ignoring case,
+// accents and a trailing "(... Mix)" or similar suffix on either side.
+func findTrack(tracks []metadata.ReleaseTrack, title string) (metadata.ReleaseTrack, bool) {
+	want := metadata.FoldText(title)
+	for _, t := range tracks {
+		if metadata.FoldText(t.Title) == want {
+			return t, true
+		}
+	}
+	for _, t := range tracks {
+		got := metadata.FoldText(t.Title)
+		if strings.HasPrefix(got, want+" (") || strings.HasPrefix(want, got+" (") {
+			return t, true
+		}
+	}
+	return metadata.ReleaseTrack{}, false
+}
+
+func tracksOnDisc(tracks []metadata.ReleaseTrack, disc int) int {
+	var n int
+	for _, t := range tracks {
+		if t.DiscNumber == disc {
+			n++
+		}
+	}
+	return n
+}
+
+// Pattern matching the numeric suffix Discogs adds to tell apart artists and
+// labels sharing a name: "Nirvana (2)".
+var disambiguation = regexp.MustCompile(`\s\(\d+\)$`)
+
+func stripSuffix(name string) string {
+	return disambiguation.ReplaceAllString(name, "")
+}
+
+// toArtistCredits converts Discogs artists, using the name variation
+// credited on the release when there is one.
+func toArtistCredits(artists []artist) []metadata.ArtistCredit {
+	credits := make([]metadata.ArtistCredit, 0, len(artists))
+	for i, a := range artists {
+		name := a.ANV
+		if name == "" {
+			name = stripSuffix(a.Name)
+		}
+		c := metadata.ArtistCredit{Name: name}
+		if i < len(artists)-1 {
+			c.JoinPhrase = joinPhrase(a.Join)
+		}
+		credits = append(credits, c)
+	}
+	return credits
+}
+
+func joinArtists(artists []artist) string {
+	return metadata.JoinArtists(toArtistCredits(artists))
+}
+
+// joinPhrase spaces a Discogs join ("&", ",", "Feat.") the way display
+// strings are written.
+func joinPhrase(join string) string {
+	switch join = strings.TrimSpace(join); join {
+	case "":
+		return ""
+	case ",":
+		return ", "
+	}
+	return " " + join + " "
+}
+
+func isVarious(artists []artist) bool {
+	return len(artists) == 1 && (artists[0].Name == "Various" || metadata.IsVariousArtists(artists[0].Name))
+}
+
+// releaseDate trims the unknown parts of a Discogs date: "1997-00-00" → "1997".
+func releaseDate(s string) string {
+	s = strings.TrimSuffix(s, "-00")
+	return strings.TrimSuffix(s, "-00")
+}
+
+func primaryImage(images []image) string {
+	for _, img := range images {
+		if img.Type == "primary" {
+			return img.URI
+		}
+	}
+	if len(images) > 0 {
+		return images[0].URI
+	}
+	return ""
+}
+
+// parseDuration parses "3:45" or "1:02:03".
+func parseDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	var total int
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total) * time.Second
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Discogs API response types
+
+type searchResponse struct {
+	Results []searchResult `json:"results"`
+}
+
+type searchResult struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"` // "Artist - Album"
+}
+
+type release struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Artists     []artist     `json:"artists"`
+	Year        int          `json:"year"`
+	Released    string       `json:"released"` // "1997-05-21", "1997-00-00" when only the year is known
+	Country     string       `json:"country"`
+	Genres      []string     `json:"genres"`
+	Styles      []string     `json:"styles"`
+	Labels      []label      `json:"labels"`
+	Formats     []format     `json:"formats"`
+	Identifiers []identifier `json:"identifiers"`
+	Images      []image      `json:"images"`
+	Tracklist   []track      `json:"tracklist"`
+}
+
+// tracks returns the playable tracks, expanding index tracks into their
+// sub-tracks and skipping headings.
+func (r release) tracks() []track {
+	var out []track
+	for _, t := range r.Tracklist {
+		switch t.Type {
+		case "heading":
+			continue
+		case "index":
+			out = append(out, t.SubTracks...)
+		default:
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// track returns the playable track that rt was made from, or nil.
+func (r release) track(rt metadata.ReleaseTrack) *track {
+	for _, t := range r.tracks() {
+		if t.Title == rt.Title {
+			return &t
+		}
+	}
+	return nil
+}
+
+type artist struct {
+	Name string `json:"name"`
+	ANV  string `json:"anv"`  // name variation credited on this release
+	Join string `json:"join"` // joins this artist to the next: "&", ",", "Feat."
+}
+
+type label struct {
+	Name  string `json:"name"`
+	CatNo string `json:"catno"`
+}
+
+type format struct {
+	Name string `json:"name"` // "Vinyl", "CD", "File"
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type image struct {
+	Type string `json:"type"` // "primary" or "secondary"
+	URI  string `json:"uri"`
+}
+
+type track struct {
+	Position  string   `json:"position"`
+	Type      string   `json:"type_"` // "track", "heading" or "index"
+	Title     string   `json:"title"`
+	Duration  string   `json:"duration"`
+	Artists   []artist `json:"artists"`
+	SubTracks []track  `json:"sub_tracks"`
+}
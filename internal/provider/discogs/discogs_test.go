@@ -0,0 +1,246 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ytmusic/internal/metadata"
+)
+
+const vinylRelease = `{
+	"id": 249504, "title": "Selected Ambient Works 85-92", "year": 1992, "released": "1992-11-09",
+	"country": "UK", "genres": ["Electronic"], "styles": ["Ambient", "IDM"],
+	"artists": [{"name": "Aphex Twin", "anv": "", "join": ""}],
+	"labels": [{"name": "Apollo (2)", "catno": "AMB 3922"}],
+	"formats": [{"name": "Vinyl"}, {"name": "Vinyl"}],
+	"identifiers": [{"type": "Barcode", "value": "5 028 55910 0284"}],
+	"images": [{"type": "secondary", "uri": "https://example.com/back.jpg"}, {"type": "primary", "uri": "https://example.com/front.jpg"}],
+	"tracklist": [
+		{"position": "", "type_": "heading", "title": "Side One"},
+		{"position": "A1", "type_": "track", "title": "Xtal", "duration": "4:51"},
+		{"position": "A2", "type_": "track", "title": "Tha", "duration": "9:01"},
+		{"position": "B1", "type_": "track", "title": "Pulsewidth", "duration": "3:47"},
+		{"position": "C1", "type_": "track", "title": "Ageispolis", "duration": "5:21"},
+		{"position": "D", "type_": "track", "title": "Heliosphan", "duration": "4:51",
+			"artists": [{"name": "Aphex Twin", "join": "Feat."}, {"name": "Some Guest (2)"}]}
+	]
+}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Discogs token=test-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New("test-token")
+	c.apiURL = srv.URL
+	c.interval = 0
+	return c
+}
+
+func TestResolveAlbum(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/database/search":
+			if q := r.URL.Query(); q.Get("release_title") != "Selected Ambient Works 85-92" || q.Get("artist") != "Aphex Twin" {
+				t.Errorf("unexpected search query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"results": [
+				{"id": 1, "title": "Aphex Twin - Selected Ambient Works 85-92 (Remastered)"},
+				{"id": 249504, "title": "Aphex Twin - Selected Ambient Works 85-92"}
+			]}`))
+		case "/releases/249504":
+			w.Write([]byte(vinylRelease))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tl, found, err := c.ResolveAlbum(context.Background(), "Selected Ambient Works 85-92", "Aphex Twin")
+	if err != nil || !found {
+		t.Fatalf("ResolveAlbum: found=%v err=%v", found, err)
+	}
+	if tl.ID != "249504" || tl.Title != "Selected Ambient Works 85-92" || tl.Artist != "Aphex Twin" {
+		t.Errorf("tracklist = %+v", tl)
+	}
+
+	want := []metadata.ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Xtal", Duration: 4*time.Minute + 51*time.Second},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Tha", Duration: 9*time.Minute + 1*time.Second},
+		{TrackNumber: 3, DiscNumber: 1, Title: "Pulsewidth", Duration: 3*time.Minute + 47*time.Second},
+		{TrackNumber: 1, DiscNumber: 2, Title: "Ageispolis", Duration: 5*time.Minute + 21*time.Second},
+		{TrackNumber: 2, DiscNumber: 2, Title: "Heliosphan", Duration: 4*time.Minute + 51*time.Second},
+	}
+	if len(tl.Tracks) != len(want) {
+		t.Fatalf("got %d tracks, want %d: %+v", len(tl.Tracks), len(want), tl.Tracks)
+	}
+	for i, w := range want {
+		if tl.Tracks[i] != w {
+			t.Errorf("track %d = %+v, want %+v", i, tl.Tracks[i], w)
+		}
+	}
+
+	rel := tl.Release
+	if rel.Label != "Apollo" || rel.CatalogNumber != "AMB 3922" || rel.Barcode != "5028559100284" || rel.Country != "UK" || rel.Media != "Vinyl" {
+		t.Errorf("release = %+v", rel)
+	}
+}
+
+func TestResolveAlbum_NotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no results", `{"results": []}`},
+		{"unrelated release", `{"results": [{"id": 2, "title": "Aphex Twin - Drukqs"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/database/search" {
+					t.Errorf("unexpected request: %s", r.URL.Path)
+				}
+				w.Write([]byte(tt.body))
+			})
+			if _, found, err := c.ResolveAlbum(context.Background(), "Selected Ambient Works 85-92", "Aphex Twin"); err != nil || found {
+				t.Errorf("found=%v err=%v, want not found", found, err)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var searches int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/database/search":
+			searches++
+			if r.URL.Query().Get("release_title") != "" {
+				w.Write([]byte(`{"results": []}`)) // playlist name, no such release
+				return
+			}
+			if q := r.URL.Query(); q.Get("track") != "Heliosphan" || q.Get("type") != "release" {
+				t.Errorf("unexpected search query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"results": [{"id": 249504, "title": "Aphex Twin - Selected Ambient Works 85-92"}]}`))
+		case "/releases/249504":
+			w.Write([]byte(vinylRelease))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	results, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Heliosphan", Artist: "Aphex Twin", Album: "My Playlist"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if searches != 2 {
+		t.Errorf("searches = %d, want a retry without the album", searches)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if r.Title != "Heliosphan" || r.Album != "Selected Ambient Works 85-92" || r.AlbumArtist != "Aphex Twin" {
+		t.Errorf("result = %+v", r)
+	}
+	if r.Artist != "Aphex Twin Feat. Some Guest" {
+		t.Errorf("Artist = %q, want the track credit", r.Artist)
+	}
+	if r.TrackNumber != 2 || r.DiscNumber != 2 || r.TotalTracks != 2 || r.TotalDiscs != 2 {
+		t.Errorf("position = %d/%d disc %d/%d, want 2/2 disc 2/2", r.TrackNumber, r.TotalTracks, r.DiscNumber, r.TotalDiscs)
+	}
+	if r.Year != 1992 || r.ReleaseDate != "1992-11-09" || r.Genre != "Electronic, Ambient, IDM" {
+		t.Errorf("year=%d date=%q genre=%q", r.Year, r.ReleaseDate, r.Genre)
+	}
+	if r.ArtworkURL != "https://example.com/front.jpg" || r.Duration != 4*time.Minute+51*time.Second {
+		t.Errorf("artwork=%q duration=%v", r.ArtworkURL, r.Duration)
+	}
+}
+
+func TestSearch_RetriesOnRateLimit(t *testing.T) {
+	var calls int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"results": []}`))
+	})
+	if _, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Xtal"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestToTracklist_Positions(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []string
+		want      [][2]int // disc, track
+	}{
+		{"cd", []string{"1", "2", "3"}, [][2]int{{1, 1}, {1, 2}, {1, 3}}},
+		{"multi-disc", []string{"1-1", "1-2", "2-1", "CD3.4"}, [][2]int{{1, 1}, {1, 2}, {2, 1}, {3, 4}}},
+		{"vinyl", []string{"A1", "A2", "B1", "B2", "C", "D1"}, [][2]int{{1, 1}, {1, 2}, {1, 3}, {1, 4}, {2, 1}, {2, 2}}},
+		{"missing positions", []string{"", ""}, [][2]int{{1, 1}, {1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rel release
+			for _, p := range tt.positions {
+				rel.Tracklist = append(rel.Tracklist, track{Position: p, Type: "track", Title: p})
+			}
+			tl := toTracklist(rel)
+			if len(tl.Tracks) != len(tt.want) {
+				t.Fatalf("got %d tracks, want %d", len(tl.Tracks), len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got := [2]int{tl.Tracks[i].DiscNumber, tl.Tracks[i].TrackNumber}; got != w {
+					t.Errorf("%q → disc %d track %d, want disc %d track %d", tt.positions[i], got[0], got[1], w[0], w[1])
+				}
+			}
+		})
+	}
+}
+
+func TestToTracklist_IndexTracksAndVarious(t *testing.T) {
+	rel := release{
+		Title:   "Mix Compilation",
+		Artists: []artist{{Name: "Various"}},
+		Tracklist: []track{
+			{Position: "1", Type: "track", Title: "Intro"},
+			{Type: "index", Title: "Medley", SubTracks: []track{
+				{Position: "2a", Type: "track", Title: "Part One"},
+				{Position: "2b", Type: "track", Title: "Part Two"},
+			}},
+		},
+	}
+	tl := toTracklist(rel)
+	if !tl.Compilation || tl.Artist != metadata.VariousArtists {
+		t.Errorf("compilation=%v artist=%q", tl.Compilation, tl.Artist)
+	}
+	want := []metadata.ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Intro"},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Part One"},
+		{TrackNumber: 3, DiscNumber: 1, Title: "Part Two"},
+	}
+	if len(tl.Tracks) != len(want) {
+		t.Fatalf("tracks = %+v", tl.Tracks)
+	}
+	for i, w := range want {
+		if tl.Tracks[i] != w {
+			t.Errorf("track %d = %+v, want %+v", i, tl.Tracks[i], w)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 type Client struct {
 	httpClient *http.Client
 	apiURL     string
+	lookupURL  string
 }
 
 // New creates a new iTunes client.
@@ -24,6 +26,7 @@ func New() *Client {
 	return &Client{
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 		apiURL:     "https://itunes.apple.com/search",
+		lookupURL:  "https://itunes.apple.com/lookup",
 	}
 }
 
@@ -42,30 +45,108 @@ func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]meta
 	params.Set("entity", "song")
 	params.Set("limit", "5")
 
-	reqURL := fmt.Sprintf("%s?%s", c.apiURL, params.Encode())
+	searchResp, err := c.get(ctx, c.apiURL, params)
+	if err != nil {
+		return nil, fmt.Errorf("itunes search request failed: %w", err)
+	}
+	return parseResults(searchResp.Results), nil
+}
+
+// ResolveAlbum implements metadata.AlbumResolver: searches for the album and
+// returns its tracklist from a collection lookup.
+func (c *Client) ResolveAlbum(ctx context.Context, album, artist string) (metadata.Tracklist, bool, error) {
+	term := buildTerm(metadata.SearchQuery{Title: album, Artist: artist})
+	if term == "" {
+		return metadata.Tracklist{}, false, nil
+	}
+
+	params := url.Values{}
+	params.Set("term", term)
+	params.Set("media", "music")
+	params.Set("entity", "album")
+	params.Set("limit", "10")
+	searchResp, err := c.get(ctx, c.apiURL, params)
+	if err != nil {
+		return metadata.Tracklist{}, false, fmt.Errorf("itunes album search failed: %w", err)
+	}
+	best, ok := pickCollection(searchResp.Results, album)
+	if !ok {
+		return metadata.Tracklist{}, false, nil
+	}
+
+	params = url.Values{}
+	params.Set("id", strconv.Itoa(best.CollectionID))
+	params.Set("entity", "song")
+	lookupResp, err := c.get(ctx, c.lookupURL, params)
+	if err != nil {
+		return metadata.Tracklist{}, false, fmt.Errorf("itunes collection lookup failed: %w", err)
+	}
+	return toTracklist(best, lookupResp.Results), true, nil
+}
+
+// get calls an iTunes endpoint with params and decodes its results.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values) (searchResponse, error) {
+	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create itunes request: %w", err)
+		return searchResponse{}, fmt.Errorf("failed to create itunes request: %w", err)
 	}
 	req.Header.Set("User-Agent", "ytmusic/1.0")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("itunes search request failed: %w", err)
+		return searchResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("itunes search returned %d: %s", resp.StatusCode, body)
+		return searchResponse{}, fmt.Errorf("itunes returned %d: %s", resp.StatusCode, body)
 	}
 
-	var searchResp searchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode itunes response: %w", err)
+	var out searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return searchResponse{}, fmt.Errorf("failed to decode itunes response: %w", err)
 	}
+	return out, nil
+}
 
-	return parseResults(searchResp.Results), nil
+// pickCollection returns the album whose title is most similar to album,
+// the first on ties. It reports false when no title reaches
+// metadata.AlbumMatchThreshold, so an unrelated album is never taken.
+func pickCollection(results []resultItem, album string) (resultItem, bool) {
+	var best resultItem
+	bestScore := metadata.AlbumMatchThreshold
+	found := false
+	for _, r := range results {
+		if s := metadata.Similarity(r.CollectionName, album); s > bestScore || (!found && s == bestScore) {
+			best, bestScore, found = r, s, true
+		}
+	}
+	return best, found
+}
+
+// toTracklist builds a tracklist from a collection lookup, whose results are
+// the collection itself followed by its songs.
+func toTracklist(album resultItem, items []resultItem) metadata.Tracklist {
+	tl := metadata.Tracklist{
+		ID:          strconv.Itoa(album.CollectionID),
+		Title:       album.CollectionName,
+		Artist:      album.ArtistName,
+		Compilation: metadata.IsVariousArtists(album.ArtistName),
+	}
+	for _, item := range items {
+		if item.WrapperType != "track" {
+			continue
+		}
+		tl.Tracks = append(tl.Tracks, metadata.ReleaseTrack{
+			TrackNumber: item.TrackNumber,
+			DiscNumber:  item.DiscNumber,
+			Title:       item.TrackName,
+			Duration:    time.Duration(item.TrackTimeMillis) * time.Millisecond,
+		})
+	}
+	return tl
 }
 
 func buildTerm(query metadata.SearchQuery) string {
@@ -88,14 +169,22 @@ func parseResults(items []resultItem) []metadata.TrackInfo {
 			artworkURL = strings.Replace(artworkURL, "100x100", "600x600", 1)
 		}
 
+		albumArtist := item.ArtistName
+		if item.CollectionArtistName != "" {
+			albumArtist = item.CollectionArtistName
+		}
+
 		info := metadata.TrackInfo{
 			Title:       item.TrackName,
 			Artist:      item.ArtistName,
 			Album:       item.CollectionName,
-			AlbumArtist: item.ArtistName,
+			AlbumArtist: albumArtist,
 			Genre:       item.PrimaryGenreName,
 			TrackNumber: item.TrackNumber,
+			TotalTracks: item.TrackCount,
 			DiscNumber:  item.DiscNumber,
+			TotalDiscs:  item.DiscCount,
+			Compilation: metadata.IsVariousArtists(item.CollectionArtistName),
 			ArtworkURL:  artworkURL,
 			Duration:    time.Duration(item.TrackTimeMillis) * time.Millisecond,
 		}
@@ -120,13 +209,18 @@ type searchResponse struct {
 }
 
 type resultItem struct {
-	TrackName        string `json:"trackName"`
-	ArtistName       string `json:"artistName"`
-	CollectionName   string `json:"collectionName"`
-	PrimaryGenreName string `json:"primaryGenreName"`
-	TrackNumber      int    `json:"trackNumber"`
-	DiscNumber       int    `json:"discNumber"`
-	TrackTimeMillis  int    `json:"trackTimeMillis"`
-	ArtworkURL100    string `json:"artworkUrl100"`
-	ReleaseDate      string `json:"releaseDate"`
+	WrapperType          string `json:"wrapperType"` // "track" or "collection"
+	CollectionID         int    `json:"collectionId"`
+	TrackName            string `json:"trackName"`
+	ArtistName           string `json:"artistName"`
+	CollectionName       string `json:"collectionName"`
+	CollectionArtistName string `json:"collectionArtistName"` // only set when it differs from ArtistName, e.g. "Various Artists"
+	PrimaryGenreName     string `json:"primaryGenreName"`
+	TrackNumber          int    `json:"trackNumber"`
+	TrackCount           int    `json:"trackCount"` // tracks on this disc
+	DiscNumber           int    `json:"discNumber"`
+	DiscCount            int    `json:"discCount"`
+	TrackTimeMillis      int    `json:"trackTimeMillis"`
+	ArtworkURL100        string `json:"artworkUrl100"`
+	ReleaseDate          string `json:"releaseDate"`
 }
@@ -0,0 +1,86 @@
+package itunes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ytmusic/internal/metadata"
+)
+
+func TestResolveAlbum(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("entity") != "album" || q.Get("term") != "Rumours Fleetwood Mac" {
+			t.Errorf("unexpected search query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"resultCount": 2, "results": [
+			{"wrapperType": "collection", "collectionId": 10, "collectionName": "Rumours (Super Deluxe)", "artistName": "Fleetwood Mac"},
+			{"wrapperType": "collection", "collectionId": 11, "collectionName": "Rumours", "artistName": "Fleetwood Mac"}
+		]}`))
+	})
+	mux.HandleFunc("/lookup", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("id") != "11" || q.Get("entity") != "song" {
+			t.Errorf("unexpected lookup query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"resultCount": 3, "results": [
+			{"wrapperType": "collection", "collectionId": 11, "collectionName": "Rumours", "artistName": "Fleetwood Mac"},
+			{"wrapperType": "track", "trackName": "Second Hand News", "trackNumber": 1, "discNumber": 1},
+			{"wrapperType": "track", "trackName": "Dreams", "trackNumber": 2, "discNumber": 1}
+		]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New()
+	c.apiURL = srv.URL + "/search"
+	c.lookupURL = srv.URL + "/lookup"
+
+	var _ metadata.AlbumResolver = c
+	tl, found, err := c.ResolveAlbum(context.Background(), "Rumours", "Fleetwood Mac")
+	if err != nil || !found {
+		t.Fatalf("ResolveAlbum: found=%v err=%v", found, err)
+	}
+	if tl.ID != "11" || tl.Title != "Rumours" || tl.Artist != "Fleetwood Mac" {
+		t.Errorf("tracklist = %+v", tl)
+	}
+	want := []metadata.ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Second Hand News"},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Dreams"},
+	}
+	if len(tl.Tracks) != len(want) {
+		t.Fatalf("tracks = %+v", tl.Tracks)
+	}
+	for i, w := range want {
+		if tl.Tracks[i] != w {
+			t.Errorf("track %d = %+v, want %+v", i, tl.Tracks[i], w)
+		}
+	}
+}
+
+func TestResolveAlbum_NotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no results", `{"resultCount": 0, "results": []}`},
+		{"unrelated album", `{"resultCount": 1, "results": [
+			{"wrapperType": "collection", "collectionId": 12, "collectionName": "Greatest Hits", "artistName": "Fleetwood Mac"}
+		]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := New()
+			c.apiURL = srv.URL
+			if _, found, err := c.ResolveAlbum(context.Background(), "Rumours", "Fleetwood Mac"); err != nil || found {
+				t.Errorf("found=%v err=%v, want not found", found, err)
+			}
+		})
+	}
+}
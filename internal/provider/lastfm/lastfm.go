@@ -0,0 +1,316 @@
+package lastfm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"ytmusic/internal/metadata"
+)
+
+// defaultMinTagWeight is the lowest top-tag weight (0-100, relative to the
+// track's most used tag) kept as a genre.
+const defaultMinTagWeight = 50
+
+// maxGenres caps the number of tags reported as the genre.
+const maxGenres = 3
+
+// errNotFound is Last.fm's error code for an unknown track or artist.
+const errNotFound = 6
+
+// junkTags are popular Last.fm tags that describe listeners, not music.
+var junkTags = map[string]bool{
+	"seen live": true, "favorites": true, "favourites": true, "favorite": true,
+	"favourite": true, "love": true, "loved": true, "awesome": true, "beautiful": true,
+	"my music": true, "albums i own": true, "spotify": true, "youtube": true,
+}
+
+// Client is a Last.fm API client that implements metadata.Provider. Its
+// matches carry little beyond title, artist and album; it is mainly useful
+// for filling in genres from the track's top tags.
+type Client struct {
+	httpClient   *http.Client
+	apiURL       string
+	apiKey       string
+	minTagWeight int
+}
+
+// New creates a new Last.fm client using the given API key.
+func New(apiKey string) *Client {
+	return &Client{
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		apiURL:       "https://ws.audioscrobbler.com/2.0/",
+		apiKey:       apiKey,
+		minTagWeight: defaultMinTagWeight,
+	}
+}
+
+// WithMinTagWeight sets the lowest tag weight (0-100) reported as a genre.
+func (c *Client) WithMinTagWeight(w int) *Client {
+	c.minTagWeight = w
+	return c
+}
+
+func (c *Client) Name() string { return "lastfm" }
+
+// Search looks the track up with track.getInfo when the artist is known,
+// otherwise with track.search, and sets the genre of the first result from
+// its top tags.
+func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]metadata.TrackInfo, error) {
+	if query.Title == "" {
+		return nil, nil
+	}
+
+	var results []metadata.TrackInfo
+	if query.Artist != "" {
+		info, found, err := c.trackInfo(ctx, query.Title, query.Artist)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			results = []metadata.TrackInfo{info}
+		}
+	}
+	if len(results) == 0 {
+		var err error
+		results, err = c.searchTracks(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	genre, err := c.genre(ctx, results[0].Title, results[0].Artist)
+	if err != nil {
+		return nil, err
+	}
+	results[0].Genre = genre
+	return results, nil
+}
+
+// trackInfo calls track.getInfo with autocorrection, reporting false if
+// Last.fm doesn't know the track.
+func (c *Client) trackInfo(ctx context.Context, title, artist string) (metadata.TrackInfo, bool, error) {
+	params := url.Values{}
+	params.Set("track", title)
+	params.Set("artist", artist)
+	params.Set("autocorrect", "1")
+
+	var resp trackInfoResponse
+	if err := c.call(ctx, "track.getInfo", params, &resp); err != nil {
+		if isNotFound(err) {
+			return metadata.TrackInfo{}, false, nil
+		}
+		return metadata.TrackInfo{}, false, err
+	}
+	t := resp.Track
+	if t.Name == "" {
+		return metadata.TrackInfo{}, false, nil
+	}
+
+	info := metadata.TrackInfo{
+		Title:      t.Name,
+		Artist:     t.Artist.Name,
+		Album:      t.Album.Title,
+		ArtworkURL: largestImage(t.Album.Image),
+	}
+	if ms, err := strconv.Atoi(t.Duration); err == nil {
+		info.Duration = time.Duration(ms) * time.Millisecond
+	}
+	if t.Album.Title != "" {
+		info.AlbumArtist = t.Album.Artist
+	}
+	return info, true, nil
+}
+
+// searchTracks calls track.search, which matches on title and artist text
+// and returns neither albums nor tags.
+func (c *Client) searchTracks(ctx context.Context, query metadata.SearchQuery) ([]metadata.TrackInfo, error) {
+	params := url.Values{}
+	params.Set("track", query.Title)
+	if query.Artist != "" {
+		params.Set("artist", query.Artist)
+	}
+	params.Set("limit", "5")
+
+	var resp searchResponse
+	if err := c.call(ctx, "track.search", params, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []metadata.TrackInfo
+	for _, t := range resp.Results.TrackMatches.Track {
+		results = append(results, metadata.TrackInfo{Title: t.Name, Artist: t.Artist})
+	}
+	return results, nil
+}
+
+// genre returns the track's top tags above the weight cutoff, falling back
+// to the artist's when the track has none, joined with ", ".
+func (c *Client) genre(ctx context.Context, title, artist string) (string, error) {
+	if artist == "" {
+		return "", nil
+	}
+	params := url.Values{}
+	params.Set("track", title)
+	params.Set("artist", artist)
+	params.Set("autocorrect", "1")
+
+	var resp topTagsResponse
+	if err := c.call(ctx, "track.getTopTags", params, &resp); err != nil && !isNotFound(err) {
+		return "", err
+	}
+	if tags := c.filterTags(resp.TopTags.Tag); len(tags) > 0 {
+		return strings.Join(tags, ", "), nil
+	}
+
+	params.Del("track")
+	resp = topTagsResponse{}
+	if err := c.call(ctx, "artist.getTopTags", params, &resp); err != nil && !isNotFound(err) {
+		return "", err
+	}
+	return strings.Join(c.filterTags(resp.TopTags.Tag), ", "), nil
+}
+
+// filterTags returns up to maxGenres tag names at or above the weight cutoff,
+// title-cased and without listener tags.
+func (c *Client) filterTags(tags []tag) []string {
+	var out []string
+	for _, t := range tags {
+		if len(out) == maxGenres {
+			break
+		}
+		name := strings.TrimSpace(t.Name)
+		if t.Count < c.minTagWeight || name == "" || junkTags[strings.ToLower(name)] {
+			continue
+		}
+		out = append(out, titleCase(name))
+	}
+	return out
+}
+
+// call invokes an API method and decodes its JSON response into v.
+func (c *Client) call(ctx context.Context, method string, params url.Values, v any) error {
+	params.Set("method", method)
+	params.Set("api_key", c.apiKey)
+	params.Set("format", "json")
+
+	reqURL := fmt.Sprintf("%s?%s", c.apiURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create lastfm request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ytmusic/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("lastfm %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read lastfm response: %w", err)
+	}
+
+	// Errors come as {"error": 6, "message": "..."}, with or without an
+	// error status.
+	var apiErr apiError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Code != 0 {
+		return &apiErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("lastfm %s returned %d: %s", method, resp.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode lastfm response: %w", err)
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Code == errNotFound
+}
+
+// largestImage returns the URL of the biggest image, or "" if there is none.
+func largestImage(images []image) string {
+	for i := len(images) - 1; i >= 0; i-- {
+		if images[i].URL != "" {
+			return images[i].URL
+		}
+	}
+	return ""
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// Last.fm API response types
+
+type apiError struct {
+	Code    int    `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("lastfm API error %d: %s", e.Code, e.Message)
+}
+
+type trackInfoResponse struct {
+	Track struct {
+		Name     string `json:"name"`
+		Duration string `json:"duration"` // milliseconds
+		Artist   struct {
+			Name string `json:"name"`
+		} `json:"artist"`
+		Album struct {
+			Title  string  `json:"title"`
+			Artist string  `json:"artist"`
+			Image  []image `json:"image"` // smallest first
+		} `json:"album"`
+	} `json:"track"`
+}
+
+type image struct {
+	URL  string `json:"#text"`
+	Size string `json:"size"`
+}
+
+type searchResponse struct {
+	Results struct {
+		TrackMatches struct {
+			Track []struct {
+				Name   string `json:"name"`
+				Artist string `json:"artist"`
+			} `json:"track"`
+		} `json:"trackmatches"`
+	} `json:"results"`
+}
+
+type topTagsResponse struct {
+	TopTags struct {
+		Tag []tag `json:"tag"`
+	} `json:"toptags"`
+}
+
+type tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"` // weight relative to the most used tag, 0-100
+}
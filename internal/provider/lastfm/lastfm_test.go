@@ -0,0 +1,157 @@
+package lastfm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ytmusic/internal/metadata"
+)
+
+// newTestServer answers each API method with the given JSON body; methods
+// without one report Last.fm's "not found" error.
+func newTestServer(t *testing.T, bodies map[string]string, calls *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("api_key") != "test-key" || q.Get("format") != "json" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		method := q.Get("method")
+		if calls != nil {
+			*calls = append(*calls, method)
+		}
+		body, ok := bodies[method]
+		if !ok {
+			body = `{"error": 6, "message": "Track not found"}`
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestSearch_TrackInfo(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"track.getInfo": `{"track": {
+			"name": "Karma Police", "duration": "264000",
+			"artist": {"name": "Radiohead"},
+			"album": {"title": "OK Computer", "artist": "Radiohead", "image": [
+				{"#text": "https://example.com/s.png", "size": "small"},
+				{"#text": "https://example.com/xl.png", "size": "extralarge"}
+			]}
+		}}`,
+		"track.getTopTags": `{"toptags": {"tag": [
+			{"name": "alternative", "count": 100},
+			{"name": "seen live", "count": 90},
+			{"name": "britpop", "count": 60},
+			{"name": "90s", "count": 55},
+			{"name": "rock", "count": 52},
+			{"name": "melancholic", "count": 20}
+		]}}`,
+	}, nil)
+	defer srv.Close()
+
+	c := New("test-key")
+	c.apiURL = srv.URL
+
+	results, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Karma Police", Artist: "Radiohead"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if r.Title != "Karma Police" || r.Artist != "Radiohead" || r.Album != "OK Computer" || r.AlbumArtist != "Radiohead" {
+		t.Errorf("result = %+v", r)
+	}
+	if r.Duration != 264*time.Second {
+		t.Errorf("Duration = %v, want 4m24s", r.Duration)
+	}
+	if r.ArtworkURL != "https://example.com/xl.png" {
+		t.Errorf("ArtworkURL = %q, want the largest image", r.ArtworkURL)
+	}
+	if r.Genre != "Alternative, Britpop, 90s" {
+		t.Errorf("Genre = %q, want %q", r.Genre, "Alternative, Britpop, 90s")
+	}
+}
+
+func TestSearch_FallsBackToTrackSearch(t *testing.T) {
+	var calls []string
+	srv := newTestServer(t, map[string]string{
+		"track.search": `{"results": {"trackmatches": {"track": [
+			{"name": "Karma Police", "artist": "Radiohead"},
+			{"name": "Karma Police (Live)", "artist": "Radiohead"}
+		]}}}`,
+		"artist.getTopTags": `{"toptags": {"tag": [{"name": "alternative rock", "count": 100}]}}`,
+	}, &calls)
+	defer srv.Close()
+
+	c := New("test-key")
+	c.apiURL = srv.URL
+
+	results, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Karma Police", Artist: "Radiohed"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Genre != "Alternative Rock" {
+		t.Errorf("Genre = %q, want the artist's tags", results[0].Genre)
+	}
+	want := []string{"track.getInfo", "track.search", "track.getTopTags", "artist.getTopTags"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("calls = %v, want %v", calls, want)
+			break
+		}
+	}
+}
+
+func TestSearch_APIError(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"track.getInfo": `{"error": 10, "message": "Invalid API key"}`,
+	}, nil)
+	defer srv.Close()
+
+	c := New("test-key")
+	c.apiURL = srv.URL
+
+	if _, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Karma Police", Artist: "Radiohead"}); err == nil {
+		t.Error("expected error for invalid API key")
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	tags := []tag{
+		{Name: "hip-hop", Count: 100},
+		{Name: "Favorites", Count: 80},
+		{Name: "rap", Count: 40},
+		{Name: "j-pop", Count: 30},
+	}
+	tests := []struct {
+		weight int
+		want   []string
+	}{
+		{defaultMinTagWeight, []string{"Hip-hop"}},
+		{30, []string{"Hip-hop", "Rap", "J-pop"}},
+	}
+	for _, tt := range tests {
+		got := New("").WithMinTagWeight(tt.weight).filterTags(tags)
+		if len(got) != len(tt.want) {
+			t.Errorf("weight %d: got %q, want %q", tt.weight, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("weight %d: got %q, want %q", tt.weight, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package musicbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"ytmusic/internal/metadata"
+)
+
+// creditsInc adds the recording's artist relationships and those of the work
+// it records to a recording lookup.
+const creditsInc = "artist-rels+work-rels+work-level-rels"
+
+// relationModifiers are relationship attributes that qualify a credit rather
+// than name an instrument or voice.
+var relationModifiers = []string{"additional", "assistant", "co", "executive", "guest", "minor", "solo"}
+
+type relation struct {
+	Type       string      `json:"type"`
+	Attributes []string    `json:"attributes"`
+	Artist     *artistInfo `json:"artist"`
+	Work       *work       `json:"work"`
+}
+
+type work struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Relations []relation `json:"relations"`
+}
+
+// WithCredits makes recording lookups include work and artist relationships,
+// so matches carry composer, lyricist, arranger and performer credits.
+func (c *Client) WithCredits(on bool) *Client {
+	c.credits = on
+	return c
+}
+
+// Credits fetches the credits of a recording and the work it records.
+// Implements metadata.CreditsResolver.
+func (c *Client) Credits(ctx context.Context, recordingID string) (metadata.Credits, error) {
+	c.rateLimit()
+
+	reqURL := fmt.Sprintf("%s/recording/%s?inc=%s&fmt=json", c.apiURL, recordingID, creditsInc)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return metadata.Credits{}, fmt.Errorf("failed to create credits request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ytmusic/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return metadata.Credits{}, fmt.Errorf("credits lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return metadata.Credits{}, fmt.Errorf("credits lookup returned %d: %s", resp.StatusCode, body)
+	}
+
+	var rec recording
+	if err := json.NewDecoder(resp.Body).Decode(&rec); err != nil {
+		return metadata.Credits{}, fmt.Errorf("failed to decode recording: %w", err)
+	}
+	return parseCredits(rec.Relations), nil
+}
+
+// parseCredits collects credits from a recording's relationships: composers,
+// writers and lyricists from the performed work, arrangers from either, and
+// performers from the recording.
+func parseCredits(rels []relation) metadata.Credits {
+	var c metadata.Credits
+	for _, rel := range rels {
+		if rel.Work != nil && rel.Type == "performance" {
+			for _, wr := range rel.Work.Relations {
+				if wr.Artist == nil {
+					continue
+				}
+				switch wr.Type {
+				case "composer", "writer":
+					c.Composers = appendUnique(c.Composers, wr.Artist.Name)
+				case "lyricist", "librettist":
+					c.Lyricists = appendUnique(c.Lyricists, wr.Artist.Name)
+				case "arranger", "instrument arranger", "vocal arranger", "orchestrator":
+					c.Arrangers = appendUnique(c.Arrangers, wr.Artist.Name)
+				}
+			}
+			continue
+		}
+		if rel.Artist == nil {
+			continue
+		}
+		switch rel.Type {
+		case "arranger", "instrument arranger", "vocal arranger", "orchestrator":
+			c.Arrangers = appendUnique(c.Arrangers, rel.Artist.Name)
+		case "instrument", "vocal", "performer", "performing orchestra":
+			c.Performers = appendUnique(c.Performers, performerCredit(rel))
+		}
+	}
+	return c
+}
+
+// performerCredit formats a performer relationship as "Name (role, ...)".
+func performerCredit(rel relation) string {
+	var roles []string
+	for _, a := range rel.Attributes {
+		if !slices.Contains(relationModifiers, a) {
+			roles = append(roles, a)
+		}
+	}
+	if len(roles) == 0 {
+		switch rel.Type {
+		case "vocal":
+			roles = []string{"vocals"}
+		case "performing orchestra":
+			roles = []string{"orchestra"}
+		}
+	}
+	if len(roles) == 0 {
+		return rel.Artist.Name
+	}
+	return rel.Artist.Name + " (" + strings.Join(roles, ", ") + ")"
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" || slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
@@ -0,0 +1,79 @@
+package musicbrainz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ytmusic/internal/metadata"
+)
+
+const creditsJSON = `{
+	"id": "rec-1",
+	"title": "Yesterday",
+	"relations": [
+		{"type": "performance", "work": {"id": "w-1", "title": "Yesterday", "relations": [
+			{"type": "composer", "artist": {"id": "a-1", "name": "Paul McCartney"}},
+			{"type": "writer", "artist": {"id": "a-2", "name": "John Lennon"}},
+			{"type": "lyricist", "artist": {"id": "a-1", "name": "Paul McCartney"}},
+			{"type": "composer", "artist": {"id": "a-1", "name": "Paul McCartney"}}
+		]}},
+		{"type": "arranger", "artist": {"id": "a-3", "name": "George Martin"}},
+		{"type": "vocal", "attributes": ["lead vocals"], "artist": {"id": "a-1", "name": "Paul McCartney"}},
+		{"type": "instrument", "attributes": ["guitar", "guest"], "artist": {"id": "a-1", "name": "Paul McCartney"}},
+		{"type": "vocal", "attributes": [], "artist": {"id": "a-4", "name": "Backing Singer"}},
+		{"type": "conductor", "artist": {"id": "a-3", "name": "George Martin"}}
+	]
+}`
+
+func TestParseCredits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("inc"); got != strings.ReplaceAll(creditsInc, "+", " ") {
+			t.Errorf("inc = %q, want %q", got, creditsInc)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(creditsJSON))
+	}))
+	defer srv.Close()
+
+	got, err := newTestClient(srv.URL).Credits(context.Background(), "rec-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := metadata.Credits{
+		Composers:  []string{"Paul McCartney", "John Lennon"},
+		Lyricists:  []string{"Paul McCartney"},
+		Arrangers:  []string{"George Martin"},
+		Performers: []string{"Paul McCartney (lead vocals)", "Paul McCartney (guitar)", "Backing Singer (vocals)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Credits =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLookupByMBID_IncludesCreditsWhenEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := strings.Contains(r.URL.Query().Get("inc"), "work-rels"); got != enabled {
+				t.Errorf("credits=%v: inc = %q", enabled, r.URL.Query().Get("inc"))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(creditsJSON))
+		}))
+
+		info, err := newTestClient(srv.URL).WithCredits(enabled).LookupByMBID(context.Background(), "rec-1", "")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(info.Credits.Composers) != 2 {
+			t.Errorf("credits=%v: composers = %v, want relationships parsed", enabled, info.Credits.Composers)
+		}
+		if info.Credits.Fetched != enabled {
+			t.Errorf("credits=%v: Fetched = %v", enabled, info.Credits.Fetched)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package musicbrainz
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,6 +23,8 @@ type Client struct {
 	httpClient     *http.Client
 	apiURL         string
 	artworkBaseURL string
+	prefs          ReleasePreferences
+	credits        bool // include work and artist relationships in recording lookups
 	mu             sync.Mutex
 	lastRequest    time.Time
 }
@@ -30,6 +35,7 @@ func New() *Client {
 		httpClient:     &http.Client{Timeout: 10 * time.Second},
 		apiURL:         "https://musicbrainz.org/ws/2",
 		artworkBaseURL: "https://coverartarchive.org/release",
+		prefs:          DefaultReleasePreferences(),
 	}
 }
 
@@ -39,9 +45,17 @@ func NewWithURL(apiURL, artworkBaseURL string) *Client {
 		httpClient:     &http.Client{Timeout: 10 * time.Second},
 		apiURL:         apiURL,
 		artworkBaseURL: artworkBaseURL,
+		prefs:          DefaultReleasePreferences(),
 	}
 }
 
+// WithReleasePreferences sets how the client chooses among the releases a
+// recording or album appears on.
+func (c *Client) WithReleasePreferences(p ReleasePreferences) *Client {
+	c.prefs = p
+	return c
+}
+
 func (c *Client) Name() string { return "musicbrainz" }
 
 // Search queries the MusicBrainz recording search API and returns matching tracks.
@@ -85,7 +99,11 @@ func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]meta
 func (c *Client) LookupByMBID(ctx context.Context, mbid, preferAlbum string) (metadata.TrackInfo, error) {
 	c.rateLimit()
 
-	reqURL := fmt.Sprintf("%s/recording/%s?inc=artists+releases+isrcs+artist-credits&fmt=json", c.apiURL, mbid)
+	inc := "artists+releases+release-groups+media+isrcs+artist-credits"
+	if c.credits {
+		inc += "+" + creditsInc
+	}
+	reqURL := fmt.Sprintf("%s/recording/%s?inc=%s&fmt=json", c.apiURL, mbid, inc)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return metadata.TrackInfo{}, fmt.Errorf("failed to create musicbrainz lookup request: %w", err)
@@ -113,9 +131,48 @@ func (c *Client) LookupByMBID(ctx context.Context, mbid, preferAlbum string) (me
 	if len(results) == 0 {
 		return metadata.TrackInfo{}, fmt.Errorf("no parseable data in musicbrainz recording %s", mbid)
 	}
+	// The relationships came with the lookup, so the resolver need not ask
+	// for them again when nobody is credited.
+	results[0].Credits.Fetched = c.credits
 	return results[0], nil
 }
 
+// LookupByISRC returns the recordings carrying isrc, or nothing if
+// MusicBrainz doesn't know it. Implements metadata.ISRCLookup.
+func (c *Client) LookupByISRC(ctx context.Context, isrc string) ([]metadata.TrackInfo, error) {
+	c.rateLimit()
+
+	reqURL := fmt.Sprintf("%s/isrc/%s?inc=artists+releases+release-groups+media+artist-credits&fmt=json", c.apiURL, url.PathEscape(isrc))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create musicbrainz isrc request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ytmusic/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("musicbrainz isrc lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("musicbrainz isrc lookup returned %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Recordings []recording `json:"recordings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode musicbrainz isrc response: %w", err)
+	}
+	return c.parseRecordings(ctx, result.Recordings, ""), nil
+}
+
 // rateLimit enforces MusicBrainz's 1 request/second limit.
 // The mutex is held for the full duration (including sleep) so that concurrent
 // callers queue up and each waits a full second from the previous request.
@@ -177,24 +234,43 @@ func buildQuery(query metadata.SearchQuery) string {
 func (c *Client) parseRecordings(ctx context.Context, recordings []recording, preferAlbum string) []metadata.TrackInfo {
 	var results []metadata.TrackInfo
 	for _, rec := range recordings {
+		credits := toArtistCredits(rec.ArtistCredit)
 		info := metadata.TrackInfo{
 			Title:    rec.Title,
-			Artist:   joinArtistCredits(rec.ArtistCredit),
+			Artist:   metadata.JoinArtists(credits),
+			Artists:  credits,
 			Duration: time.Duration(rec.Length) * time.Millisecond,
+			Credits:  parseCredits(rec.Relations),
+			MusicBrainz: metadata.MusicBrainzIDs{
+				RecordingID: rec.ID,
+				ArtistIDs:   artistIDs(rec.ArtistCredit),
+			},
 		}
 
 		if len(rec.ISRCs) > 0 {
 			info.ISRC = rec.ISRCs[0]
 		}
 
+		// Live recordings are marked in the disambiguation ("live, 1985-07-13: Wembley")
+		// rather than the title.
+		if v, ok := metadata.ParseVersion(strings.SplitN(rec.Disambiguation, ",", 2)[0]); ok {
+			info.Version = v
+		}
+
 		if len(rec.Releases) > 0 {
-			rel := pickBestRelease(rec.Releases, preferAlbum)
+			rel := pickBestRelease(rec.Releases, preferAlbum, c.prefs)
 			info.Album = rel.Title
 			if len(rel.ArtistCredit) > 0 {
 				info.AlbumArtist = rel.ArtistCredit[0].Artist.Name
+				info.Sort.AlbumArtist = rel.ArtistCredit[0].Artist.SortName
 			}
+			info.Compilation = isVariousArtists(rel.ArtistCredit)
+			info.Release = releaseInfo(rel, mediaFormats(rel.Media))
 			info.Year = parseYear(rel.Date)
 			info.ReleaseDate = rel.Date
+			info.MusicBrainz.ReleaseID = rel.ID
+			info.MusicBrainz.ReleaseGroupID = rel.ReleaseGroup.ID
+			info.MusicBrainz.AlbumArtistIDs = artistIDs(rel.ArtistCredit)
 
 			artworkURL := fmt.Sprintf("%s/%s/front-500", c.artworkBaseURL, rel.ID)
 			if c.hasArtwork(ctx, artworkURL) {
@@ -203,6 +279,7 @@ func (c *Client) parseRecordings(ctx context.Context, recordings []recording, pr
 
 			if len(rel.Media) > 0 && len(rel.Media[0].Track) > 0 {
 				m := rel.Media[0]
+				info.MusicBrainz.TrackID = m.Track[0].ID
 				if n, err := strconv.Atoi(m.Track[0].Number); err == nil {
 					info.TrackNumber = n
 				} else if m.Track[0].Position > 0 {
@@ -236,95 +313,109 @@ func (c *Client) hasArtwork(ctx context.Context, artworkURL string) bool {
 	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusTemporaryRedirect
 }
 
-func joinArtistCredits(credits []artistCredit) string {
-	var parts []string
+// variousArtistsID is MusicBrainz's special-purpose artist for compilations.
+const variousArtistsID = "89ad4ac3-39f7-470e-963a-56509c546377"
+
+// isVariousArtists reports whether a release artist credit marks a compilation.
+func isVariousArtists(credits []artistCredit) bool {
+	return len(credits) == 1 &&
+		(credits[0].Artist.ID == variousArtistsID || metadata.IsVariousArtists(credits[0].Artist.Name))
+}
+
+// toArtistCredits converts MusicBrainz artist credits, preferring the credited
+// name (e.g. "Pharrell") over the artist's canonical name.
+func toArtistCredits(credits []artistCredit) []metadata.ArtistCredit {
+	var out []metadata.ArtistCredit
 	for _, ac := range credits {
-		parts = append(parts, ac.Artist.Name)
+		name := ac.Name
+		if name == "" {
+			name = ac.Artist.Name
+		}
+		out = append(out, metadata.ArtistCredit{Name: name, JoinPhrase: ac.JoinPhrase, SortName: ac.Artist.SortName})
 	}
-	return strings.Join(parts, ", ")
+	return out
 }
 
-// pickBestRelease selects the most appropriate release for tagging.
-// Prefers: Official status, Album type, no secondary types (not Compilation).
-// Among equal-scored releases, prefers releases whose title matches preferAlbum
-// (to avoid landing on variants like "LP! OFFLINE" when the source is "LP!"),
-// then the one with track position data, then the earliest date.
-func pickBestRelease(releases []release, preferAlbum string) release {
-	best := releases[0]
-	bestScore := releaseScore(best)
+// artistIDs returns the MusicBrainz artist IDs of credits in order, skipping
+// credits without an ID.
+func artistIDs(credits []artistCredit) []string {
+	var ids []string
+	for _, ac := range credits {
+		if ac.Artist.ID != "" {
+			ids = append(ids, ac.Artist.ID)
+		}
+	}
+	return ids
+}
 
+// pickBestRelease selects the most appropriate release for tagging according
+// to prefs. Among otherwise equal releases, one whose title matches
+// preferAlbum wins (to avoid landing on variants like "LP! OFFLINE" when the
+// source is "LP!").
+func pickBestRelease(releases []release, preferAlbum string, prefs ReleasePreferences) release {
+	best := releases[0]
 	for _, rel := range releases[1:] {
-		s := releaseScore(rel)
-		relHasTrack := len(rel.Media) > 0 && len(rel.Media[0].Track) > 0
-		bestHasTrack := len(best.Media) > 0 && len(best.Media[0].Track) > 0
-
-		betterScore := s > bestScore
-		sameScore := s == bestScore
-
-		relAlbumSim := releaseAlbumSim(rel.Title, preferAlbum)
-		bestAlbumSim := releaseAlbumSim(best.Title, preferAlbum)
-
-		sameScoreBetterAlbum := sameScore && relAlbumSim > bestAlbumSim
-		sameScoreSameAlbumWithTrack := sameScore && relAlbumSim == bestAlbumSim && relHasTrack && !bestHasTrack
-		sameScoreSameAlbumEarlierDate := sameScore && relAlbumSim == bestAlbumSim && relHasTrack == bestHasTrack && rel.Date != "" && (best.Date == "" || rel.Date < best.Date)
-
-		if betterScore || sameScoreBetterAlbum || sameScoreSameAlbumWithTrack || sameScoreSameAlbumEarlierDate {
+		if prefs.compare(rel, best, preferAlbum) > 0 {
 			best = rel
-			bestScore = s
 		}
 	}
 	return best
 }
 
-// releaseAlbumSim returns a rough similarity score between a release title and
-// the preferred album name. Used only as a tiebreaker in pickBestRelease.
+// releaseAlbumSim returns the similarity between a release title and the
+// preferred album name, using the resolver's metric. Used only as a tiebreaker
+// in pickBestRelease.
 func releaseAlbumSim(releaseTitle, preferAlbum string) float64 {
 	if preferAlbum == "" {
 		return 0
 	}
-	a := strings.ToLower(strings.TrimSpace(releaseTitle))
-	b := strings.ToLower(strings.TrimSpace(preferAlbum))
-	if a == b {
-		return 1.0
-	}
-	at := strings.Fields(a)
-	bt := strings.Fields(b)
-	if len(at) == 0 || len(bt) == 0 {
-		return 0
-	}
-	set := make(map[string]bool, len(bt))
-	for _, t := range bt {
-		set[t] = true
+	return metadata.Similarity(releaseTitle, preferAlbum)
+}
+
+// releaseInfo extracts the release-level tags of rel, whose media have the
+// given formats.
+func releaseInfo(rel release, formats []string) metadata.ReleaseInfo {
+	info := metadata.ReleaseInfo{
+		Barcode:      rel.Barcode,
+		Country:      rel.Country,
+		Media:        joinFormats(formats),
+		OriginalDate: rel.ReleaseGroup.FirstReleaseDate,
 	}
-	matches := 0
-	for _, t := range at {
-		if set[t] {
-			matches++
+	for _, li := range rel.LabelInfo {
+		if info.Label == "" && li.Label != nil {
+			info.Label = li.Label.Name
+		}
+		if info.CatalogNumber == "" && li.CatalogNumber != "[none]" {
+			info.CatalogNumber = li.CatalogNumber
 		}
 	}
-	maxLen := len(at)
-	if len(bt) > maxLen {
-		maxLen = len(bt)
+	if t := rel.ReleaseGroup.PrimaryType; t != "" {
+		info.Types = append(info.Types, strings.ToLower(t))
 	}
-	return float64(matches) / float64(maxLen)
-}
-
-func releaseScore(rel release) int {
-	score := 0
-
-	if rel.Status == "Official" {
-		score += 4
+	for _, t := range rel.ReleaseGroup.SecondaryTypes {
+		info.Types = append(info.Types, strings.ToLower(t))
 	}
+	return info
+}
 
-	if rel.ReleaseGroup.PrimaryType == "Album" {
-		score += 2
+func mediaFormats(media []media) []string {
+	formats := make([]string, 0, len(media))
+	for _, m := range media {
+		formats = append(formats, m.Format)
 	}
+	return formats
+}
 
-	if len(rel.ReleaseGroup.SecondaryTypes) == 0 {
-		score += 1
+// joinFormats describes a release's media: "CD" for any number of discs of
+// one format, "CD + DVD-Video" for mixed formats.
+func joinFormats(formats []string) string {
+	var distinct []string
+	for _, f := range formats {
+		if f != "" && !slices.Contains(distinct, f) {
+			distinct = append(distinct, f)
+		}
 	}
-
-	return score
+	return strings.Join(distinct, " + ")
 }
 
 func parseYear(date string) int {
@@ -343,21 +434,26 @@ type searchResponse struct {
 }
 
 type recording struct {
-	ID           string         `json:"id"`
-	Title        string         `json:"title"`
-	Length       int            `json:"length"`
-	ArtistCredit []artistCredit `json:"artist-credit"`
-	Releases     []release      `json:"releases"`
-	ISRCs        []string       `json:"isrcs"`
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	Disambiguation string         `json:"disambiguation"`
+	Length         int            `json:"length"`
+	ArtistCredit   []artistCredit `json:"artist-credit"`
+	Releases       []release      `json:"releases"`
+	ISRCs          []string       `json:"isrcs"`
+	Relations      []relation     `json:"relations"`
 }
 
 type artistCredit struct {
-	Artist artistInfo `json:"artist"`
+	Name       string     `json:"name"`
+	JoinPhrase string     `json:"joinphrase"`
+	Artist     artistInfo `json:"artist"`
 }
 
 type artistInfo struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	SortName string `json:"sort-name"`
 }
 
 type release struct {
@@ -365,23 +461,38 @@ type release struct {
 	Title        string         `json:"title"`
 	Status       string         `json:"status"`
 	Date         string         `json:"date"`
+	Country      string         `json:"country"`
+	Barcode      string         `json:"barcode"`
+	LabelInfo    []labelInfo    `json:"label-info"`
 	ArtistCredit []artistCredit `json:"artist-credit"`
 	ReleaseGroup releaseGroup   `json:"release-group"`
 	Media        []media        `json:"media"`
+	TrackCount   int            `json:"track-count"` // only in search results
+}
+
+type labelInfo struct {
+	CatalogNumber string `json:"catalog-number"`
+	Label         *struct {
+		Name string `json:"name"`
+	} `json:"label"`
 }
 
 type releaseGroup struct {
-	PrimaryType    string   `json:"primary-type"`
-	SecondaryTypes []string `json:"secondary-types"`
+	ID               string   `json:"id"`
+	PrimaryType      string   `json:"primary-type"`
+	SecondaryTypes   []string `json:"secondary-types"`
+	FirstReleaseDate string   `json:"first-release-date"`
 }
 
 type media struct {
 	Position   int     `json:"position"`    // disc number (1-indexed)
+	Format     string  `json:"format"`      // e.g. "CD", "Digital Media"
 	TrackCount int     `json:"track-count"` // total tracks on this disc
 	Track      []track `json:"track"`
 }
 
 type track struct {
+	ID       string `json:"id"`
 	Number   string `json:"number"`   // display number (may be non-numeric, e.g. "A1")
 	Position int    `json:"position"` // numeric position, used when Number is non-numeric
 }
@@ -395,24 +506,32 @@ type releaseListResponse struct {
 type releaseLookupResponse struct {
 	ID           string                `json:"id"`
 	Title        string                `json:"title"`
+	Country      string                `json:"country"`
+	Barcode      string                `json:"barcode"`
+	LabelInfo    []labelInfo           `json:"label-info"`
 	ArtistCredit []artistCredit        `json:"artist-credit"`
+	ReleaseGroup releaseGroup          `json:"release-group"`
 	Media        []releaseLookupMedium `json:"media"`
 }
 
 type releaseLookupMedium struct {
 	Position int                  `json:"position"`
+	Format   string               `json:"format"`
 	Tracks   []releaseLookupTrack `json:"tracks"`
 }
 
 type releaseLookupTrack struct {
+	ID        string           `json:"id"`
 	Number    string           `json:"number"`
 	Position  int              `json:"position"`
 	Title     string           `json:"title"`
+	Length    int              `json:"length"` // milliseconds, 0 if unknown
 	Recording releaseLookupRec `json:"recording"`
 }
 
 type releaseLookupRec struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	Length int    `json:"length"`
 }
 
 // searchRelease queries MusicBrainz for releases matching album + artist.
@@ -454,7 +573,7 @@ func (c *Client) searchRelease(ctx context.Context, album, artist string) ([]rel
 func (c *Client) lookupRelease(ctx context.Context, releaseID string) (metadata.Tracklist, error) {
 	c.rateLimit()
 
-	reqURL := fmt.Sprintf("%s/release/%s?inc=recordings+artist-credits&fmt=json", c.apiURL, releaseID)
+	reqURL := fmt.Sprintf("%s/release/%s?inc=recordings+artist-credits+release-groups+labels&fmt=json", c.apiURL, releaseID)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return metadata.Tracklist{}, fmt.Errorf("failed to create release lookup request: %w", err)
@@ -479,12 +598,26 @@ func (c *Client) lookupRelease(ctx context.Context, releaseID string) (metadata.
 	}
 
 	tl := metadata.Tracklist{
-		ID:    result.ID,
-		Title: result.Title,
+		ID:             result.ID,
+		ReleaseGroupID: result.ReleaseGroup.ID,
+		Title:          result.Title,
 	}
 	if len(result.ArtistCredit) > 0 {
 		tl.Artist = result.ArtistCredit[0].Artist.Name
+		tl.ArtistSort = result.ArtistCredit[0].Artist.SortName
+	}
+	tl.Compilation = isVariousArtists(result.ArtistCredit)
+
+	var formats []string
+	for _, m := range result.Media {
+		formats = append(formats, m.Format)
 	}
+	tl.Release = releaseInfo(release{
+		Country:      result.Country,
+		Barcode:      result.Barcode,
+		LabelInfo:    result.LabelInfo,
+		ReleaseGroup: result.ReleaseGroup,
+	}, formats)
 
 	for _, m := range result.Media {
 		for _, t := range m.Tracks {
@@ -497,18 +630,72 @@ func (c *Client) lookupRelease(ctx context.Context, releaseID string) (metadata.
 				DiscNumber:  m.Position,
 				Title:       t.Title,
 				MBID:        t.Recording.ID,
+				TrackID:     t.ID,
+				Duration:    trackLength(t),
 			})
 		}
 	}
 	return tl, nil
 }
 
-// ReleaseIDsForRecording returns all release IDs that contain the given recording MBID.
-// Implements metadata.ReleaseResolver.
+// trackLength returns the length of a release track, falling back to its
+// recording's.
+func trackLength(t releaseLookupTrack) time.Duration {
+	ms := t.Length
+	if ms == 0 {
+		ms = t.Recording.Length
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ResolveAlbumCandidates implements metadata.AlbumCandidates: returns the
+// tracklists of up to limit matching releases, most preferred first. A
+// release with the same title and track count as a preferred one is another
+// pressing of the same edition and is skipped, as is one whose lookup fails;
+// an error is returned only when no release could be fetched.
+func (c *Client) ResolveAlbumCandidates(ctx context.Context, album, artist string, limit int) ([]metadata.Tracklist, error) {
+	releases, err := c.searchRelease(ctx, album, artist)
+	if err != nil {
+		return nil, fmt.Errorf("release search failed: %w", err)
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return c.prefs.compare(releases[i], releases[j], album) > 0
+	})
+
+	seen := make(map[string]bool)
+	var out []metadata.Tracklist
+	var errs []error
+	for _, rel := range releases {
+		if len(out) == limit {
+			break
+		}
+		var edition string
+		if rel.TrackCount > 0 {
+			edition = fmt.Sprintf("%s/%d", metadata.FoldText(rel.Title), rel.TrackCount)
+			if seen[edition] {
+				continue
+			}
+		}
+		tl, err := c.lookupRelease(ctx, rel.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("release lookup failed: %w", err))
+			continue
+		}
+		seen[edition] = true
+		out = append(out, tl)
+	}
+	if len(out) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// ReleaseIDsForRecording returns all release IDs that contain the given recording MBID,
+// most preferred first. Implements metadata.ReleaseResolver.
 func (c *Client) ReleaseIDsForRecording(ctx context.Context, mbid string) ([]string, error) {
 	c.rateLimit()
 
-	reqURL := fmt.Sprintf("%s/recording/%s?inc=releases&fmt=json", c.apiURL, mbid)
+	reqURL := fmt.Sprintf("%s/recording/%s?inc=releases+release-groups+media&fmt=json", c.apiURL, mbid)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create recording lookup request: %w", err)
@@ -532,6 +719,7 @@ func (c *Client) ReleaseIDsForRecording(ctx context.Context, mbid string) ([]str
 		return nil, fmt.Errorf("failed to decode recording: %w", err)
 	}
 
+	c.prefs.sort(rec.Releases)
 	ids := make([]string, 0, len(rec.Releases))
 	for _, rel := range rec.Releases {
 		ids = append(ids, rel.ID)
@@ -556,7 +744,7 @@ func (c *Client) ResolveAlbum(ctx context.Context, album, artist string) (metada
 		return metadata.Tracklist{}, false, nil
 	}
 
-	best := pickBestRelease(candidates, album)
+	best := pickBestRelease(candidates, album, c.prefs)
 	tl, err := c.lookupRelease(ctx, best.ID)
 	if err != nil {
 		return metadata.Tracklist{}, false, fmt.Errorf("release lookup failed: %w", err)
@@ -3,8 +3,11 @@ package musicbrainz
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ func newTestClient(url string) *Client {
 	return &Client{
 		httpClient:  &http.Client{Timeout: 5 * time.Second},
 		apiURL:      url,
+		prefs:       DefaultReleasePreferences(),
 		lastRequest: time.Now().Add(-2 * time.Second), // avoid rate limit in tests
 	}
 }
@@ -38,7 +42,8 @@ func TestSearch_ParsesResponse(t *testing.T) {
 					"title": "A Night at the Opera",
 					"date": "1975-10-31",
 					"artist-credit": [{"artist": {"id": "a1", "name": "Queen"}}],
-					"media": [{"position": 1, "track-count": 12, "track": [{"number": "11", "position": 11}]}]
+					"release-group": {"id": "rg-1", "primary-type": "Album"},
+					"media": [{"position": 1, "track-count": 12, "track": [{"id": "trk-11", "number": "11", "position": 11}]}]
 				}],
 				"isrcs": ["GBUM71029604"]
 			}]
@@ -78,6 +83,17 @@ func TestSearch_ParsesResponse(t *testing.T) {
 	if r.AlbumArtist != "Queen" {
 		t.Errorf("AlbumArtist = %q, want %q", r.AlbumArtist, "Queen")
 	}
+	wantIDs := metadata.MusicBrainzIDs{
+		RecordingID:    "rec-1",
+		TrackID:        "trk-11",
+		ReleaseID:      "rel-1",
+		ReleaseGroupID: "rg-1",
+		ArtistIDs:      []string{"a1"},
+		AlbumArtistIDs: []string{"a1"},
+	}
+	if !reflect.DeepEqual(r.MusicBrainz, wantIDs) {
+		t.Errorf("MusicBrainz = %+v, want %+v", r.MusicBrainz, wantIDs)
+	}
 	if r.Year != 1975 {
 		t.Errorf("Year = %d, want 1975", r.Year)
 	}
@@ -184,6 +200,73 @@ func TestSearch_MultipleArtistCredits(t *testing.T) {
 	}
 }
 
+func TestSearch_ArtistCreditJoinPhrases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"recordings": [{
+				"id": "rec-4",
+				"title": "Get Lucky",
+				"artist-credit": [
+					{"name": "Daft Punk", "joinphrase": " feat. ", "artist": {"id": "a1", "name": "Daft Punk"}},
+					{"name": "Pharrell", "joinphrase": " & ", "artist": {"id": "a2", "name": "Pharrell Williams"}},
+					{"name": "Nile Rodgers", "joinphrase": "", "artist": {"id": "a3", "name": "Nile Rodgers"}}
+				]
+			}]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	results, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Get Lucky"})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if r.Artist != "Daft Punk feat. Pharrell & Nile Rodgers" {
+		t.Errorf("Artist = %q, want %q", r.Artist, "Daft Punk feat. Pharrell & Nile Rodgers")
+	}
+	if len(r.Artists) != 3 {
+		t.Fatalf("expected 3 artist credits, got %d", len(r.Artists))
+	}
+	if r.Artists[0].JoinPhrase != " feat. " {
+		t.Errorf("Artists[0].JoinPhrase = %q, want %q", r.Artists[0].JoinPhrase, " feat. ")
+	}
+	if r.Artists[1].Name != "Pharrell" {
+		t.Errorf("Artists[1].Name = %q, want credited name %q", r.Artists[1].Name, "Pharrell")
+	}
+}
+
+func TestSearch_LiveDisambiguation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"recordings": [{
+				"id": "rec-3",
+				"title": "Bohemian Rhapsody",
+				"disambiguation": "live, 1985-07-13: Wembley Stadium, London, UK",
+				"artist-credit": [{"artist": {"id": "a1", "name": "Queen"}}]
+			}]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	results, err := c.Search(context.Background(), metadata.SearchQuery{Title: "Bohemian Rhapsody"})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Version.Kind != metadata.VersionLive {
+		t.Errorf("Version.Kind = %q, want %q", results[0].Version.Kind, metadata.VersionLive)
+	}
+}
+
 func TestPickBestRelease(t *testing.T) {
 	compilation := release{
 		ID:     "comp-1",
@@ -318,7 +401,7 @@ func TestPickBestRelease(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := pickBestRelease(tt.releases, "")
+			got := pickBestRelease(tt.releases, "", DefaultReleasePreferences())
 			if got.ID != tt.wantID {
 				t.Errorf("pickBestRelease() picked %q (%s), want %q", got.Title, got.ID, tt.wantID)
 			}
@@ -327,7 +410,7 @@ func TestPickBestRelease(t *testing.T) {
 
 	for _, tt := range albumVariantTests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := pickBestRelease(tt.releases, tt.prefer)
+			got := pickBestRelease(tt.releases, tt.prefer, DefaultReleasePreferences())
 			if got.ID != tt.wantID {
 				t.Errorf("pickBestRelease() picked %q (%s), want %q", got.Title, got.ID, tt.wantID)
 			}
@@ -335,6 +418,15 @@ func TestPickBestRelease(t *testing.T) {
 	}
 }
 
+func TestReleaseAlbumSim_FoldsUnicode(t *testing.T) {
+	if got := releaseAlbumSim("Ágætis byrjun", "Agaetis Byrjun"); got != 1.0 {
+		t.Errorf("releaseAlbumSim = %.2f, want 1.0", got)
+	}
+	if got := releaseAlbumSim("ＬＰ！", "LP!"); got != 1.0 {
+		t.Errorf("releaseAlbumSim = %.2f, want 1.0 for full-width title", got)
+	}
+}
+
 func TestBuildQuery(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -488,12 +580,13 @@ func TestLookupRelease_ReturnsTracklist(t *testing.T) {
 			"id": "release-lp",
 			"title": "LP!",
 			"artist-credit": [{"artist": {"id": "a1", "name": "JPEGMAFIA"}}],
+			"release-group": {"id": "rg-lp"},
 			"media": [
 				{
 					"position": 1,
 					"track-count": 3,
 					"tracks": [
-						{"number": "1", "position": 1, "title": "TRUST!", "recording": {"id": "rec-1"}},
+						{"id": "trk-1", "number": "1", "position": 1, "title": "TRUST!", "recording": {"id": "rec-1"}},
 						{"number": "2", "position": 2, "title": "DIRTY!", "recording": {"id": "rec-2"}},
 						{"number": "3", "position": 3, "title": "NEMO!",  "recording": {"id": "rec-3"}}
 					]
@@ -523,6 +616,86 @@ func TestLookupRelease_ReturnsTracklist(t *testing.T) {
 	if tl.Tracks[2].MBID != "rec-3" {
 		t.Errorf("track 2 MBID = %q, want rec-3", tl.Tracks[2].MBID)
 	}
+	if tl.ReleaseGroupID != "rg-lp" {
+		t.Errorf("ReleaseGroupID = %q, want rg-lp", tl.ReleaseGroupID)
+	}
+	if tl.Tracks[0].TrackID != "trk-1" {
+		t.Errorf("track 0 TrackID = %q, want trk-1", tl.Tracks[0].TrackID)
+	}
+}
+
+func TestLookupRelease_ReleaseDetails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("inc"); !strings.Contains(got, "labels") {
+			t.Errorf("inc = %q, want labels included", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "release-ok",
+			"title": "OK Computer",
+			"country": "GB",
+			"barcode": "724385522925",
+			"label-info": [{"catalog-number": "NODATA 02", "label": {"name": "Parlophone"}}],
+			"release-group": {"id": "rg-ok", "primary-type": "Album", "secondary-types": [], "first-release-date": "1997-05-21"},
+			"media": [
+				{"position": 1, "format": "CD", "tracks": [{"number": "1", "position": 1, "title": "Airbag", "recording": {"id": "rec-1"}}]},
+				{"position": 2, "format": "CD", "tracks": [{"number": "1", "position": 1, "title": "Lull", "recording": {"id": "rec-2"}}]}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	tl, err := c.lookupRelease(context.Background(), "release-ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := metadata.ReleaseInfo{
+		Label:         "Parlophone",
+		CatalogNumber: "NODATA 02",
+		Barcode:       "724385522925",
+		Country:       "GB",
+		Types:         []string{"album"},
+		Media:         "CD",
+		OriginalDate:  "1997-05-21",
+	}
+	if !reflect.DeepEqual(tl.Release, want) {
+		t.Errorf("Release = %+v, want %+v", tl.Release, want)
+	}
+}
+
+func TestReleaseInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		rel     release
+		formats []string
+		want    metadata.ReleaseInfo
+	}{
+		{
+			name:    "secondary types follow the primary type",
+			rel:     release{ReleaseGroup: releaseGroup{PrimaryType: "Album", SecondaryTypes: []string{"Compilation", "Live"}}},
+			formats: []string{"Digital Media"},
+			want:    metadata.ReleaseInfo{Types: []string{"album", "compilation", "live"}, Media: "Digital Media"},
+		},
+		{
+			name:    "mixed formats",
+			rel:     release{},
+			formats: []string{"CD", "CD", "DVD-Video"},
+			want:    metadata.ReleaseInfo{Media: "CD + DVD-Video"},
+		},
+		{
+			name: "no catalog number",
+			rel:  release{LabelInfo: []labelInfo{{CatalogNumber: "[none]"}}},
+			want: metadata.ReleaseInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releaseInfo(tt.rel, tt.formats); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("releaseInfo = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestLookupRelease_MultiDisc(t *testing.T) {
@@ -595,6 +768,99 @@ func TestResolveAlbum_ReturnsBestMatchingTracklist(t *testing.T) {
 	}
 }
 
+func TestResolveAlbumCandidates_SkipsOtherPressings(t *testing.T) {
+	var lookups []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/release", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"releases": [
+			{"id": "r-us", "title": "LP!", "status": "Official", "track-count": 2, "release-group": {"primary-type": "Album"}},
+			{"id": "r-gb", "title": "LP!", "status": "Official", "track-count": 2, "release-group": {"primary-type": "Album"}},
+			{"id": "r-deluxe", "title": "LP! (Deluxe)", "status": "Official", "track-count": 3, "release-group": {"primary-type": "Album"}}
+		]}`))
+	})
+	mux.HandleFunc("/release/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/release/")
+		lookups = append(lookups, id)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": %q, "title": "LP!", "media": [{"position": 1, "tracks": [
+			{"number": "1", "position": 1, "title": "TRUST!", "length": 120000, "recording": {"id": "rec-1"}},
+			{"number": "2", "position": 2, "title": "HAZARD DUTY PAY!", "recording": {"id": "rec-2", "length": 150000}}
+		]}]}`, id)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	var _ metadata.AlbumCandidates = c
+	tls, err := c.ResolveAlbumCandidates(context.Background(), "LP!", "JPEGMAFIA", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(lookups, ",") != "r-us,r-deluxe" {
+		t.Errorf("looked up %v, want r-us and r-deluxe", lookups)
+	}
+	if len(tls) != 2 || tls[0].ID != "r-us" {
+		t.Fatalf("candidates = %+v", tls)
+	}
+	if d := tls[0].Tracks[0].Duration; d != 2*time.Minute {
+		t.Errorf("track length = %v, want 2m", d)
+	}
+	if d := tls[0].Tracks[1].Duration; d != 150*time.Second {
+		t.Errorf("recording length fallback = %v, want 2m30s", d)
+	}
+}
+
+func TestResolveAlbumCandidates_SkipsFailedLookups(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing map[string]bool
+		want    []string
+		wantErr bool
+	}{
+		{"one lookup fails", map[string]bool{"r-std": true}, []string{"r-deluxe"}, false},
+		{"every lookup fails", map[string]bool{"r-std": true, "r-deluxe": true}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/release", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"releases": [
+					{"id": "r-std", "title": "LP!", "status": "Official", "track-count": 2, "release-group": {"primary-type": "Album"}},
+					{"id": "r-deluxe", "title": "LP! (Deluxe)", "status": "Official", "track-count": 3, "release-group": {"primary-type": "Album"}}
+				]}`))
+			})
+			mux.HandleFunc("/release/", func(w http.ResponseWriter, r *http.Request) {
+				id := strings.TrimPrefix(r.URL.Path, "/release/")
+				if tt.failing[id] {
+					http.Error(w, "boom", http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"id": %q, "title": "LP!", "media": [{"position": 1, "tracks": [
+					{"number": "1", "position": 1, "title": "TRUST!", "recording": {"id": "rec-1"}}
+				]}]}`, id)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			c := newTestClient(srv.URL)
+			tls, err := c.ResolveAlbumCandidates(context.Background(), "LP!", "JPEGMAFIA", 5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, tl := range tls {
+				ids = append(ids, tl.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("candidates = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
 func TestResolveAlbum_NotFound(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -685,3 +951,96 @@ func TestLookupTracklist_DelegatesToLookupRelease(t *testing.T) {
 		t.Errorf("unexpected tracks: %+v", tl.Tracks)
 	}
 }
+
+func TestLookupRelease_VariousArtistsIsCompilation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "release-va",
+			"title": "Drive (Original Motion Picture Soundtrack)",
+			"artist-credit": [{"name": "Various Artists", "artist": {"id": "89ad4ac3-39f7-470e-963a-56509c546377", "name": "Various Artists"}}],
+			"media": [{"position": 1, "tracks": [{"number": "1", "position": 1, "title": "Nightcall", "recording": {"id": "rec-1"}}]}]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	tl, err := c.lookupRelease(context.Background(), "release-va")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tl.Compilation {
+		t.Error("various artists release not marked as compilation")
+	}
+}
+
+func TestIsVariousArtists(t *testing.T) {
+	tests := []struct {
+		name    string
+		credits []artistCredit
+		want    bool
+	}{
+		{"by id", []artistCredit{{Artist: artistInfo{ID: variousArtistsID, Name: "Verschiedene Interpreten"}}}, true},
+		{"by name", []artistCredit{{Artist: artistInfo{Name: "Various Artists"}}}, true},
+		{"single artist", []artistCredit{{Artist: artistInfo{ID: "a1", Name: "Kavinsky"}}}, false},
+		{"no credit", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVariousArtists(tt.credits); got != tt.want {
+				t.Errorf("isVariousArtists = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToArtistCredits_SortNames(t *testing.T) {
+	credits := toArtistCredits([]artistCredit{
+		{Name: "The Beatles", JoinPhrase: " & ", Artist: artistInfo{Name: "The Beatles", SortName: "Beatles, The"}},
+		{Artist: artistInfo{Name: "米津玄師", SortName: "Yonezu, Kenshi"}},
+	})
+	want := []metadata.ArtistCredit{
+		{Name: "The Beatles", JoinPhrase: " & ", SortName: "Beatles, The"},
+		{Name: "米津玄師", SortName: "Yonezu, Kenshi"},
+	}
+	if !reflect.DeepEqual(credits, want) {
+		t.Errorf("credits = %+v, want %+v", credits, want)
+	}
+}
+
+func TestLookupByISRC(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/isrc/GBAYE6900524":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{
+				"isrc": "GBAYE6900524",
+				"recordings": [{
+					"id": "rec-1", "title": "Come Together", "length": 259000,
+					"artist-credit": [{"name": "The Beatles", "artist": {"id": "a-1", "name": "The Beatles", "sort-name": "Beatles, The"}}],
+					"releases": [{"id": "rel-1", "title": "Abbey Road", "status": "Official", "date": "1969-09-26", "release-group": {"primary-type": "Album"}}]
+				}]
+			}`))
+		default:
+			http.Error(w, `{"error": "Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	c.artworkBaseURL = srv.URL + "/art"
+	var _ metadata.ISRCLookup = c
+
+	results, err := c.LookupByISRC(context.Background(), "GBAYE6900524")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Come Together" || results[0].Album != "Abbey Road" {
+		t.Errorf("results = %+v, want Come Together on Abbey Road", results)
+	}
+
+	results, err = c.LookupByISRC(context.Background(), "ZZZZZ0000000")
+	if err != nil || len(results) != 0 {
+		t.Errorf("unknown ISRC: results=%v err=%v, want none", results, err)
+	}
+}
@@ -0,0 +1,130 @@
+package musicbrainz
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReleasePreferences decides which release is used when a recording or album
+// appears on several. List entries are matched case-insensitively; earlier
+// entries are preferred and unlisted values rank after all listed ones.
+type ReleasePreferences struct {
+	PrimaryTypes     []string // release-group primary types, e.g. "Album", "EP", "Single"
+	ExcludeSecondary []string // secondary types to avoid, e.g. "Compilation", "Live"
+	Countries        []string // release countries, e.g. "US", "GB", "XW"
+	Formats          []string // medium formats, e.g. "Digital Media", "CD"
+	Latest           bool     // prefer the latest release date instead of the earliest
+}
+
+// SecondaryTypes lists MusicBrainz's release-group secondary types.
+var SecondaryTypes = []string{
+	"Compilation", "Soundtrack", "Spokenword", "Interview", "Audiobook", "Audio drama",
+	"Live", "Remix", "DJ-mix", "Mixtape/Street", "Demo", "Field recording",
+}
+
+// DefaultReleasePreferences prefers albums without any secondary type, from
+// any country and in any format, earliest first.
+func DefaultReleasePreferences() ReleasePreferences {
+	return ReleasePreferences{
+		PrimaryTypes:     []string{"Album"},
+		ExcludeSecondary: SecondaryTypes,
+	}
+}
+
+// compare returns a positive number if a is the better release, negative if
+// b is, and 0 if they are equivalent. Criteria in order: official status, no
+// excluded secondary type, primary type, title similarity to preferAlbum,
+// track position data, country, medium format, release date.
+func (p ReleasePreferences) compare(a, b release, preferAlbum string) int {
+	if c := boolCmp(a.Status == "Official", b.Status == "Official"); c != 0 {
+		return c
+	}
+	if c := boolCmp(!p.excluded(a), !p.excluded(b)); c != 0 {
+		return c
+	}
+	if c := rank(p.PrimaryTypes, b.ReleaseGroup.PrimaryType) - rank(p.PrimaryTypes, a.ReleaseGroup.PrimaryType); c != 0 {
+		return c
+	}
+	if simA, simB := releaseAlbumSim(a.Title, preferAlbum), releaseAlbumSim(b.Title, preferAlbum); simA != simB {
+		if simA > simB {
+			return 1
+		}
+		return -1
+	}
+	if c := boolCmp(hasTrack(a), hasTrack(b)); c != 0 {
+		return c
+	}
+	if c := rank(p.Countries, b.Country) - rank(p.Countries, a.Country); c != 0 {
+		return c
+	}
+	if c := p.formatRank(b) - p.formatRank(a); c != 0 {
+		return c
+	}
+	return p.compareDates(a.Date, b.Date)
+}
+
+// excluded reports whether rel has a secondary type the preferences avoid.
+func (p ReleasePreferences) excluded(rel release) bool {
+	for _, t := range rel.ReleaseGroup.SecondaryTypes {
+		if rank(p.ExcludeSecondary, t) < len(p.ExcludeSecondary) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatRank is the rank of the best-preferred medium format of rel.
+func (p ReleasePreferences) formatRank(rel release) int {
+	best := len(p.Formats)
+	for _, m := range rel.Media {
+		best = min(best, rank(p.Formats, m.Format))
+	}
+	return best
+}
+
+// compareDates prefers a known date over none, then the earlier (or later)
+// one. Partial dates compare as strings, so "2020" sorts before "2020-03".
+func (p ReleasePreferences) compareDates(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	case (a < b) != p.Latest:
+		return 1
+	}
+	return -1
+}
+
+// sort orders releases best first.
+func (p ReleasePreferences) sort(releases []release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		return p.compare(releases[i], releases[j], "") > 0
+	})
+}
+
+// rank returns the index of v in list ignoring case, or len(list).
+func rank(list []string, v string) int {
+	for i, s := range list {
+		if strings.EqualFold(s, v) {
+			return i
+		}
+	}
+	return len(list)
+}
+
+func boolCmp(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+func hasTrack(rel release) bool {
+	return len(rel.Media) > 0 && len(rel.Media[0].Track) > 0
+}
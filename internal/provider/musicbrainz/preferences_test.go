@@ -0,0 +1,111 @@
+package musicbrainz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPickBestRelease_Preferences(t *testing.T) {
+	official := func(id, date, country, format, primary string, secondary ...string) release {
+		return release{
+			ID: id, Title: "Album", Status: "Official", Date: date, Country: country,
+			ReleaseGroup: releaseGroup{PrimaryType: primary, SecondaryTypes: secondary},
+			Media:        []media{{Format: format}},
+		}
+	}
+	original := official("original", "2019-05-17", "US", "Digital Media", "Album")
+	japan := official("japan", "2019-05-15", "JP", "CD", "Album")
+	live := official("live", "2018-01-01", "US", "CD", "Album", "Live")
+	ep := official("ep", "2017-01-01", "US", "Digital Media", "EP")
+
+	tests := []struct {
+		name     string
+		prefs    ReleasePreferences
+		releases []release
+		wantID   string
+	}{
+		{
+			name:     "defaults pick the earliest",
+			prefs:    DefaultReleasePreferences(),
+			releases: []release{original, japan},
+			wantID:   "japan",
+		},
+		{
+			name:     "preferred country beats an earlier bonus edition",
+			prefs:    ReleasePreferences{Countries: []string{"US", "GB"}},
+			releases: []release{japan, original},
+			wantID:   "original",
+		},
+		{
+			name:     "preferred format",
+			prefs:    ReleasePreferences{Formats: []string{"digital media"}},
+			releases: []release{japan, original},
+			wantID:   "original",
+		},
+		{
+			name:     "latest",
+			prefs:    ReleasePreferences{Latest: true},
+			releases: []release{japan, original},
+			wantID:   "original",
+		},
+		{
+			name:     "excluded secondary type loses to any other",
+			prefs:    ReleasePreferences{PrimaryTypes: []string{"Album"}, ExcludeSecondary: []string{"live"}},
+			releases: []release{live, ep},
+			wantID:   "ep",
+		},
+		{
+			name:     "primary type order",
+			prefs:    ReleasePreferences{PrimaryTypes: []string{"EP", "Album"}},
+			releases: []release{original, ep},
+			wantID:   "ep",
+		},
+		{
+			name:     "live allowed when not excluded",
+			prefs:    ReleasePreferences{PrimaryTypes: []string{"Album", "EP"}},
+			releases: []release{ep, live},
+			wantID:   "live",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickBestRelease(tt.releases, "", tt.prefs); got.ID != tt.wantID {
+				t.Errorf("picked %s, want %s", got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestReleaseIDsForRecording_PreferenceOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("inc"); got != "releases release-groups media" {
+			t.Errorf("inc = %q, want release groups and media included", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "rec-1",
+			"releases": [
+				{"id": "rel-comp", "status": "Official", "date": "2010", "release-group": {"primary-type": "Album", "secondary-types": ["Compilation"]}},
+				{"id": "rel-jp", "status": "Official", "date": "2008-03-01", "country": "JP", "release-group": {"primary-type": "Album"}},
+				{"id": "rel-us", "status": "Official", "date": "2008-03-04", "country": "US", "release-group": {"primary-type": "Album"}}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL).WithReleasePreferences(ReleasePreferences{
+		PrimaryTypes:     []string{"Album"},
+		ExcludeSecondary: []string{"Compilation"},
+		Countries:        []string{"US"},
+	})
+	ids, err := c.ReleaseIDsForRecording(context.Background(), "rec-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"rel-us", "rel-jp", "rel-comp"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
@@ -3,10 +3,13 @@ package spotify
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,7 +30,8 @@ type Client struct {
 	tokenExpiry time.Time
 
 	cacheMu    sync.Mutex
-	genreCache map[string][]string // artist ID → genres
+	genreCache map[string][]string           // artist ID → genres
+	albumCache map[string]metadata.Tracklist // album ID → tracklist
 
 	// Overridable for testing
 	tokenURL string
@@ -41,6 +45,7 @@ func New(clientID, clientSecret string) *Client {
 		clientSecret: clientSecret,
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
 		genreCache:   make(map[string][]string),
+		albumCache:   make(map[string]metadata.Tracklist),
 		tokenURL:     "https://accounts.spotify.com/api/token",
 		apiURL:       "https://api.spotify.com/v1",
 	}
@@ -54,7 +59,17 @@ func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]meta
 	if q == "" {
 		return nil, nil
 	}
+	return c.searchTracks(ctx, q)
+}
+
+// LookupByISRC returns the tracks with the given ISRC.
+// Implements metadata.ISRCLookup.
+func (c *Client) LookupByISRC(ctx context.Context, isrc string) ([]metadata.TrackInfo, error) {
+	return c.searchTracks(ctx, "isrc:"+isrc)
+}
 
+// searchTracks runs a track search for q and enriches the results with genres.
+func (c *Client) searchTracks(ctx context.Context, q string) ([]metadata.TrackInfo, error) {
 	token, err := c.getToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("spotify auth failed: %w", err)
@@ -91,6 +106,204 @@ func (c *Client) Search(ctx context.Context, query metadata.SearchQuery) ([]meta
 	return results, nil
 }
 
+// ResolveAlbum implements metadata.AlbumResolver: searches for the album and
+// returns its tracklist with disc numbers.
+func (c *Client) ResolveAlbum(ctx context.Context, album, artist string) (metadata.Tracklist, bool, error) {
+	albums, err := c.searchAlbums(ctx, album, artist)
+	if err != nil || len(albums) == 0 {
+		return metadata.Tracklist{}, false, err
+	}
+	tl, err := c.albumTracklist(ctx, albums[0].ID)
+	if err != nil {
+		return metadata.Tracklist{}, false, err
+	}
+	return tl, true, nil
+}
+
+// ResolveAlbumCandidates implements metadata.AlbumCandidates: returns the
+// tracklists of up to limit albums from the album search, most similar to
+// album first. An album with the same title and track count as an earlier
+// one is another release of the same edition and is skipped, as is one whose
+// lookup fails; an error is returned only when no album could be fetched.
+func (c *Client) ResolveAlbumCandidates(ctx context.Context, album, artist string, limit int) ([]metadata.Tracklist, error) {
+	albums, err := c.searchAlbums(ctx, album, artist)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var out []metadata.Tracklist
+	var errs []error
+	for _, a := range albums {
+		if len(out) == limit {
+			break
+		}
+		var edition string
+		if a.TotalTracks > 0 {
+			edition = fmt.Sprintf("%s/%d", metadata.FoldText(a.Name), a.TotalTracks)
+			if seen[edition] {
+				continue
+			}
+		}
+		tl, err := c.albumTracklist(ctx, a.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		seen[edition] = true
+		out = append(out, tl)
+	}
+	if len(out) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// searchAlbums searches for album by artist, ordered by rankAlbums.
+func (c *Client) searchAlbums(ctx context.Context, album, artist string) ([]albumInfo, error) {
+	q := "album:" + album
+	if artist != "" {
+		q += " artist:" + artist
+	}
+	var resp albumSearchResponse
+	if err := c.get(ctx, "/search?type=album&limit=10&q="+url.QueryEscape(q), &resp); err != nil {
+		return nil, fmt.Errorf("spotify album search failed: %w", err)
+	}
+	return rankAlbums(resp.Albums.Items, album), nil
+}
+
+// AlbumDetails implements metadata.AlbumDetailsResolver: sets the track
+// total of the match's disc, the disc total, label and barcode from its
+// album. Albums are cached, so the tracks of one album cost a single lookup.
+func (c *Client) AlbumDetails(ctx context.Context, info metadata.TrackInfo) (metadata.TrackInfo, error) {
+	if info.AlbumID == "" {
+		return info, nil
+	}
+	tl, err := c.albumTracklist(ctx, info.AlbumID)
+	if err != nil {
+		return info, err
+	}
+	if tracks, discs := totals(tl, info.DiscNumber); tracks > 0 {
+		info.TotalTracks, info.TotalDiscs = tracks, discs
+	}
+	info.Release.Label = tl.Release.Label
+	info.Release.Barcode = tl.Release.Barcode
+	return info, nil
+}
+
+// albumTracklist returns the full tracklist of an album, using the cache
+// when available.
+func (c *Client) albumTracklist(ctx context.Context, albumID string) (metadata.Tracklist, error) {
+	c.cacheMu.Lock()
+	if tl, ok := c.albumCache[albumID]; ok {
+		c.cacheMu.Unlock()
+		return tl, nil
+	}
+	c.cacheMu.Unlock()
+
+	var album albumResponse
+	if err := c.get(ctx, "/albums/"+url.PathEscape(albumID), &album); err != nil {
+		return metadata.Tracklist{}, fmt.Errorf("spotify album lookup failed: %w", err)
+	}
+	// Long albums page their tracks 50 at a time.
+	for len(album.Tracks.Items) < album.Tracks.Total {
+		var page trackPage
+		path := fmt.Sprintf("/albums/%s/tracks?limit=50&offset=%d", url.PathEscape(albumID), len(album.Tracks.Items))
+		if err := c.get(ctx, path, &page); err != nil {
+			return metadata.Tracklist{}, fmt.Errorf("spotify album tracks lookup failed: %w", err)
+		}
+		if len(page.Items) == 0 {
+			break
+		}
+		album.Tracks.Items = append(album.Tracks.Items, page.Items...)
+	}
+
+	tl := toTracklist(album)
+	c.cacheMu.Lock()
+	c.albumCache[albumID] = tl
+	c.cacheMu.Unlock()
+	return tl, nil
+}
+
+// get fetches path from the API and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return fmt.Errorf("spotify auth failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("returned %d: %s", resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// rankAlbums drops the albums whose name is below
+// metadata.AlbumMatchThreshold against album and orders the rest most similar
+// first, keeping the search order on ties.
+func rankAlbums(albums []albumInfo, album string) []albumInfo {
+	ranked := slices.DeleteFunc(slices.Clone(albums), func(a albumInfo) bool {
+		return metadata.Similarity(a.Name, album) < metadata.AlbumMatchThreshold
+	})
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return metadata.Similarity(ranked[i].Name, album) > metadata.Similarity(ranked[j].Name, album)
+	})
+	return ranked
+}
+
+func toTracklist(album albumResponse) metadata.Tracklist {
+	tl := metadata.Tracklist{
+		ID:          album.ID,
+		Title:       album.Name,
+		Compilation: album.AlbumType == "compilation",
+		Release: metadata.ReleaseInfo{
+			Label:   album.Label,
+			Barcode: album.ExternalIDs.UPC,
+		},
+	}
+	if len(album.Artists) > 0 {
+		tl.Artist = album.Artists[0].Name
+	}
+	if album.AlbumType != "" {
+		tl.Release.Types = []string{album.AlbumType}
+	}
+	for _, t := range album.Tracks.Items {
+		tl.Tracks = append(tl.Tracks, metadata.ReleaseTrack{
+			TrackNumber: t.TrackNumber,
+			DiscNumber:  t.DiscNumber,
+			Title:       t.Name,
+			Duration:    time.Duration(t.DurationMs) * time.Millisecond,
+		})
+	}
+	return tl
+}
+
+// totals returns the number of tracks on disc and the number of discs of tl.
+func totals(tl metadata.Tracklist, disc int) (tracks, discs int) {
+	for _, t := range tl.Tracks {
+		if t.DiscNumber == disc {
+			tracks++
+		}
+		discs = max(discs, t.DiscNumber)
+	}
+	return tracks, discs
+}
+
 // enrichGenres fetches genres for primary artists and sets them on results.
 // Uses an internal cache to avoid redundant API calls.
 func (c *Client) enrichGenres(ctx context.Context, results []metadata.TrackInfo, resp searchResponse) {
@@ -263,9 +476,13 @@ func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
 func parseSearchResults(resp searchResponse) []metadata.TrackInfo {
 	var results []metadata.TrackInfo
 	for _, item := range resp.Tracks.Items {
-		var artists []string
-		for _, a := range item.Artists {
-			artists = append(artists, a.Name)
+		var credits []metadata.ArtistCredit
+		for i, a := range item.Artists {
+			credit := metadata.ArtistCredit{Name: a.Name}
+			if i < len(item.Artists)-1 {
+				credit.JoinPhrase = ", "
+			}
+			credits = append(credits, credit)
 		}
 
 		var albumArtist string
@@ -278,18 +495,29 @@ func parseSearchResults(resp searchResponse) []metadata.TrackInfo {
 			artworkURL = item.Album.Images[0].URL
 		}
 
+		var release metadata.ReleaseInfo
+		if item.Album.AlbumType != "" {
+			release.Types = []string{item.Album.AlbumType}
+		}
+
+		// The album's total_tracks counts every disc, and a search result
+		// can't tell whether there are others, so per-disc totals come from
+		// AlbumDetails or ResolveAlbum only.
 		info := metadata.TrackInfo{
 			Title:       item.Name,
-			Artist:      strings.Join(artists, ", "),
+			Artist:      metadata.JoinArtists(credits),
+			Artists:     credits,
 			Album:       item.Album.Name,
+			AlbumID:     item.Album.ID,
 			AlbumArtist: albumArtist,
 			TrackNumber: item.TrackNumber,
-			TotalTracks: item.Album.TotalTracks,
 			DiscNumber:  item.DiscNumber,
+			Compilation: item.Album.AlbumType == "compilation",
 			Year:        parseYear(item.Album.ReleaseDate),
 			ReleaseDate: item.Album.ReleaseDate,
 			ISRC:        item.ExternalIDs.ISRC,
 			ArtworkURL:  artworkURL,
+			Release:     release,
 			Duration:    time.Duration(item.DurationMs) * time.Millisecond,
 		}
 		results = append(results, info)
@@ -336,13 +564,36 @@ type artist struct {
 }
 
 type albumInfo struct {
+	ID          string   `json:"id"`
 	Name        string   `json:"name"`
+	AlbumType   string   `json:"album_type"` // "album", "single" or "compilation"
 	Artists     []artist `json:"artists"`
 	ReleaseDate string   `json:"release_date"`
 	TotalTracks int      `json:"total_tracks"`
 	Images      []image  `json:"images"`
 }
 
+type albumSearchResponse struct {
+	Albums struct {
+		Items []albumInfo `json:"items"`
+	} `json:"albums"`
+}
+
+// albumResponse is a full album object, with its first page of tracks.
+type albumResponse struct {
+	albumInfo
+	Label       string `json:"label"`
+	ExternalIDs struct {
+		UPC string `json:"upc"`
+	} `json:"external_ids"`
+	Tracks trackPage `json:"tracks"`
+}
+
+type trackPage struct {
+	Items []trackItem `json:"items"` // without album
+	Total int         `json:"total"`
+}
+
 type image struct {
 	URL    string `json:"url"`
 	Width  int    `json:"width"`
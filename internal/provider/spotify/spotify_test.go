@@ -3,9 +3,13 @@ package spotify
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"ytmusic/internal/metadata"
 )
@@ -98,8 +102,8 @@ func TestSearch(t *testing.T) {
 	if r.TrackNumber != 9 {
 		t.Errorf("track = %d, want 9", r.TrackNumber)
 	}
-	if r.TotalTracks != 14 {
-		t.Errorf("total_tracks = %d, want 14", r.TotalTracks)
+	if r.TotalTracks != 0 {
+		t.Errorf("total_tracks = %d, want 0 (left to AlbumDetails)", r.TotalTracks)
 	}
 	if r.ISRC != "USUG12000497" {
 		t.Errorf("isrc = %q, want %q", r.ISRC, "USUG12000497")
@@ -109,6 +113,64 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestParseSearchResults_MultipleArtists(t *testing.T) {
+	resp := searchResponse{}
+	resp.Tracks.Items = []trackItem{
+		{
+			Name:    "Get Lucky (feat. Pharrell Williams & Nile Rodgers)",
+			Artists: []artist{{Name: "Daft Punk"}, {Name: "Pharrell Williams"}, {Name: "Nile Rodgers"}},
+		},
+	}
+
+	results := parseSearchResults(resp)
+	r := results[0]
+	if r.Artist != "Daft Punk, Pharrell Williams, Nile Rodgers" {
+		t.Errorf("artist = %q", r.Artist)
+	}
+	if len(r.Artists) != 3 {
+		t.Fatalf("expected 3 artist credits, got %d", len(r.Artists))
+	}
+	if r.Artists[0].Name != "Daft Punk" || r.Artists[2].JoinPhrase != "" {
+		t.Errorf("unexpected credits: %+v", r.Artists)
+	}
+}
+
+func TestParseSearchResults_Compilation(t *testing.T) {
+	resp := searchResponse{}
+	resp.Tracks.Items = []trackItem{
+		{Name: "Nightcall", Album: albumInfo{Name: "Drive", AlbumType: "compilation", TotalTracks: 19}},
+		{Name: "Nightcall", Album: albumInfo{Name: "OutRun", AlbumType: "album"}},
+	}
+
+	results := parseSearchResults(resp)
+	if !results[0].Compilation {
+		t.Errorf("compilation album parsed as %+v", results[0])
+	}
+	if results[1].Compilation {
+		t.Error("regular album marked as compilation")
+	}
+	if got := results[0].Release.Types; len(got) != 1 || got[0] != "compilation" {
+		t.Errorf("release types = %v, want [compilation]", got)
+	}
+}
+
+func TestParseSearchResults_DiscTotals(t *testing.T) {
+	resp := searchResponse{}
+	resp.Tracks.Items = []trackItem{
+		{Name: "Intro", DiscNumber: 1, Album: albumInfo{Name: "Double", TotalTracks: 20}},
+		{Name: "Reprise", DiscNumber: 2, Album: albumInfo{Name: "Double", TotalTracks: 20}},
+	}
+
+	// total_tracks counts both discs, so neither result may take it as
+	// its disc's total.
+	results := parseSearchResults(resp)
+	for _, r := range results {
+		if r.TotalTracks != 0 || r.TotalDiscs != 0 {
+			t.Errorf("%s: totals = %d/%d, want none", r.Title, r.TotalTracks, r.TotalDiscs)
+		}
+	}
+}
+
 func TestSearchEmptyQuery(t *testing.T) {
 	client := New("id", "secret")
 	results, err := client.Search(context.Background(), metadata.SearchQuery{})
@@ -210,3 +272,244 @@ func TestParseYear(t *testing.T) {
 		}
 	}
 }
+
+func TestLookupByISRC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "test-token", TokenType: "Bearer", ExpiresIn: 3600})
+	})
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "isrc:USUG12000497" {
+			t.Errorf("q = %q, want isrc:USUG12000497", q)
+		}
+		resp := searchResponse{}
+		resp.Tracks.Items = []trackItem{{Name: "Blinding Lights", Artists: []artist{{Name: "The Weeknd"}}, ExternalIDs: externalID{ISRC: "USUG12000497"}}}
+		json.NewEncoder(w).Encode(resp)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := New("test-id", "test-secret")
+	client.tokenURL = server.URL + "/api/token"
+	client.apiURL = server.URL + "/v1"
+
+	var _ metadata.ISRCLookup = client
+	results, err := client.LookupByISRC(context.Background(), "USUG12000497")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Blinding Lights" {
+		t.Errorf("results = %+v, want Blinding Lights", results)
+	}
+}
+
+// newAlbumServer serves a two-disc album whose tracks come in two pages.
+func newAlbumServer(t *testing.T, albumCalls *int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "test-token", TokenType: "Bearer", ExpiresIn: 3600})
+	})
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("type") {
+		case "album":
+			if q.Get("q") != "album:Blonde artist:Frank Ocean" {
+				t.Errorf("q = %q", q.Get("q"))
+			}
+			w.Write([]byte(`{"albums": {"items": [
+				{"id": "alb-live", "name": "Blonde (Live)", "artists": [{"name": "Frank Ocean"}]},
+				{"id": "alb-1", "name": "Blonde", "artists": [{"name": "Frank Ocean"}]}
+			]}}`))
+		default:
+			w.Write([]byte(`{"tracks": {"items": [
+				{"name": "Godspeed", "track_number": 1, "disc_number": 2, "artists": [{"name": "Frank Ocean"}],
+				 "album": {"id": "alb-1", "name": "Blonde", "total_tracks": 3, "artists": [{"name": "Frank Ocean"}]}}
+			]}}`))
+		}
+	})
+	mux.HandleFunc("/v1/albums/alb-1", func(w http.ResponseWriter, r *http.Request) {
+		if albumCalls != nil {
+			*albumCalls++
+		}
+		w.Write([]byte(`{"id": "alb-1", "name": "Blonde", "album_type": "album", "label": "Boys Don't Cry",
+			"external_ids": {"upc": "0000000000001"}, "artists": [{"name": "Frank Ocean"}],
+			"tracks": {"total": 3, "items": [
+				{"name": "Nikes", "track_number": 1, "disc_number": 1},
+				{"name": "Ivy", "track_number": 2, "disc_number": 1}
+			]}}`))
+	})
+	mux.HandleFunc("/v1/albums/alb-1/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") != "2" {
+			t.Errorf("offset = %q, want 2", r.URL.Query().Get("offset"))
+		}
+		w.Write([]byte(`{"total": 3, "items": [{"name": "Godspeed", "track_number": 1, "disc_number": 2}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolveAlbum(t *testing.T) {
+	srv := newAlbumServer(t, nil)
+	client := New("test-id", "test-secret")
+	client.tokenURL = srv.URL + "/api/token"
+	client.apiURL = srv.URL + "/v1"
+
+	var _ metadata.AlbumResolver = client
+	tl, found, err := client.ResolveAlbum(context.Background(), "Blonde", "Frank Ocean")
+	if err != nil || !found {
+		t.Fatalf("ResolveAlbum: found=%v err=%v", found, err)
+	}
+	if tl.ID != "alb-1" || tl.Title != "Blonde" || tl.Artist != "Frank Ocean" {
+		t.Errorf("tracklist = %+v", tl)
+	}
+	if tl.Release.Label != "Boys Don't Cry" || tl.Release.Barcode != "0000000000001" {
+		t.Errorf("release = %+v", tl.Release)
+	}
+	want := []metadata.ReleaseTrack{
+		{TrackNumber: 1, DiscNumber: 1, Title: "Nikes"},
+		{TrackNumber: 2, DiscNumber: 1, Title: "Ivy"},
+		{TrackNumber: 1, DiscNumber: 2, Title: "Godspeed"},
+	}
+	if len(tl.Tracks) != len(want) {
+		t.Fatalf("tracks = %+v", tl.Tracks)
+	}
+	for i, w := range want {
+		if tl.Tracks[i] != w {
+			t.Errorf("track %d = %+v, want %+v", i, tl.Tracks[i], w)
+		}
+	}
+}
+
+func TestAlbumDetails(t *testing.T) {
+	var albumCalls int
+	srv := newAlbumServer(t, &albumCalls)
+	client := New("test-id", "test-secret")
+	client.tokenURL = srv.URL + "/api/token"
+	client.apiURL = srv.URL + "/v1"
+
+	results, err := client.Search(context.Background(), metadata.SearchQuery{Title: "Godspeed", Artist: "Frank Ocean"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if albumCalls != 0 {
+		t.Errorf("search looked up %d albums, want none", albumCalls)
+	}
+
+	var _ metadata.AlbumDetailsResolver = client
+	for range 2 {
+		r, err := client.AlbumDetails(context.Background(), results[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.TotalTracks != 1 || r.TotalDiscs != 2 {
+			t.Errorf("totals = %d tracks, %d discs; want 1 track on disc 2 of 2", r.TotalTracks, r.TotalDiscs)
+		}
+		if r.Release.Label != "Boys Don't Cry" || r.Release.Barcode != "0000000000001" {
+			t.Errorf("release = %+v", r.Release)
+		}
+	}
+	if albumCalls != 1 {
+		t.Errorf("album looked up %d times, want 1 (cached)", albumCalls)
+	}
+}
+
+func TestResolveAlbumCandidates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "test-token", TokenType: "Bearer", ExpiresIn: 3600})
+	})
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"albums": {"items": [
+			{"id": "deluxe", "name": "Blonde (Deluxe)"},
+			{"id": "other", "name": "Channel Orange"},
+			{"id": "std", "name": "Blonde"}
+		]}}`))
+	})
+	mux.HandleFunc("/v1/albums/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v1/albums/")
+		fmt.Fprintf(w, `{"id": %q, "name": "Blonde", "tracks": {"total": 1, "items": [
+			{"name": "Nikes", "track_number": 1, "disc_number": 1, "duration_ms": 314000}
+		]}}`, id)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := New("test-id", "test-secret")
+	client.tokenURL = srv.URL + "/api/token"
+	client.apiURL = srv.URL + "/v1"
+
+	var _ metadata.AlbumCandidates = client
+	tls, err := client.ResolveAlbumCandidates(context.Background(), "Blonde", "Frank Ocean", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tls) != 2 || tls[0].ID != "std" || tls[1].ID != "deluxe" {
+		t.Fatalf("candidates = %+v, want std then deluxe", tls)
+	}
+	if d := tls[0].Tracks[0].Duration; d != 314*time.Second {
+		t.Errorf("track length = %v, want 5m14s", d)
+	}
+}
+
+func TestResolveAlbumCandidates_SkipsFailedLookups(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing map[string]bool
+		want    []string
+		wantErr bool
+	}{
+		{"one lookup fails", map[string]bool{"std": true}, []string{"std-2", "deluxe"}, false},
+		{"other releases are skipped", nil, []string{"std", "deluxe"}, false},
+		{"every lookup fails", map[string]bool{"std": true, "std-2": true, "deluxe": true}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/token", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(tokenResponse{AccessToken: "test-token", TokenType: "Bearer", ExpiresIn: 3600})
+			})
+			mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"albums": {"items": [
+					{"id": "std", "name": "Blonde", "total_tracks": 1},
+					{"id": "std-2", "name": "Blonde", "total_tracks": 1},
+					{"id": "deluxe", "name": "Blonde (Deluxe)", "total_tracks": 2}
+				]}}`))
+			})
+			mux.HandleFunc("/v1/albums/", func(w http.ResponseWriter, r *http.Request) {
+				id := strings.TrimPrefix(r.URL.Path, "/v1/albums/")
+				if tt.failing[id] {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+				fmt.Fprintf(w, `{"id": %q, "name": "Blonde", "tracks": {"total": 1, "items": [
+					{"name": "Nikes", "track_number": 1, "disc_number": 1}
+				]}}`, id)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			client := New("test-id", "test-secret")
+			client.tokenURL = srv.URL + "/api/token"
+			client.apiURL = srv.URL + "/v1"
+
+			tls, err := client.ResolveAlbumCandidates(context.Background(), "Blonde", "Frank Ocean", 5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, tl := range tls {
+				ids = append(ids, tl.ID)
+			}
+			// std-2 is another release of the std edition, looked up only
+			// when std fails.
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("candidates = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
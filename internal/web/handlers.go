@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"ytmusic/internal/config"
 	"ytmusic/internal/pipeline"
 	"ytmusic/pkg/utils"
 )
@@ -183,6 +185,7 @@ func (s *Server) processJob(job *Job) {
 		OnWarning: func(msg string) {
 			warningMsg = msg
 		},
+		ReportPath: config.GetReportPath(time.Now().Format(config.RunIDFormat) + "_" + job.ID),
 	}
 
 	if err := pipeline.Run(ctx, job.Config, jobLog, tempDir, hooks); err != nil {